@@ -0,0 +1,60 @@
+// Command isengo-server serves the WebAurion client as a REST/JSON API.
+//
+// Usage:
+//
+//	isengo-server [-addr :8080] [-campus isen-ouest] [-cas]
+//
+// Open a session with POST /v1/sessions, then send the returned token as
+// "Authorization: Bearer <token>". The OpenAPI document is served on /v1/openapi.json.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/CorentinMre/isengo/webaurion"
+	"github.com/CorentinMre/isengo/webaurion/campus"
+	"github.com/CorentinMre/isengo/webaurion/server"
+)
+
+func main() {
+	config := server.DefaultConfig()
+	addr := flag.String("addr", ":8080", "address to listen on")
+	campusKey := flag.String("campus", envOr("ISENGO_CAMPUS", campus.Default), "campus key or JSON campus profile file")
+	cas := flag.Bool("cas", false, "log in to WebAurion through the CAS")
+	flag.DurationVar(&config.GradesTTL, "grades-ttl", config.GradesTTL, "cache duration of the grades")
+	flag.DurationVar(&config.AbsencesTTL, "absences-ttl", config.AbsencesTTL, "cache duration of the absences")
+	flag.DurationVar(&config.PlanningTTL, "planning-ttl", config.PlanningTTL, "cache duration of the planning")
+	flag.DurationVar(&config.CatalogTTL, "catalog-ttl", config.CatalogTTL, "cache duration of the catalogs")
+	flag.DurationVar(&config.StaleWhileRevalidate, "stale", config.StaleWhileRevalidate, "how long expired values are served while they are refreshed")
+	flag.DurationVar(&config.SessionIdle, "session-idle", config.SessionIdle, "sessions unused for longer are closed")
+	flag.Parse()
+
+	if _, err := campus.Load(*campusKey); err != nil {
+		log.Fatal(err)
+	}
+	var authenticator webaurion.Authenticator
+	if *cas {
+		authenticator = webaurion.CASLogin{}
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           server.New(config, server.NewLogin(*campusKey, authenticator)),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	log.Printf("isengo-server listening on %s", *addr)
+	log.Fatal(srv.ListenAndServe())
+}
+
+// envOr returns the environment variable key, or fallback when it is empty.
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
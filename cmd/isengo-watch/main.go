@@ -0,0 +1,281 @@
+// Command isengo-watch polls WebAurion and sends a notification when a grade,
+// an absence or the planning changes.
+//
+// Usage:
+//
+//	isengo-watch [flags]
+//
+// Credentials are read from the ISENGO_USERNAME and ISENGO_PASSWORD
+// environment variables, or from the -u and -p flags. A session of a browser
+// can be reused with -cookie instead, and another campus than ISEN-Ouest is
+// selected with -campus, a registered key or a JSON profile file. The last known state is
+// kept in a store file so that restarting the daemon doesn't notify twice.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/CorentinMre/isengo/webaurion"
+	"github.com/CorentinMre/isengo/webaurion/campus"
+	"github.com/CorentinMre/isengo/webaurion/notify"
+	"github.com/CorentinMre/isengo/webaurion/store"
+)
+
+func main() {
+	username := flag.String("u", os.Getenv("ISENGO_USERNAME"), "WebAurion username")
+	password := flag.String("p", os.Getenv("ISENGO_PASSWORD"), "WebAurion password")
+	cas := flag.Bool("cas", false, "log in to WebAurion through the CAS")
+	cookie := flag.String("cookie", os.Getenv("ISENGO_COOKIE"), "WebAurion session cookie (JSESSIONID) copied from a browser, instead of -u/-p")
+	campusKey := flag.String("campus", envOr("ISENGO_CAMPUS", campus.Default), "campus key or JSON campus profile file")
+	storePath := flag.String("store", "isengo-watch.db", "database keeping the last known state")
+	gradesEvery := flag.Duration("grades", time.Hour, "grades polling interval (0 to disable)")
+	absencesEvery := flag.Duration("absences", 6*time.Hour, "absences polling interval (0 to disable)")
+	planningEvery := flag.Duration("planning", time.Hour, "planning polling interval (0 to disable)")
+	once := flag.Bool("once", false, "poll everything once and exit")
+
+	stdout := flag.Bool("stdout", false, "print notifications on stdout")
+	stdoutJSON := flag.Bool("stdout-json", false, "print notifications on stdout as JSON lines")
+	webhookURL := flag.String("webhook", "", "URL receiving every notification as a JSON POST")
+	discordURL := flag.String("discord", "", "Discord (or compatible) webhook URL")
+	smtpAddr := flag.String("smtp", "", "SMTP server (host:port) used to send emails")
+	smtpUser := flag.String("smtp-user", os.Getenv("ISENGO_SMTP_USER"), "SMTP username")
+	smtpPassword := flag.String("smtp-password", os.Getenv("ISENGO_SMTP_PASSWORD"), "SMTP password")
+	smtpFrom := flag.String("smtp-from", "", "sender of the emails")
+	smtpTo := flag.String("smtp-to", "", "comma separated recipients of the emails")
+	flag.Parse()
+
+	logger := log.New(os.Stderr, "isengo-watch: ", log.LstdFlags)
+
+	var notifiers []notify.Notifier
+	if *stdout || *stdoutJSON {
+		notifiers = append(notifiers, &notify.StdoutNotifier{JSON: *stdoutJSON})
+	}
+	if *webhookURL != "" {
+		notifiers = append(notifiers, notify.NewWebhookNotifier(*webhookURL))
+	}
+	if *discordURL != "" {
+		notifiers = append(notifiers, notify.NewDiscordNotifier(*discordURL))
+	}
+	if *smtpAddr != "" {
+		if *smtpFrom == "" || *smtpTo == "" {
+			logger.Fatal("-smtp requires -smtp-from and -smtp-to")
+		}
+		notifiers = append(notifiers, notify.NewSMTPNotifier(*smtpAddr, *smtpUser, *smtpPassword, *smtpFrom, strings.Split(*smtpTo, ",")))
+	}
+	if len(notifiers) == 0 {
+		notifiers = append(notifiers, &notify.StdoutNotifier{})
+	}
+
+	if _, err := campus.Load(*campusKey); err != nil {
+		logger.Fatal(err)
+	}
+	var authenticator webaurion.Authenticator
+	switch {
+	case *cookie != "":
+		authenticator = webaurion.CookieLogin{Cookie: *cookie}
+	case *username == "" || *password == "":
+		logger.Fatal("missing credentials (use -u/-p or ISENGO_USERNAME/ISENGO_PASSWORD, or -cookie)")
+	case *cas:
+		authenticator = webaurion.CASLogin{}
+	}
+
+	st, err := store.Open(*storePath)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer st.Close()
+
+	wt := &watcher{
+		username:      *username,
+		password:      *password,
+		campus:        *campusKey,
+		authenticator: authenticator,
+		store:         st,
+		notifier:      notify.Multi(notifiers...),
+		logger:        logger,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	jobs := []job{
+		{name: "grades", every: *gradesEvery, poll: wt.pollGrades},
+		{name: "absences", every: *absencesEvery, poll: wt.pollAbsences},
+		{name: "planning", every: *planningEvery, poll: wt.pollPlanning},
+	}
+	wt.run(ctx, jobs, *once)
+}
+
+// envOr returns the environment variable key, or fallback when it is empty.
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// job is a resource polled on its own schedule.
+type job struct {
+	name  string
+	every time.Duration
+	poll  func(ctx context.Context) error
+}
+
+// watcher polls WebAurion, saves the snapshots and notifies the changes.
+// Jobs run one at a time since a WebAurion session can't be shared between requests.
+type watcher struct {
+	username      string
+	password      string
+	campus        string // key of a registered campus
+	authenticator webaurion.Authenticator
+	session       *webaurion.WebAurion
+	store         *store.Store
+	notifier      notify.Notifier
+	logger        *log.Logger
+}
+
+func (wt *watcher) run(ctx context.Context, jobs []job, once bool) {
+	next := make([]time.Time, len(jobs))
+	for {
+		now := time.Now()
+		wait := time.Duration(-1)
+		for i, j := range jobs {
+			if j.every <= 0 && !once {
+				continue
+			}
+			if !now.Before(next[i]) {
+				if err := j.poll(ctx); err != nil {
+					wt.logger.Printf("%s: %v", j.name, err)
+				}
+				next[i] = time.Now().Add(j.every)
+			}
+			if d := time.Until(next[i]); wait < 0 || d < wait {
+				wait = d
+			}
+		}
+
+		if once || wait < 0 {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// fetch runs f with a valid session, logging in again once if it fails.
+func (wt *watcher) fetch(f func(w *webaurion.WebAurion) error) error {
+	if wt.session == nil || !wt.session.LoggedIn {
+		if err := wt.login(); err != nil {
+			return err
+		}
+	}
+	if err := f(wt.session); err == nil {
+		return nil
+	}
+
+	// the session may have expired
+	if err := wt.login(); err != nil {
+		return err
+	}
+	return f(wt.session)
+}
+
+func (wt *watcher) login() error {
+	w, err := webaurion.NewWebAurionForCampus(wt.campus)
+	if err != nil {
+		return err
+	}
+	w.Authenticator = wt.authenticator
+	if _, err := w.Login(wt.username, wt.password); err != nil {
+		return err
+	}
+	wt.session = w
+	return nil
+}
+
+// send delivers the notifications, unless it is the first snapshot of the resource
+// (everything would be new).
+func (wt *watcher) send(ctx context.Context, kind store.Kind, notifications []notify.Notification) {
+	snapshots, err := wt.store.Snapshots(kind, time.Time{}, time.Time{})
+	if err != nil {
+		wt.logger.Printf("%s: %v", kind, err)
+		return
+	}
+	if len(snapshots) <= 1 {
+		wt.logger.Printf("%s: first snapshot saved", kind)
+		return
+	}
+	for _, n := range notifications {
+		if err := wt.notifier.Notify(ctx, n); err != nil {
+			wt.logger.Printf("%s: %v", kind, err)
+		}
+	}
+}
+
+func (wt *watcher) pollGrades(ctx context.Context) error {
+	var report *webaurion.GradeReport
+	err := wt.fetch(func(w *webaurion.WebAurion) (err error) {
+		report, err = w.GetGrades()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	changes, err := wt.store.SaveGrades(report, now)
+	if err != nil {
+		return fmt.Errorf("error saving grades: %v", err)
+	}
+	wt.send(ctx, store.KindGrades, notify.GradeNotifications(changes, now))
+	return nil
+}
+
+func (wt *watcher) pollAbsences(ctx context.Context) error {
+	var report *webaurion.AbsenceReport
+	err := wt.fetch(func(w *webaurion.WebAurion) (err error) {
+		report, err = w.GetAbsences()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	changes, err := wt.store.SaveAbsences(report, now)
+	if err != nil {
+		return fmt.Errorf("error saving absences: %v", err)
+	}
+	wt.send(ctx, store.KindAbsences, notify.AbsenceNotifications(changes, now))
+	return nil
+}
+
+func (wt *watcher) pollPlanning(ctx context.Context) error {
+	var report *webaurion.PlanningReport
+	err := wt.fetch(func(w *webaurion.WebAurion) (err error) {
+		report, err = w.GetPlanning()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	changes, err := wt.store.SavePlanning(report, now)
+	if err != nil {
+		return fmt.Errorf("error saving planning: %v", err)
+	}
+	wt.send(ctx, store.KindPlanning, notify.PlanningNotifications(changes, now))
+	return nil
+}
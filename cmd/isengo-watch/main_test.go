@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/CorentinMre/isengo/webaurion"
+	"github.com/CorentinMre/isengo/webaurion/notify"
+	"github.com/CorentinMre/isengo/webaurion/store"
+)
+
+type recorder []notify.Notification
+
+func (r *recorder) Notify(ctx context.Context, n notify.Notification) error {
+	*r = append(*r, n)
+	return nil
+}
+
+func TestSendSkipsFirstSnapshot(t *testing.T) {
+	st, err := store.Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.Close()
+
+	sent := &recorder{}
+	wt := &watcher{store: st, notifier: sent, logger: log.New(io.Discard, "", 0)}
+	save := func(at time.Time, codes ...string) {
+		var grades []webaurion.Grade
+		for _, code := range codes {
+			grades = append(grades, webaurion.Grade{Code: code, Name: code, Grade: 15})
+		}
+		changes, err := st.SaveGrades(webaurion.NewGradeReport(15, grades), at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wt.send(context.Background(), store.KindGrades, notify.GradeNotifications(changes, at))
+	}
+
+	now := time.Now()
+	save(now, "A", "B")
+	if len(*sent) != 0 {
+		t.Fatalf("the first snapshot shouldn't be notified, got %d notifications", len(*sent))
+	}
+	save(now.Add(time.Hour), "A", "B", "C")
+	if len(*sent) == 0 {
+		t.Fatal("the new grade should be notified")
+	}
+}
+
+func TestRunOnce(t *testing.T) {
+	wt := &watcher{logger: log.New(io.Discard, "", 0)}
+	polled := map[string]int{}
+	poll := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			polled[name]++
+			return nil
+		}
+	}
+
+	// -once polls every job, even the disabled ones, and returns
+	wt.run(context.Background(), []job{
+		{name: "grades", every: time.Hour, poll: poll("grades")},
+		{name: "planning", every: 0, poll: poll("planning")},
+	}, true)
+	if polled["grades"] != 1 || polled["planning"] != 1 {
+		t.Errorf("unexpected polls: %v", polled)
+	}
+}
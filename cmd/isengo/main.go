@@ -0,0 +1,321 @@
+// Command isengo is a small command line client for WebAurion and Moodle.
+//
+// Usage:
+//
+//	isengo <command> [flags]
+//
+// Credentials are read from the ISENGO_USERNAME and ISENGO_PASSWORD
+// environment variables, or from the -u and -p flags. A session of a browser
+// can be reused with -cookie instead. Another campus than ISEN-Ouest is
+// selected with -campus, a registered key or a JSON profile file.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/CorentinMre/isengo/webaurion"
+	"github.com/CorentinMre/isengo/webaurion/campus"
+)
+
+const usage = `usage: isengo <command> [flags]
+
+commands:
+  grades       print your grades
+  absences     print your absences
+  planning     print your planning
+  simulate     simulate averages with hypothetical grades
+  moodle-sync  mirror the resources of your Moodle courses in a directory
+
+run "isengo <command> -h" for the flags of a command`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "grades":
+		err = runGrades(os.Args[2:])
+	case "absences":
+		err = runAbsences(os.Args[2:])
+	case "planning":
+		err = runPlanning(os.Args[2:])
+	case "simulate":
+		err = runSimulate(os.Args[2:])
+	case "moodle-sync":
+		err = runMoodleSync(os.Args[2:])
+	case "-h", "--help", "help":
+		fmt.Println(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n%s\n", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// credentials holds the login flags shared by every command.
+type credentials struct {
+	username string
+	password string
+	cas      bool
+	cookie   string
+	campus   string
+}
+
+func (c *credentials) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.username, "u", os.Getenv("ISENGO_USERNAME"), "WebAurion username")
+	fs.StringVar(&c.password, "p", os.Getenv("ISENGO_PASSWORD"), "WebAurion password")
+	fs.BoolVar(&c.cas, "cas", false, "log in to WebAurion through the CAS")
+	fs.StringVar(&c.cookie, "cookie", os.Getenv("ISENGO_COOKIE"), "WebAurion session cookie (JSESSIONID) copied from a browser, instead of -u/-p")
+	fs.StringVar(&c.campus, "campus", envOr("ISENGO_CAMPUS", campus.Default), "campus key or JSON campus profile file")
+}
+
+// envOr returns the environment variable key, or fallback when it is empty.
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// profile returns the campus of the -campus flag.
+func (c *credentials) profile() (*campus.Campus, error) {
+	return campus.Load(c.campus)
+}
+
+func (c *credentials) login() (*webaurion.WebAurion, error) {
+	if _, err := c.profile(); err != nil {
+		return nil, err
+	}
+	w, err := webaurion.NewWebAurionForCampus(c.campus)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case c.cookie != "":
+		w.Authenticator = webaurion.CookieLogin{Cookie: c.cookie}
+	case c.username == "" || c.password == "":
+		return nil, fmt.Errorf("missing credentials (use -u/-p or ISENGO_USERNAME/ISENGO_PASSWORD)")
+	case c.cas:
+		w.Authenticator = webaurion.CASLogin{}
+	}
+
+	if _, err := w.Login(c.username, c.password); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func runGrades(args []string) error {
+	fs := flag.NewFlagSet("grades", flag.ExitOnError)
+	var creds credentials
+	creds.register(fs)
+	fs.Parse(args)
+
+	w, err := creds.login()
+	if err != nil {
+		return err
+	}
+	grades, err := w.GetGrades()
+	if err != nil {
+		return err
+	}
+	fmt.Println(grades.JSON())
+	return nil
+}
+
+func runAbsences(args []string) error {
+	fs := flag.NewFlagSet("absences", flag.ExitOnError)
+	var creds credentials
+	creds.register(fs)
+	fs.Parse(args)
+
+	w, err := creds.login()
+	if err != nil {
+		return err
+	}
+	absences, err := w.GetAbsences()
+	if err != nil {
+		return err
+	}
+	fmt.Println(absences.JSON())
+	return nil
+}
+
+func runPlanning(args []string) error {
+	fs := flag.NewFlagSet("planning", flag.ExitOnError)
+	var creds credentials
+	creds.register(fs)
+	fs.Parse(args)
+
+	w, err := creds.login()
+	if err != nil {
+		return err
+	}
+	planning, err := w.GetPlanning()
+	if err != nil {
+		return err
+	}
+	fmt.Println(planning.JSON())
+	return nil
+}
+
+// moodleLogin logs in to Moodle through the CAS, with the same credentials as WebAurion.
+func (c *credentials) moodleLogin() (*webaurion.Moodle, error) {
+	if c.username == "" || c.password == "" {
+		return nil, fmt.Errorf("missing credentials (use -u/-p or ISENGO_USERNAME/ISENGO_PASSWORD)")
+	}
+
+	profile, err := c.profile()
+	if err != nil {
+		return nil, err
+	}
+	if profile.MoodleURL == "" {
+		return nil, fmt.Errorf("campus %s has no Moodle", profile.Name)
+	}
+	m := webaurion.NewMoodleForCampus(profile)
+	if _, err := m.Login(c.username, c.password); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func runMoodleSync(args []string) error {
+	fs := flag.NewFlagSet("moodle-sync", flag.ExitOnError)
+	var creds credentials
+	creds.register(fs)
+	course := fs.String("course", "", "course ID or part of its name (default every course, one directory each)")
+	dir := fs.String("dir", ".", "mirror directory")
+	jobs := fs.Int("j", 4, "parallel downloads")
+	fs.Parse(args)
+
+	m, err := creds.moodleLogin()
+	if err != nil {
+		return err
+	}
+	options := webaurion.MirrorOptions{Concurrency: *jobs}
+
+	var reports []*webaurion.MirrorReport
+	if *course == "" {
+		reports, err = m.MirrorCourses(*dir, options)
+	} else {
+		id, convErr := strconv.Atoi(*course)
+		if convErr != nil {
+			courses, err := m.GetCourses()
+			if err != nil {
+				return err
+			}
+			found := courses.Find(*course)
+			if found == nil {
+				return fmt.Errorf("no course matching %q", *course)
+			}
+			id = found.ID
+		}
+		var report *webaurion.MirrorReport
+		report, err = m.MirrorCourse(id, *dir, options)
+		reports = append(reports, report)
+	}
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, report := range reports {
+		fmt.Println(report.JSON())
+		failed += len(report.Failed)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d files failed, run the command again to retry them", failed)
+	}
+	return nil
+}
+
+// listFlag collects a repeatable string flag.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func runSimulate(args []string) error {
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	var creds credentials
+	creds.register(fs)
+	var sets, remaining listFlag
+	coefFile := fs.String("coef", "", "JSON file mapping evaluation codes (or code prefixes) to coefficients")
+	target := fs.Float64("target", -1, "target average to solve for (requires -remaining)")
+	module := fs.String("module", "", "restrict -target to one module code")
+	fs.Var(&sets, "set", "hypothetical grade, as CODE=GRADE (repeatable)")
+	fs.Var(&remaining, "remaining", "code of an evaluation still to come (repeatable)")
+	fs.Parse(args)
+
+	coefficients := webaurion.CoefficientTable{}
+	if *coefFile != "" {
+		data, err := os.ReadFile(*coefFile)
+		if err != nil {
+			return fmt.Errorf("error reading coefficients: %v", err)
+		}
+		if err := json.Unmarshal(data, &coefficients); err != nil {
+			return fmt.Errorf("error parsing coefficients: %v", err)
+		}
+	}
+
+	hypothetical := make(map[string]float64)
+	for _, set := range sets {
+		code, value, ok := strings.Cut(set, "=")
+		if !ok {
+			return fmt.Errorf("invalid -set %q, expected CODE=GRADE", set)
+		}
+		grade, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+		if err != nil {
+			return fmt.Errorf("invalid grade in -set %q: %v", set, err)
+		}
+		hypothetical[code] = grade
+	}
+
+	w, err := creds.login()
+	if err != nil {
+		return err
+	}
+	grades, err := w.GetGrades()
+	if err != nil {
+		return err
+	}
+
+	simulator := webaurion.NewGradeSimulator(grades, coefficients)
+	result, err := simulator.Simulate(hypothetical)
+	if err != nil {
+		return err
+	}
+	fmt.Println(result.JSON())
+
+	if *target >= 0 {
+		var required float64
+		if *module != "" {
+			required, err = simulator.RequiredModuleGrade(*module, *target, remaining...)
+		} else {
+			required, err = simulator.RequiredGrade(*target, remaining...)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Minimum grade needed on %s to reach %.2f: %.2f\n", strings.Join(remaining, ", "), *target, required)
+	}
+	return nil
+}
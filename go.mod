@@ -4,9 +4,14 @@ go 1.23.0
 
 toolchain go1.23.1
 
-require github.com/PuerkitoBio/goquery v1.10.0
+require (
+	github.com/PuerkitoBio/goquery v1.10.0
+	go.etcd.io/bbolt v1.3.11
+	golang.org/x/text v0.23.0
+)
 
 require (
 	github.com/andybalholm/cascadia v1.3.3 // indirect
 	golang.org/x/net v0.38.0 // indirect
+	golang.org/x/sys v0.31.0 // indirect
 )
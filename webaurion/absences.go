@@ -0,0 +1,147 @@
+package webaurion
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// durations look like "02:00" (sometimes "2h00")
+var absenceDurationRegex = regexp.MustCompile(`^\s*(\d{1,3})\s*[:h]\s*(\d{2})\s*$`)
+
+// schedules look like "08:00 - 10:00" (sometimes "de 08h00 à 10h00")
+var absenceScheduleRegex = regexp.MustCompile(`(\d{1,2})[:h](\d{2})\D+(\d{1,2})[:h](\d{2})`)
+
+// ParsedDuration returns the duration of the Absence.
+func (a *Absence) ParsedDuration() (time.Duration, error) {
+	match := absenceDurationRegex.FindStringSubmatch(a.Duration)
+	if match == nil {
+		return 0, fmt.Errorf("invalid duration: %q", a.Duration)
+	}
+
+	hours, _ := strconv.Atoi(match[1])
+	minutes, _ := strconv.Atoi(match[2])
+	if minutes > 59 {
+		return 0, fmt.Errorf("invalid duration: %q", a.Duration)
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+}
+
+// ParsedSchedule returns the start and end of the Absence in Europe/Paris.
+func (a *Absence) ParsedSchedule() (time.Time, time.Time, error) {
+	date, err := a.ParsedDate()
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	match := absenceScheduleRegex.FindStringSubmatch(a.Schedule)
+	if match == nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid schedule: %q", a.Schedule)
+	}
+
+	var values [4]int
+	for i := range values {
+		values[i], _ = strconv.Atoi(match[i+1])
+	}
+	if values[0] > 23 || values[1] > 59 || values[2] > 23 || values[3] > 59 {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid schedule: %q", a.Schedule)
+	}
+
+	start := time.Date(date.Year(), date.Month(), date.Day(), values[0], values[1], 0, 0, date.Location())
+	end := time.Date(date.Year(), date.Month(), date.Day(), values[2], values[3], 0, 0, date.Location())
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid schedule: %q ends before it starts", a.Schedule)
+	}
+	return start, end, nil
+}
+
+// IsJustified reports whether the reason of the Absence says it is justified.
+func (a *Absence) IsJustified() bool {
+	reason := strings.ToLower(removeAccents(a.Reason))
+	if strings.Contains(reason, "non justifi") || strings.Contains(reason, "injustifi") {
+		return false
+	}
+	return strings.Contains(reason, "justifi")
+}
+
+// absenceWarnings returns a warning for every absence whose duration or schedule can't be parsed.
+func absenceWarnings(absences []Absence) []string {
+	var warnings []string
+	for i, absence := range absences {
+		if _, err := absence.ParsedDuration(); err != nil {
+			warnings = append(warnings, fmt.Sprintf("row %d (%s, %s): %v", i, absence.Date, absence.Subject, err))
+		}
+		if _, _, err := absence.ParsedSchedule(); err != nil {
+			warnings = append(warnings, fmt.Sprintf("row %d (%s, %s): %v", i, absence.Date, absence.Subject, err))
+		}
+	}
+	return warnings
+}
+
+// TotalDuration returns the total duration of the absences.
+func (ar *AbsenceReport) TotalDuration() time.Duration {
+	var total time.Duration
+	for _, absence := range ar.Data {
+		duration, _ := absence.ParsedDuration()
+		total += duration
+	}
+	return total
+}
+
+// aggregate sums the absence durations by key.
+func (ar *AbsenceReport) aggregate(key func(Absence) string) map[string]time.Duration {
+	totals := make(map[string]time.Duration)
+	for _, absence := range ar.Data {
+		duration, err := absence.ParsedDuration()
+		if err != nil {
+			continue
+		}
+		totals[key(absence)] += duration
+	}
+	return totals
+}
+
+// DurationByReason returns the total duration of the absences by reason.
+func (ar *AbsenceReport) DurationByReason() map[string]time.Duration {
+	return ar.aggregate(func(a Absence) string { return a.Reason })
+}
+
+// DurationByJustification returns the total duration of the justified and unjustified absences.
+func (ar *AbsenceReport) DurationByJustification() (justified, unjustified time.Duration) {
+	for _, absence := range ar.Data {
+		duration, err := absence.ParsedDuration()
+		if err != nil {
+			continue
+		}
+		if absence.IsJustified() {
+			justified += duration
+		} else {
+			unjustified += duration
+		}
+	}
+	return justified, unjustified
+}
+
+// DurationByCourse returns the total duration of the absences by course.
+func (ar *AbsenceReport) DurationByCourse() map[string]time.Duration {
+	return ar.aggregate(func(a Absence) string { return a.Course })
+}
+
+// DurationBySubject returns the total duration of the absences by subject.
+func (ar *AbsenceReport) DurationBySubject() map[string]time.Duration {
+	return ar.aggregate(func(a Absence) string { return a.Subject })
+}
+
+// DurationByWeek returns the total duration of the absences by ISO week ("2024-W07").
+// Absences with an unparsable date are grouped under "unknown".
+func (ar *AbsenceReport) DurationByWeek() map[string]time.Duration {
+	return ar.aggregate(func(a Absence) string {
+		date, err := ParseFrenchDate(a.Date, orParis(ar.Location))
+		if err != nil {
+			return "unknown"
+		}
+		return isoWeek(date)
+	})
+}
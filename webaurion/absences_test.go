@@ -0,0 +1,90 @@
+package webaurion
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsedDuration(t *testing.T) {
+	tests := []struct {
+		duration string
+		want     time.Duration
+		ok       bool
+	}{
+		{"02:00", 2 * time.Hour, true},
+		{"2h00", 2 * time.Hour, true},
+		{" 1 h 30 ", 90 * time.Minute, true},
+		{"120:15", 120*time.Hour + 15*time.Minute, true},
+		{"02:75", 0, false},
+		{"2h", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		absence := Absence{Duration: tt.duration}
+		got, err := absence.ParsedDuration()
+		if (err == nil) != tt.ok || got != tt.want {
+			t.Errorf("ParsedDuration(%q) = %v, %v, want %v (ok=%v)", tt.duration, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParsedSchedule(t *testing.T) {
+	tests := []struct {
+		date     string
+		schedule string
+		start    string
+		end      string
+		ok       bool
+	}{
+		{"15/01/2024", "08:00 - 10:00", "08:00", "10:00", true},
+		{"15/01/2024", "de 08h00 à 10h00", "08:00", "10:00", true},
+		{"15/01/2024", "13h30-15h45", "13:30", "15:45", true},
+		{"15/01/2024", "08:60 - 10:00", "", "", false},
+		{"15/01/2024", "24:00 - 25:00", "", "", false},
+		{"15/01/2024", "10:00 - 08:00", "", "", false}, // ends before it starts
+		{"15/01/2024", "le matin", "", "", false},
+		{"lundi", "08:00 - 10:00", "", "", false},
+	}
+	for _, tt := range tests {
+		absence := Absence{Date: tt.date, Schedule: tt.schedule}
+		start, end, err := absence.ParsedSchedule()
+		if (err == nil) != tt.ok {
+			t.Errorf("ParsedSchedule(%q, %q): got error %v, want ok=%v", tt.date, tt.schedule, err, tt.ok)
+			continue
+		}
+		if tt.ok && (start.Format("15:04") != tt.start || end.Format("15:04") != tt.end || start.Location() != ParisLocation || start.Day() != 15) {
+			t.Errorf("ParsedSchedule(%q) = %v, %v", tt.schedule, start, end)
+		}
+	}
+}
+
+func TestAbsenceAggregations(t *testing.T) {
+	report := NewAbsenceReport(4, 0, []Absence{
+		{Date: "15/01/2024", Duration: "02:00", Schedule: "08:00 - 10:00", Reason: "Absence justifiée", Course: "Cours", Subject: "Maths"},
+		{Date: "17/01/2024", Duration: "1h30", Schedule: "10:00 - 11:30", Reason: "Absence non justifiée", Course: "TP", Subject: "Maths"},
+		{Date: "22/01/2024", Duration: "01:00", Schedule: "14:00 - 15:00", Reason: "Absence non justifiée", Course: "Cours", Subject: "Physique"},
+		{Date: "23/01/2024", Duration: "?", Schedule: "?", Reason: "Absence justifiée", Course: "Cours", Subject: "Physique"},
+	})
+	report.Warnings = absenceWarnings(report.Data)
+
+	if got := report.TotalDuration(); got != 4*time.Hour+30*time.Minute {
+		t.Errorf("TotalDuration() = %v", got)
+	}
+	justified, unjustified := report.DurationByJustification()
+	if justified != 2*time.Hour || unjustified != 150*time.Minute {
+		t.Errorf("DurationByJustification() = %v, %v", justified, unjustified)
+	}
+	if got := report.DurationBySubject(); got["Maths"] != 210*time.Minute || got["Physique"] != time.Hour {
+		t.Errorf("DurationBySubject() = %v", got)
+	}
+	if got := report.DurationByCourse(); got["Cours"] != 3*time.Hour || got["TP"] != 90*time.Minute {
+		t.Errorf("DurationByCourse() = %v", got)
+	}
+	if got := report.DurationByWeek(); got["2024-W03"] != 210*time.Minute || got["2024-W04"] != time.Hour {
+		t.Errorf("DurationByWeek() = %v", got)
+	}
+	// the duration and the schedule of the last row are invalid
+	if len(report.Warnings) != 2 {
+		t.Errorf("got warnings %v", report.Warnings)
+	}
+}
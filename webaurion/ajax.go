@@ -0,0 +1,55 @@
+package webaurion
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/CorentinMre/isengo/webaurion/partial"
+)
+
+// PostPartial sends an AJAX request to a page of WebAurion (e.g. "/webAurion/faces/Planning.xhtml")
+// and decodes its partial response. A new ViewState in the response replaces the one of the
+// session, the server errors and the redirects are returned as errors with the response.
+func (w *WebAurion) PostPartial(page string, payload url.Values) (*partial.Response, error) {
+	req, err := http.NewRequest("POST", w.BaseURL+page, strings.NewReader(payload.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("error creating AJAX request: %v", err)
+	}
+	w.setRequestHeaders(req)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=UTF-8")
+	req.Header.Set("Faces-Request", "partial/ajax")
+	req.Header.Set("X-Requested-With", "XMLHttpRequest")
+	req.Header.Set("Accept", "application/xml, text/xml, */*; q=0.01")
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("AJAX request failed with proxy %s: %v", w.getCurrentProxy(), err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading AJAX response: %v", err)
+	}
+	response, err := partial.Parse(body)
+	if err != nil {
+		return nil, err
+	}
+	if response.ViewState != "" {
+		w.setViewState(response.ViewState)
+	}
+	return response, response.Err()
+}
+
+// setViewState replaces the ViewState of the session, in ViewState and in the payload of the
+// main page.
+func (w *WebAurion) setViewState(viewState string) {
+	w.ViewState = viewState
+	if form := w.pageForm(); form.Has("javax.faces.ViewState") {
+		form.Set("javax.faces.ViewState", viewState)
+		w.Payload = form.Encode()
+	}
+}
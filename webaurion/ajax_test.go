@@ -0,0 +1,51 @@
+package webaurion
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestPostPartial(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	current := "vs-1" // the server only accepts its last ViewState
+	mux.HandleFunc("/webAurion/faces/Planning.xhtml", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Faces-Request") != "partial/ajax" || r.FormValue("javax.faces.ViewState") != current {
+			fmt.Fprint(w, `<partial-response><error><error-name>ViewExpiredException</error-name></error></partial-response>`)
+			return
+		}
+		current = "vs-2"
+		fmt.Fprint(w, `<partial-response><changes>
+			<update id="form:schedule"><![CDATA[{"events":[]}]]></update>
+			<update id="j_id1:javax.faces.ViewState:0"><![CDATA[vs-2]]></update>
+		</changes></partial-response>`)
+	})
+
+	w := NewWebAurion()
+	w.BaseURL = server.URL
+	w.ViewState = "vs-1"
+	w.Payload = "form=form&javax.faces.ViewState=vs-1&form%3AidInit=init-1"
+
+	response, err := w.PostPartial("/webAurion/faces/Planning.xhtml", url.Values{"javax.faces.ViewState": {"vs-1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := response.Update("form:schedule"); content != `{"events":[]}` {
+		t.Errorf("got update %q", content)
+	}
+	// the next requests are sent with the new ViewState
+	if w.ViewState != "vs-2" || w.PayloadValue("javax.faces.ViewState") != "vs-2" || w.PayloadValue("form:idInit") != "init-1" {
+		t.Errorf("got ViewState %q, payload %q", w.ViewState, w.Payload)
+	}
+
+	// a stale ViewState is refused
+	_, err = w.PostPartial("/webAurion/faces/Planning.xhtml", url.Values{"javax.faces.ViewState": {"vs-1"}})
+	if err == nil || !strings.Contains(err.Error(), "ViewExpiredException") {
+		t.Errorf("got %v, want the server error", err)
+	}
+}
@@ -0,0 +1,272 @@
+package webaurion
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Severity is the level of an AbsenceAlert.
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityCritical
+)
+
+var severityNames = map[Severity]string{
+	SeverityInfo:     "info",
+	SeverityWarning:  "warning",
+	SeverityCritical: "critical",
+}
+
+// String returns the name of the Severity.
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// MarshalJSON implements the json.Marshaler interface for Severity.
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for Severity.
+func (s *Severity) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	for severity, severityName := range severityNames {
+		if severityName == name {
+			*s = severity
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid severity: %s", name)
+}
+
+// RuleScope tells how a rule groups absences before comparing them to its threshold.
+type RuleScope string
+
+const (
+	// ScopeTotal compares the total of all the absences.
+	ScopeTotal RuleScope = "total"
+	// ScopeModule compares the total of each subject.
+	ScopeModule RuleScope = "module"
+	// ScopeSemester compares the total of each semester.
+	ScopeSemester RuleScope = "semester"
+)
+
+// jsonDuration is a time.Duration written in JSON as a duration string ("4h", "1h30m").
+// A number is read as hours.
+type jsonDuration time.Duration
+
+// MarshalJSON implements the json.Marshaler interface for jsonDuration.
+func (d jsonDuration) MarshalJSON() ([]byte, error) {
+	text := time.Duration(d).String()
+	if strings.HasSuffix(text, "m0s") {
+		text = strings.TrimSuffix(text, "0s")
+	}
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+	return json.Marshal(text)
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for jsonDuration.
+func (d *jsonDuration) UnmarshalJSON(data []byte) error {
+	var hours float64
+	if err := json.Unmarshal(data, &hours); err == nil {
+		*d = jsonDuration(hours * float64(time.Hour))
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("invalid duration: %s", data)
+	}
+	duration, err := time.ParseDuration(text)
+	if err != nil {
+		return fmt.Errorf("invalid duration: %v", err)
+	}
+	*d = jsonDuration(duration)
+	return nil
+}
+
+// AbsenceRule triggers an alert when the absences of a scope exceed a threshold.
+// In JSON, the threshold is a duration string ("4h") or a number of hours.
+type AbsenceRule struct {
+	Name            string        `json:"name"`
+	Scope           RuleScope     `json:"scope"`
+	Threshold       time.Duration `json:"threshold"`
+	UnjustifiedOnly bool          `json:"unjustifiedOnly"`
+	Severity        Severity      `json:"severity"`
+}
+
+// DefaultAbsenceRules returns a set of rules that can be used as a starting point.
+// The thresholds are indicative, check the rules of your own campus.
+func DefaultAbsenceRules() []AbsenceRule {
+	return []AbsenceRule{
+		{Name: "unjustified hours per module", Scope: ScopeModule, Threshold: 4 * time.Hour, UnjustifiedOnly: true, Severity: SeverityWarning},
+		{Name: "unjustified hours per semester", Scope: ScopeSemester, Threshold: 10 * time.Hour, UnjustifiedOnly: true, Severity: SeverityWarning},
+		{Name: "unjustified hours per semester", Scope: ScopeSemester, Threshold: 20 * time.Hour, UnjustifiedOnly: true, Severity: SeverityCritical},
+		{Name: "total hours", Scope: ScopeTotal, Threshold: 30 * time.Hour, Severity: SeverityInfo},
+	}
+}
+
+// MarshalJSON implements the json.Marshaler interface for AbsenceRule.
+func (r AbsenceRule) MarshalJSON() ([]byte, error) {
+	type rule AbsenceRule
+	return json.Marshal(struct {
+		rule
+		Threshold jsonDuration `json:"threshold"`
+	}{rule(r), jsonDuration(r.Threshold)})
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for AbsenceRule.
+func (r *AbsenceRule) UnmarshalJSON(data []byte) error {
+	type rule AbsenceRule
+	var decoded struct {
+		rule
+		Threshold jsonDuration `json:"threshold"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*r = AbsenceRule(decoded.rule)
+	r.Threshold = time.Duration(decoded.Threshold)
+	return nil
+}
+
+// AbsenceAlert is a rule triggered by an AbsenceReport.
+type AbsenceAlert struct {
+	Rule      string        `json:"rule"`
+	Severity  Severity      `json:"severity"`
+	Scope     RuleScope     `json:"scope"`
+	Key       string        `json:"key,omitempty"` // subject or semester, empty for ScopeTotal
+	Duration  time.Duration `json:"duration"`
+	Threshold time.Duration `json:"threshold"`
+}
+
+// MarshalJSON implements the json.Marshaler interface for AbsenceAlert, the durations are
+// duration strings like in AbsenceRule.
+func (aa AbsenceAlert) MarshalJSON() ([]byte, error) {
+	type alert AbsenceAlert
+	return json.Marshal(struct {
+		alert
+		Duration  jsonDuration `json:"duration"`
+		Threshold jsonDuration `json:"threshold"`
+	}{alert(aa), jsonDuration(aa.Duration), jsonDuration(aa.Threshold)})
+}
+
+// String returns a string representation of the AbsenceAlert.
+func (aa *AbsenceAlert) String() string {
+	return fmt.Sprintf("AbsenceAlert(rule='%s', severity='%s', scope='%s', key='%s', duration=%s, threshold=%s)",
+		aa.Rule, aa.Severity, aa.Scope, aa.Key, aa.Duration, aa.Threshold)
+}
+
+// Semester returns the academic semester of a date, e.g. "2024-2025 S1".
+// S1 runs from September to January, S2 from February to August.
+func Semester(date time.Time) string {
+	year := date.Year()
+	switch {
+	case date.Month() >= time.September:
+		return fmt.Sprintf("%d-%d S1", year, year+1)
+	case date.Month() == time.January:
+		return fmt.Sprintf("%d-%d S1", year-1, year)
+	default:
+		return fmt.Sprintf("%d-%d S2", year-1, year)
+	}
+}
+
+// Evaluate returns the alerts triggered by the report, most severe first.
+func (ar *AbsenceReport) Evaluate(rules []AbsenceRule) ([]AbsenceAlert, error) {
+	alerts := []AbsenceAlert{}
+
+	for _, rule := range rules {
+		totals := make(map[string]time.Duration)
+		for _, absence := range ar.Data {
+			if rule.UnjustifiedOnly && absence.IsJustified() {
+				continue
+			}
+			duration, err := absence.ParsedDuration()
+			if err != nil {
+				continue
+			}
+
+			var key string
+			switch rule.Scope {
+			case ScopeTotal:
+			case ScopeModule:
+				key = absence.Subject
+			case ScopeSemester:
+				date, err := ParseFrenchDate(absence.Date, orParis(ar.Location))
+				if err != nil {
+					continue
+				}
+				key = Semester(date)
+			default:
+				return nil, fmt.Errorf("invalid scope for rule %s: %s", rule.Name, rule.Scope)
+			}
+			totals[key] += duration
+		}
+
+		for key, duration := range totals {
+			if duration > rule.Threshold {
+				alerts = append(alerts, AbsenceAlert{
+					Rule:      rule.Name,
+					Severity:  rule.Severity,
+					Scope:     rule.Scope,
+					Key:       key,
+					Duration:  duration,
+					Threshold: rule.Threshold,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(alerts, func(i, j int) bool {
+		if alerts[i].Severity != alerts[j].Severity {
+			return alerts[i].Severity > alerts[j].Severity
+		}
+		if alerts[i].Rule != alerts[j].Rule {
+			return alerts[i].Rule < alerts[j].Rule
+		}
+		return alerts[i].Key < alerts[j].Key
+	})
+	return alerts, nil
+}
+
+// absenceKey identifies an absence across two snapshots.
+func absenceKey(a Absence) string {
+	return a.Date + "|" + a.Schedule + "|" + a.Course + "|" + a.Subject
+}
+
+// NewlyRecordedAbsences returns the absences of current that were not in previous.
+func NewlyRecordedAbsences(previous, current *AbsenceReport) []Absence {
+	known := make(map[string]int)
+	if previous != nil {
+		for _, absence := range previous.Data {
+			known[absenceKey(absence)]++
+		}
+	}
+
+	added := []Absence{}
+	if current == nil {
+		return added
+	}
+	for _, absence := range current.Data {
+		key := absenceKey(absence)
+		if known[key] > 0 {
+			known[key]--
+			continue
+		}
+		added = append(added, absence)
+	}
+	return added
+}
@@ -0,0 +1,120 @@
+package webaurion
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAbsenceRuleJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		json      string
+		threshold time.Duration
+		written   string
+	}{
+		{"duration string", `{"name":"r","scope":"module","threshold":"1h30m","severity":"warning"}`, 90 * time.Minute, `"threshold":"1h30m"`},
+		{"hours", `{"name":"r","scope":"module","threshold":4,"severity":"warning"}`, 4 * time.Hour, `"threshold":"4h"`},
+		{"decimal hours", `{"name":"r","scope":"module","threshold":0.5,"severity":"warning"}`, 30 * time.Minute, `"threshold":"30m"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rule AbsenceRule
+			if err := json.Unmarshal([]byte(tt.json), &rule); err != nil {
+				t.Fatal(err)
+			}
+			if rule.Threshold != tt.threshold || rule.Scope != ScopeModule || rule.Severity != SeverityWarning {
+				t.Errorf("got %+v", rule)
+			}
+
+			data, err := json.Marshal(rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data), tt.written) {
+				t.Errorf("got %s, want %s", data, tt.written)
+			}
+			var again AbsenceRule
+			if err := json.Unmarshal(data, &again); err != nil || again != rule {
+				t.Errorf("round trip: got %+v, %v", again, err)
+			}
+		})
+	}
+
+	var rule AbsenceRule
+	for _, invalid := range []string{`{"threshold":"beaucoup"}`, `{"threshold":true}`, `{"severity":"fatal"}`} {
+		if err := json.Unmarshal([]byte(invalid), &rule); err == nil {
+			t.Errorf("%s: want an error", invalid)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	report := NewAbsenceReport(5, 0, []Absence{
+		{Date: "10/10/2024", Duration: "03:00", Reason: "Absence non justifiée", Subject: "Maths"},
+		{Date: "12/11/2024", Duration: "02:00", Reason: "Absence non justifiée", Subject: "Maths"},
+		{Date: "15/01/2025", Duration: "04:00", Reason: "Absence justifiée", Subject: "Physique"},
+		{Date: "10/03/2025", Duration: "02:00", Reason: "Absence non justifiée", Subject: "Physique"},
+		{Date: "11/03/2025", Duration: "?", Reason: "Absence non justifiée", Subject: "Physique"},
+	})
+
+	tests := []struct {
+		name string
+		rule AbsenceRule
+		want []string // "severity/key/duration"
+	}{
+		{"total", AbsenceRule{Scope: ScopeTotal, Threshold: 10 * time.Hour, Severity: SeverityInfo}, []string{"info//11h0m0s"}},
+		{"total under threshold", AbsenceRule{Scope: ScopeTotal, Threshold: 11 * time.Hour, Severity: SeverityInfo}, nil},
+		{"unjustified per module", AbsenceRule{Scope: ScopeModule, Threshold: 4 * time.Hour, UnjustifiedOnly: true, Severity: SeverityWarning}, []string{"warning/Maths/5h0m0s"}},
+		{"per module", AbsenceRule{Scope: ScopeModule, Threshold: 4 * time.Hour, Severity: SeverityWarning}, []string{"warning/Maths/5h0m0s", "warning/Physique/6h0m0s"}},
+		{"per semester", AbsenceRule{Scope: ScopeSemester, Threshold: 5 * time.Hour, Severity: SeverityCritical}, []string{"critical/2024-2025 S1/9h0m0s"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alerts, err := report.Evaluate([]AbsenceRule{tt.rule})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, alert := range alerts {
+				got = append(got, alert.Severity.String()+"/"+alert.Key+"/"+alert.Duration.String())
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// most severe first
+	alerts, err := report.Evaluate([]AbsenceRule{tests[0].rule, tests[2].rule, tests[4].rule})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 3 || alerts[0].Severity != SeverityCritical || alerts[2].Severity != SeverityInfo {
+		t.Errorf("got alerts %v", alerts)
+	}
+
+	if _, err := report.Evaluate([]AbsenceRule{{Name: "weekly", Scope: "week"}}); err == nil {
+		t.Error("Evaluate should refuse an unknown scope")
+	}
+}
+
+func TestNewlyRecordedAbsences(t *testing.T) {
+	first := Absence{Date: "10/10/2024", Schedule: "08:00 - 10:00", Subject: "Maths"}
+	second := Absence{Date: "11/10/2024", Schedule: "08:00 - 10:00", Subject: "Maths"}
+	previous := NewAbsenceReport(1, 0, []Absence{first})
+	// the same absence recorded twice counts once more
+	current := NewAbsenceReport(3, 0, []Absence{first, first, second})
+
+	added := NewlyRecordedAbsences(previous, current)
+	if len(added) != 2 || added[0] != first || added[1] != second {
+		t.Errorf("got %+v", added)
+	}
+	if added := NewlyRecordedAbsences(nil, previous); len(added) != 1 {
+		t.Errorf("without a previous report: got %+v", added)
+	}
+	if added := NewlyRecordedAbsences(previous, nil); len(added) != 0 {
+		t.Errorf("without a current report: got %+v", added)
+	}
+}
@@ -0,0 +1,132 @@
+package webaurion
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+)
+
+// Authenticator opens a WebAurion session in the client of w. WebAurion.Login uses
+// w.Authenticator, FormLogin when it is nil.
+type Authenticator interface {
+	Authenticate(w *WebAurion, username, password string) error
+}
+
+// FormLogin posts the credentials to the login form of WebAurion.
+type FormLogin struct {
+	Path   string     // path of the form action, "/webAurion/login" when empty
+	Fields url.Values // other fields of the form, the login button of the campus when nil
+}
+
+// Authenticate implements Authenticator.
+func (f FormLogin) Authenticate(w *WebAurion, username, password string) error {
+	path := f.Path
+	if path == "" {
+		path = "/webAurion/login"
+	}
+	payload := url.Values{}
+	if f.Fields == nil {
+		payload.Set(w.campus().Components.LoginButton, "")
+	}
+	for name, values := range f.Fields {
+		payload[name] = values
+	}
+	payload.Set("username", username)
+	payload.Set("password", password)
+
+	req, err := http.NewRequest("POST", w.BaseURL+path, strings.NewReader(payload.Encode()))
+	if err != nil {
+		return errors.New("error creating login request")
+	}
+
+	w.setRequestHeaders(req)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error during login request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	w.Cookies = resp.Cookies()
+	return nil
+}
+
+// CASLogin logs in through a CAS server, e.g. when WebAurion redirects to auth3.isen-ouest.fr.
+// The login ticket and the execution of the CAS form are scraped before posting the credentials.
+type CASLogin struct {
+	CASURL  string // CAS of the campus when empty
+	Service string // service of the ticket, the WebAurion home page when empty
+}
+
+// Authenticate implements Authenticator.
+func (c CASLogin) Authenticate(w *WebAurion, username, password string) error {
+	casURL := c.CASURL
+	if casURL == "" {
+		casURL = w.campus().CASURL
+	}
+	service := c.Service
+	if service == "" {
+		service = w.BaseURL + "/webAurion/"
+	}
+	_, err := casLogin(w.Client, casURL, service, username, password)
+	return err
+}
+
+// CookieLogin reuses a session opened in a browser: Cookie is a Cookie header
+// ("JSESSIONID=...; ...") or the value of the JSESSIONID cookie alone. The username and
+// the password are ignored.
+type CookieLogin struct {
+	Cookie string
+}
+
+// Authenticate implements Authenticator.
+func (c CookieLogin) Authenticate(w *WebAurion, username, password string) error {
+	header := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(c.Cookie), "Cookie:"))
+	if header == "" {
+		return fmt.Errorf("no cookie to import")
+	}
+	if !strings.Contains(header, "=") {
+		header = "JSESSIONID=" + header
+	}
+	cookies := (&http.Request{Header: http.Header{"Cookie": {header}}}).Cookies()
+	if len(cookies) == 0 {
+		return fmt.Errorf("invalid cookie: %q", c.Cookie)
+	}
+
+	u, err := url.Parse(w.BaseURL)
+	if err != nil {
+		return fmt.Errorf("invalid base URL: %v", err)
+	}
+	if w.Client.Jar == nil {
+		w.Client.Jar, _ = cookiejar.New(nil)
+	}
+	for _, cookie := range cookies {
+		cookie.Path = "/"
+	}
+	w.Client.Jar.SetCookies(u, cookies)
+	w.Cookies = cookies
+	return nil
+}
+
+// retryable reports whether a failed login with auth is worth retrying: an imported cookie
+// stays invalid.
+func retryable(auth Authenticator) bool {
+	switch auth.(type) {
+	case CookieLogin, *CookieLogin:
+		return false
+	}
+	return true
+}
+
+// loginError is the error of a login with auth that didn't open a session.
+func loginError(auth Authenticator) error {
+	switch auth.(type) {
+	case CookieLogin, *CookieLogin:
+		return errors.New("session cookie invalid or expired, copy it again from the browser")
+	}
+	return errors.New("username or password incorrect")
+}
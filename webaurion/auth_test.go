@@ -0,0 +1,191 @@
+package webaurion
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// mainPage is the main page of a fake WebAurion, with the links and the sidebar of
+// ISEN-Ouest. The "Divers" submenu is loaded on demand.
+const mainPage = `<html><body><form id="form">
+	<div class="menuMonCompte"><h3>Jean Dupont</h3><img src="/webAurion/photo.jpg"><a id="form:profile" href="#">Mon compte</a></div>
+	<a id="form:internship" class="lien-cliquable" href="#">Mes notes de stage</a>
+	<a id="form:grades" class="lien-cliquable" href="#">Mes notes</a>
+	<a id="form:absences" class="lien-cliquable" href="#">Mes Absences</a>
+	<a id="form:planning" class="lien-cliquable" href="#">Mon Planning</a>
+	<div id="form:sidebar"><ul>
+		<li class="ui-menu-parent submenu_6"><a href="#"><span class="ui-menuitem-text">Divers</span></a><ul></ul></li>
+	</ul></div>
+	<input type="hidden" name="form" value="form">
+	<input type="hidden" name="form:largeurDivCenter" value="1200">
+	<input type="hidden" name="form:idInit" value="init-1">
+	<input type="hidden" name="form:j_idt774:j_idt776_page" value="0">
+	<input type="hidden" name="form:j_idt822:j_idt825_view" value="basicDay">
+	<input type="hidden" name="javax.faces.ViewState" value="vs-1">
+</form></body></html>`
+
+// submenuResponse is the partial response opening the "Divers" submenu.
+const submenuResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<partial-response><changes>
+<update id="form:sidebar"><![CDATA[<div id="form:sidebar"><ul>
+	<li class="ui-menu-parent submenu_6"><a href="#"><span class="ui-menuitem-text">Divers</span></a><ul>
+		<li class="ui-menuitem"><a href="#" onclick="PrimeFaces.addSubmitParam('form',{'form:sidebar':'form:sidebar','form:sidebar_menuid':'6_0'}).submit('form');return false;"><span class="ui-menuitem-text">Catalogue des stages</span></a></li>
+	</ul></li>
+</ul></div>]]></update>
+<update id="j_id1:javax.faces.ViewState:0"><![CDATA[vs-2]]></update>
+</changes></partial-response>`
+
+// catalogPage is the page of the internship catalog, its search is run with AJAX requests.
+const catalogPage = `<html><body><h1>Catalogue des stages</h1><form id="form">
+	<div id="form:j_idt193" class="ui-datatable"><table>
+		<thead><tr><th>Entreprise</th><th>Ville</th><th>Code postal</th><th>Année</th></tr></thead>
+		<tbody id="form:j_idt193_data">
+			<tr data-ri="0"><td>Ecole Navale</td><td>Brest</td><td>29200</td><td>2025</td></tr>
+			<tr data-ri="1"><td>Thales</td><td>Rennes</td><td>35000</td><td>2025</td></tr>
+		</tbody>
+	</table></div>
+	<input type="hidden" name="form:idInit" value="init-2">
+	<input type="hidden" name="javax.faces.ViewState" value="vs-catalog">
+</form></body></html>`
+
+// searchResponse is the partial response of a search in the catalog, WebAurion ignores the
+// accents of the filters.
+const searchResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<partial-response><changes>
+<update id="form:j_idt193"><![CDATA[<tr data-ri="0"><td>École Navale</td><td>Brest</td><td>29200</td><td>2025</td></tr>]]></update>
+<update id="j_id1:javax.faces.ViewState:0"><![CDATA[vs-search]]></update>
+<extension ln="primefaces" type="args">{"totalRecords":1}</extension>
+</changes></partial-response>`
+
+// fakeWebAurion is a WebAurion server with the account "user"/"secret" and the session
+// cookie "JSESSIONID=s1", logged in with its form or with a CAS.
+type fakeWebAurion struct {
+	*httptest.Server
+	CAS       *httptest.Server
+	mainPages int          // requests of the main page
+	posts     []url.Values // forms posted to the pages
+}
+
+func newFakeWebAurion(t *testing.T) *fakeWebAurion {
+	t.Helper()
+	f := &fakeWebAurion{}
+	mux := http.NewServeMux()
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Server.Close)
+
+	mux.HandleFunc("/webAurion/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("username") == "user" && r.FormValue("password") == "secret" {
+			http.SetCookie(w, &http.Cookie{Name: "JSESSIONID", Value: "s1", Path: "/"})
+		}
+		http.Redirect(w, r, "/webAurion/", http.StatusFound)
+	})
+	mux.HandleFunc("/webAurion/", func(w http.ResponseWriter, r *http.Request) {
+		f.mainPages++
+		if r.URL.Query().Get("ticket") == "ST-1" {
+			http.SetCookie(w, &http.Cookie{Name: "JSESSIONID", Value: "s1", Path: "/"})
+		} else if cookie, err := r.Cookie("JSESSIONID"); err != nil || cookie.Value != "s1" {
+			fmt.Fprint(w, `<html><body><form action="/webAurion/login"><input name="username"><input type="password" name="password"></form></body></html>`)
+			return
+		}
+		fmt.Fprint(w, mainPage)
+	})
+
+	mux.HandleFunc("/webAurion/faces/MainMenuPage.xhtml", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		f.posts = append(f.posts, r.PostForm)
+		if r.PostForm.Get("javax.faces.partial.ajax") != "true" {
+			fmt.Fprint(w, catalogPage)
+			return
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprint(w, submenuResponse)
+	})
+	mux.HandleFunc("/webAurion/faces/ChoixEvenementDUnFormulaire.xhtml", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		f.posts = append(f.posts, r.PostForm)
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprint(w, searchResponse)
+	})
+
+	cas := http.NewServeMux()
+	f.CAS = httptest.NewServer(cas)
+	t.Cleanup(f.CAS.Close)
+	cas.HandleFunc("/cas/login", func(w http.ResponseWriter, r *http.Request) {
+		service := r.URL.Query().Get("service")
+		if r.Method == "POST" && r.FormValue("username") == "user" && r.FormValue("password") == "secret" && r.FormValue("execution") == "e1s1" {
+			http.Redirect(w, r, service+"?ticket=ST-1", http.StatusFound)
+			return
+		}
+		fmt.Fprintf(w, `<html><body><form method="post" action="/cas/login?service=%s">
+			<input type="hidden" name="execution" value="e1s1">
+			<input type="text" name="username"><input type="password" name="password">
+			</form></body></html>`, url.QueryEscape(service))
+	})
+	return f
+}
+
+// client returns a WebAurion client of the fake server.
+func (f *fakeWebAurion) client(auth Authenticator) *WebAurion {
+	w := NewWebAurion()
+	jar, _ := cookiejar.New(nil)
+	w.Client = &http.Client{Jar: jar}
+	w.BaseURL = f.URL
+	w.Campus.BaseURL = f.URL
+	w.Campus.CASURL = f.CAS.URL + "/cas"
+	w.Authenticator = auth
+	return w
+}
+
+func TestAuthenticators(t *testing.T) {
+	tests := []struct {
+		name     string
+		auth     Authenticator
+		password string
+		err      string
+	}{
+		{"form", nil, "secret", ""},
+		{"form with wrong password", FormLogin{}, "wrong", "username or password incorrect"},
+		{"CAS", CASLogin{}, "secret", ""},
+		{"CAS with wrong password", CASLogin{}, "wrong", "username or password incorrect"},
+		{"cookie", CookieLogin{Cookie: "s1"}, "", ""},
+		{"cookie header", CookieLogin{Cookie: "Cookie: lang=fr; JSESSIONID=s1"}, "", ""},
+		{"expired cookie", CookieLogin{Cookie: "JSESSIONID=expired"}, "", "session cookie invalid or expired"},
+		{"no cookie", CookieLogin{}, "", "no cookie to import"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeWebAurion(t)
+			w := f.client(tt.auth)
+			ok, err := w.LoginWithRetry("user", tt.password, 1)
+			if tt.err != "" {
+				if ok || err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("got %v, %v, want error %q", ok, err, tt.err)
+				}
+				return
+			}
+			if !ok || err != nil {
+				t.Fatalf("login failed: %v", err)
+			}
+			if !w.LoggedIn || w.ViewState != "vs-1" || w.Name != "Jean Dupont" {
+				t.Errorf("unexpected session: loggedIn=%v viewState=%q name=%q", w.LoggedIn, w.ViewState, w.Name)
+			}
+		})
+	}
+}
+
+func TestCookieLoginIsNotRetried(t *testing.T) {
+	f := newFakeWebAurion(t)
+	w := f.client(CookieLogin{Cookie: "expired"})
+	_, err := w.Login("", "")
+	if err == nil || !strings.Contains(err.Error(), "cookie") || strings.Contains(err.Error(), "attempts") {
+		t.Errorf("got error %v", err)
+	}
+	if f.mainPages != 1 {
+		t.Errorf("the main page was requested %d times, want 1", f.mainPages)
+	}
+}
@@ -0,0 +1,185 @@
+package cache
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry is a value stored by a Backend.
+type Entry struct {
+	StoredAt time.Time       `json:"storedAt"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// Backend persists the cached values, e.g. to survive a restart.
+type Backend interface {
+	Load(key string) (*Entry, error) // nil without error when the key is unknown
+	Save(key string, entry *Entry) error
+	Delete(key string) error
+	Clear() error
+}
+
+// DiskBackend stores one JSON file per key in a directory.
+type DiskBackend struct {
+	Dir string
+}
+
+// NewDiskBackend creates a new instance of DiskBackend, creating dir if needed
+func NewDiskBackend(dir string) (*DiskBackend, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("error creating cache directory: %v", err)
+	}
+	return &DiskBackend{Dir: dir}, nil
+}
+
+func (d *DiskBackend) path(key string) string {
+	return filepath.Join(d.Dir, url.PathEscape(key)+".json")
+}
+
+func (d *DiskBackend) Load(key string) (*Entry, error) {
+	data, err := os.ReadFile(d.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading cache entry: %v", err)
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("error decoding cache entry: %v", err)
+	}
+	return &entry, nil
+}
+
+// Save writes the entry atomically (temporary file then rename).
+func (d *DiskBackend) Save(key string, entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error encoding cache entry: %v", err)
+	}
+	tmp, err := os.CreateTemp(d.Dir, ".entry-*")
+	if err != nil {
+		return fmt.Errorf("error writing cache entry: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing cache entry: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing cache entry: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), d.path(key)); err != nil {
+		return fmt.Errorf("error writing cache entry: %v", err)
+	}
+	return nil
+}
+
+func (d *DiskBackend) Delete(key string) error {
+	if err := os.Remove(d.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("error deleting cache entry: %v", err)
+	}
+	return nil
+}
+
+// Clear deletes every entry of the directory.
+func (d *DiskBackend) Clear() error {
+	files, err := filepath.Glob(filepath.Join(d.Dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("error listing cache entries: %v", err)
+	}
+	for _, file := range files {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("error deleting cache entry: %v", err)
+		}
+	}
+	return nil
+}
+
+// LRU is an in-memory cache evicting the least recently used values.
+type LRU struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // front = most recently used
+	items    map[string]*list.Element
+}
+
+type lruItem struct {
+	key      string
+	value    interface{}
+	storedAt time.Time
+}
+
+// NewLRU creates a new instance of LRU. A capacity <= 0 means unbounded.
+func NewLRU(capacity int) *LRU {
+	return &LRU{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the value of key and when it was stored.
+func (l *LRU) Get(key string) (interface{}, time.Time, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	element, ok := l.items[key]
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	l.order.MoveToFront(element)
+	item := element.Value.(*lruItem)
+	return item.value, item.storedAt, true
+}
+
+// Set stores the value of key, evicting the least recently used value when full.
+func (l *LRU) Set(key string, value interface{}, storedAt time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if element, ok := l.items[key]; ok {
+		element.Value = &lruItem{key: key, value: value, storedAt: storedAt}
+		l.order.MoveToFront(element)
+		return
+	}
+	l.items[key] = l.order.PushFront(&lruItem{key: key, value: value, storedAt: storedAt})
+	if l.capacity > 0 && l.order.Len() > l.capacity {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.items, oldest.Value.(*lruItem).key)
+	}
+}
+
+// Delete removes the value of key.
+func (l *LRU) Delete(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if element, ok := l.items[key]; ok {
+		l.order.Remove(element)
+		delete(l.items, key)
+	}
+}
+
+// Keys returns the cached keys, most recently used first.
+func (l *LRU) Keys() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	keys := make([]string, 0, l.order.Len())
+	for element := l.order.Front(); element != nil; element = element.Next() {
+		keys = append(keys, element.Value.(*lruItem).key)
+	}
+	return keys
+}
+
+// Len returns the number of cached values.
+func (l *LRU) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
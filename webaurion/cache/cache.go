@@ -0,0 +1,413 @@
+// Package cache wraps a WebAurion client so that fetchers can be called freely:
+// results are kept for a per-resource TTL in an in-memory LRU (and optionally on disk),
+// refreshed in the background once stale, and served from the last snapshot when
+// WebAurion fails.
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CorentinMre/isengo/webaurion"
+	cat "github.com/CorentinMre/isengo/webaurion/catalog"
+)
+
+// Resource is a kind of cached value, each resource has its own TTL.
+type Resource string
+
+const (
+	ResourceUserInfo Resource = "userinfo"
+	ResourceGrades   Resource = "grades"
+	ResourceAbsences Resource = "absences"
+	ResourcePlanning Resource = "planning"
+	ResourceCatalogs Resource = "catalogs" // catalog list, entries and details
+)
+
+// cache keys of the resources
+const (
+	KeyUserInfo = string(ResourceUserInfo)
+	KeyGrades   = string(ResourceGrades)
+	KeyAbsences = string(ResourceAbsences)
+	KeyPlanning = string(ResourcePlanning)
+	KeyCatalogs = string(ResourceCatalogs)
+)
+
+// CatalogKey returns the cache key of the entries of a catalog.
+func CatalogKey(idx int) string {
+	return fmt.Sprintf("catalogs/%d", idx)
+}
+
+// CatalogEntryKey returns the cache key of the details of a catalog entry.
+func CatalogEntryKey(idx, row int) string {
+	return fmt.Sprintf("catalogs/%d/entries/%d", idx, row)
+}
+
+// resourceOf returns the resource of a cache key.
+func resourceOf(key string) Resource {
+	resource, _, _ := strings.Cut(key, "/")
+	return Resource(resource)
+}
+
+// Config holds the cache settings.
+type Config struct {
+	// TTL of every resource, a missing or <= 0 TTL disables the cache of the resource
+	TTL map[Resource]time.Duration
+	// how long after its TTL a value is still served while it is refreshed in the background
+	StaleWhileRevalidate time.Duration
+	// serve the last snapshot (even expired) when WebAurion fails
+	ServeStaleOnError bool
+	// maximum number of values kept in memory (<= 0 means unbounded)
+	Capacity int
+	// optional persistent storage of the values
+	Backend Backend
+}
+
+// DefaultConfig returns the default cache settings, without a Backend.
+func DefaultConfig() Config {
+	return Config{
+		TTL: map[Resource]time.Duration{
+			ResourceUserInfo: 24 * time.Hour,
+			ResourceGrades:   15 * time.Minute,
+			ResourceAbsences: 15 * time.Minute,
+			ResourcePlanning: 15 * time.Minute,
+			ResourceCatalogs: time.Hour,
+		},
+		StaleWhileRevalidate: time.Hour,
+		ServeStaleOnError:    true,
+		Capacity:             128,
+	}
+}
+
+// Cache wraps a logged in WebAurion client. It is safe for concurrent use: the client
+// keeps a JSF ViewState, so the requests to WebAurion run one at a time.
+type Cache struct {
+	Config Config
+	// OnError is called with the errors that don't reach the caller: failed background
+	// refreshes, stale values served instead of an error, and Backend errors
+	OnError func(key string, err error)
+
+	clientMu sync.Mutex
+	client   *webaurion.WebAurion
+	location *time.Location // of the campus of client
+	memory   *LRU
+	now      func() time.Time
+
+	mu         sync.Mutex
+	refreshing map[string]bool
+	generation map[string]int // bumped by invalidations so that a running refresh doesn't store an old value
+	wg         sync.WaitGroup
+}
+
+// New creates a new instance of Cache
+func New(w *webaurion.WebAurion, config Config) *Cache {
+	return &Cache{
+		Config:     config,
+		client:     w,
+		location:   w.GetCampus().Location(),
+		memory:     NewLRU(config.Capacity),
+		now:        time.Now,
+		refreshing: make(map[string]bool),
+		generation: make(map[string]int),
+	}
+}
+
+// Location returns the timezone of the campus of the wrapped client.
+func (c *Cache) Location() *time.Location {
+	return c.location
+}
+
+// Do runs f with the wrapped client, one call at a time, bypassing the cache.
+func (c *Cache) Do(f func(w *webaurion.WebAurion) error) error {
+	c.clientMu.Lock()
+	defer c.clientMu.Unlock()
+	return f(c.client)
+}
+
+// Invalidate drops the cached values of keys, e.g. KeyGrades or CatalogKey(0).
+func (c *Cache) Invalidate(keys ...string) {
+	for _, key := range keys {
+		c.mu.Lock()
+		c.generation[key]++
+		c.mu.Unlock()
+
+		c.memory.Delete(key)
+		if c.Config.Backend != nil {
+			if err := c.Config.Backend.Delete(key); err != nil {
+				c.report(key, err)
+			}
+		}
+	}
+}
+
+// InvalidateAll drops every cached value.
+func (c *Cache) InvalidateAll() {
+	c.Invalidate(c.memory.Keys()...)
+	if c.Config.Backend != nil {
+		if err := c.Config.Backend.Clear(); err != nil {
+			c.report("", err)
+		}
+	}
+}
+
+// Wait waits for the background refreshes to finish.
+func (c *Cache) Wait() {
+	c.wg.Wait()
+}
+
+// Age returns how long ago the value of key was fetched, false when it isn't cached.
+func (c *Cache) Age(key string) (time.Duration, bool) {
+	if _, storedAt, ok := c.memory.Get(key); ok {
+		return c.now().Sub(storedAt), true
+	}
+	if c.Config.Backend != nil {
+		if entry, err := c.Config.Backend.Load(key); err == nil && entry != nil {
+			return c.now().Sub(entry.StoredAt), true
+		}
+	}
+	return 0, false
+}
+
+func (c *Cache) report(key string, err error) {
+	if c.OnError != nil {
+		c.OnError(key, err)
+	}
+}
+
+// lookup returns the cached value of key from memory, or from the Backend.
+func lookup[T any](c *Cache, key string) (value T, storedAt time.Time, ok bool) {
+	if v, storedAt, ok := c.memory.Get(key); ok {
+		if value, ok := v.(T); ok {
+			return value, storedAt, true
+		}
+	}
+	if c.Config.Backend == nil {
+		return value, storedAt, false
+	}
+
+	entry, err := c.Config.Backend.Load(key)
+	if err != nil {
+		c.report(key, err)
+		return value, storedAt, false
+	}
+	if entry == nil {
+		return value, storedAt, false
+	}
+	if err := json.Unmarshal(entry.Data, &value); err != nil {
+		c.report(key, fmt.Errorf("error decoding cache entry: %v", err))
+		return value, storedAt, false
+	}
+	// the timezone of the reports isn't in their JSON
+	switch report := any(value).(type) {
+	case *webaurion.GradeReport:
+		report.Location = c.location
+	case *webaurion.AbsenceReport:
+		report.Location = c.location
+	case *webaurion.PlanningReport:
+		report.Location = c.location
+	}
+	c.memory.Set(key, value, entry.StoredAt)
+	return value, entry.StoredAt, true
+}
+
+// store caches the value of key, unless key was invalidated since generation.
+func store[T any](c *Cache, key string, value T, generation int) {
+	c.mu.Lock()
+	current := c.generation[key]
+	c.mu.Unlock()
+	if current != generation {
+		return
+	}
+
+	now := c.now()
+	c.memory.Set(key, value, now)
+	if c.Config.Backend == nil {
+		return
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		c.report(key, fmt.Errorf("error encoding cache entry: %v", err))
+		return
+	}
+	if err := c.Config.Backend.Save(key, &Entry{StoredAt: now, Data: data}); err != nil {
+		c.report(key, err)
+	}
+}
+
+// get returns the value of key, fetching it with the client when it isn't fresh.
+func get[T any](c *Cache, key string, fetch func(w *webaurion.WebAurion) (T, error)) (T, error) {
+	ttl := c.Config.TTL[resourceOf(key)]
+	cached, storedAt, ok := lookup[T](c, key)
+	if ttl > 0 && ok {
+		age := c.now().Sub(storedAt)
+		if age < ttl {
+			return cached, nil
+		}
+		if age < ttl+c.Config.StaleWhileRevalidate {
+			c.revalidate(key, func(generation int) error {
+				value, err := fetchValue(c, fetch)
+				if err == nil {
+					store(c, key, value, generation)
+				}
+				return err
+			})
+			return cached, nil
+		}
+	}
+
+	c.mu.Lock()
+	generation := c.generation[key]
+	c.mu.Unlock()
+
+	c.clientMu.Lock()
+	// another caller may have fetched the value while we were waiting for the client
+	if v, storedAt, found := c.memory.Get(key); found && ttl > 0 && c.now().Sub(storedAt) < ttl {
+		if value, isT := v.(T); isT {
+			c.clientMu.Unlock()
+			return value, nil
+		}
+	}
+	value, err := fetch(c.client)
+	c.clientMu.Unlock()
+
+	if err != nil {
+		if ok && c.Config.ServeStaleOnError {
+			c.report(key, fmt.Errorf("serving a snapshot from %s: %v", storedAt.Format(time.RFC3339), err))
+			return cached, nil
+		}
+		return value, err
+	}
+	if ttl > 0 {
+		store(c, key, value, generation)
+	}
+	return value, nil
+}
+
+func fetchValue[T any](c *Cache, fetch func(w *webaurion.WebAurion) (T, error)) (T, error) {
+	c.clientMu.Lock()
+	defer c.clientMu.Unlock()
+	return fetch(c.client)
+}
+
+// revalidate runs refresh in the background, once per key at a time.
+func (c *Cache) revalidate(key string, refresh func(generation int) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.refreshing[key] {
+		return
+	}
+	c.refreshing[key] = true
+	generation := c.generation[key]
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		if err := refresh(generation); err != nil {
+			c.report(key, err)
+		}
+		c.mu.Lock()
+		delete(c.refreshing, key)
+		c.mu.Unlock()
+	}()
+}
+
+// UserInfo returns the cached webaurion.WebAurion.UserInfo.
+func (c *Cache) UserInfo() (*webaurion.UserInfo, error) {
+	return get(c, KeyUserInfo, func(w *webaurion.WebAurion) (*webaurion.UserInfo, error) {
+		return w.UserInfo()
+	})
+}
+
+// GetGrades returns the cached webaurion.WebAurion.GetGrades.
+func (c *Cache) GetGrades() (*webaurion.GradeReport, error) {
+	return get(c, KeyGrades, func(w *webaurion.WebAurion) (*webaurion.GradeReport, error) {
+		return w.GetGrades()
+	})
+}
+
+// GetAbsences returns the cached webaurion.WebAurion.GetAbsences.
+func (c *Cache) GetAbsences() (*webaurion.AbsenceReport, error) {
+	return get(c, KeyAbsences, func(w *webaurion.WebAurion) (*webaurion.AbsenceReport, error) {
+		return w.GetAbsences()
+	})
+}
+
+// GetPlanning returns the cached webaurion.WebAurion.GetPlanning.
+func (c *Cache) GetPlanning() (*webaurion.PlanningReport, error) {
+	return get(c, KeyPlanning, func(w *webaurion.WebAurion) (*webaurion.PlanningReport, error) {
+		return w.GetPlanning()
+	})
+}
+
+// ListCatalogs returns the cached catalogs, loading them on the client if needed.
+func (c *Cache) ListCatalogs() ([]cat.Catalog, error) {
+	return get(c, KeyCatalogs, func(w *webaurion.WebAurion) ([]cat.Catalog, error) {
+		if err := w.LoadCatalogs(); err != nil {
+			return nil, err
+		}
+		return w.ListCatalogs(), nil
+	})
+}
+
+// catalogPage keeps the row indexes of the entries of a catalog, which are hidden from
+// their JSON, so that entries loaded from the Backend can still be consulted.
+type catalogPage struct {
+	Report *cat.CatalogReport `json:"report"`
+	Rows   []int              `json:"rows"`
+}
+
+func (p *catalogPage) UnmarshalJSON(data []byte) error {
+	type page catalogPage
+	if err := json.Unmarshal(data, (*page)(p)); err != nil {
+		return err
+	}
+	if p.Report != nil && len(p.Rows) == len(p.Report.Entries) {
+		for i := range p.Report.Entries {
+			p.Report.Entries[i].RowIndex = p.Rows[i]
+		}
+	}
+	return nil
+}
+
+// GetCatalogEntries returns the cached entries of a catalog.
+func (c *Cache) GetCatalogEntries(idx int) (*cat.CatalogReport, error) {
+	page, err := get(c, CatalogKey(idx), func(w *webaurion.WebAurion) (*catalogPage, error) {
+		if len(w.Catalogs) == 0 {
+			if err := w.LoadCatalogs(); err != nil {
+				return nil, err
+			}
+		}
+		report, err := w.GetCatalogEntries(idx)
+		if err != nil {
+			return nil, err
+		}
+		page := &catalogPage{Report: report, Rows: make([]int, len(report.Entries))}
+		for i, entry := range report.Entries {
+			page.Rows[i] = entry.RowIndex
+		}
+		return page, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page.Report, nil
+}
+
+// GetCatalogEntryDetails returns the cached details of an entry of the catalog idx.
+func (c *Cache) GetCatalogEntryDetails(idx int, entry cat.CatalogEntry) (*cat.CatalogDetails, error) {
+	return get(c, CatalogEntryKey(idx, entry.RowIndex), func(w *webaurion.WebAurion) (*cat.CatalogDetails, error) {
+		if len(w.Catalogs) == 0 {
+			if err := w.LoadCatalogs(); err != nil {
+				return nil, err
+			}
+		}
+		// the "Consulter" button acts on the catalog page WebAurion last served to the client,
+		// which may be another catalog when the entries came from the cache
+		if _, err := w.GetCatalogEntries(idx); err != nil {
+			return nil, err
+		}
+		return w.GetCatalogEntryDetails(entry)
+	})
+}
@@ -0,0 +1,253 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/CorentinMre/isengo/webaurion"
+	cat "github.com/CorentinMre/isengo/webaurion/catalog"
+)
+
+// fakeClock is a clock moved by the tests.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// newTestCache returns a cache with a TTL of 10 minutes for the grades, stale values served
+// for 1 hour after it, and its clock.
+func newTestCache(t *testing.T, backend Backend) (*Cache, *fakeClock) {
+	t.Helper()
+	config := DefaultConfig()
+	config.TTL = map[Resource]time.Duration{
+		ResourceUserInfo: 10 * time.Minute,
+		ResourceGrades:   10 * time.Minute,
+		ResourceAbsences: 10 * time.Minute,
+		ResourcePlanning: 10 * time.Minute,
+		ResourceCatalogs: 10 * time.Minute,
+	}
+	config.StaleWhileRevalidate = time.Hour
+	config.Backend = backend
+	c := New(webaurion.NewWebAurion(), config)
+	clock := &fakeClock{now: time.Date(2024, time.October, 14, 8, 0, 0, 0, time.UTC)}
+	c.now = clock.Now
+	return c, clock
+}
+
+// counter is a fetch function returning its number of calls as the name of the report.
+type counter struct {
+	calls atomic.Int32
+	err   error
+	block chan struct{} // when set, the calls wait for it to be closed
+}
+
+func (f *counter) fetch(*webaurion.WebAurion) (*webaurion.UserInfo, error) {
+	n := f.calls.Add(1)
+	if f.block != nil {
+		<-f.block
+	}
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &webaurion.UserInfo{Name: strings.Repeat("v", int(n))}, nil
+}
+
+func getValue(t *testing.T, c *Cache, f *counter) string {
+	t.Helper()
+	info, err := get(c, KeyUserInfo, f.fetch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Name
+}
+
+func TestFreshHit(t *testing.T) {
+	c, clock := newTestCache(t, nil)
+	f := &counter{}
+	getValue(t, c, f)
+	clock.Add(9 * time.Minute)
+	if got := getValue(t, c, f); got != "v" || f.calls.Load() != 1 {
+		t.Errorf("got %q after %d fetches, want the cached value", got, f.calls.Load())
+	}
+	if age, ok := c.Age(KeyUserInfo); !ok || age != 9*time.Minute {
+		t.Errorf("got age %v, %v", age, ok)
+	}
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	c, clock := newTestCache(t, nil)
+	f := &counter{}
+	getValue(t, c, f)
+	clock.Add(20 * time.Minute)
+
+	f.block = make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if info, err := get(c, KeyUserInfo, f.fetch); err != nil || info.Name != "v" {
+				t.Errorf("got %v, %v, want the stale value", info, err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(f.block)
+	c.Wait()
+
+	if calls := f.calls.Load(); calls != 2 {
+		t.Errorf("got %d fetches, want one revalidation", calls)
+	}
+	if got := getValue(t, c, f); got != "vv" {
+		t.Errorf("got %q after the revalidation", got)
+	}
+}
+
+func TestServeStaleOnError(t *testing.T) {
+	for _, serveStale := range []bool{true, false} {
+		c, clock := newTestCache(t, nil)
+		c.Config.ServeStaleOnError = serveStale
+		var reported []error
+		c.OnError = func(key string, err error) { reported = append(reported, err) }
+
+		f := &counter{}
+		getValue(t, c, f)
+		clock.Add(2 * time.Hour) // past the stale window
+		f.err = errors.New("WebAurion is down")
+
+		info, err := get(c, KeyUserInfo, f.fetch)
+		if serveStale {
+			if err != nil || info.Name != "v" || len(reported) != 1 || !strings.Contains(reported[0].Error(), "WebAurion is down") {
+				t.Errorf("got %v, %v, reported %v, want the snapshot and the error reported", info, err, reported)
+			}
+		} else if err == nil || len(reported) != 0 {
+			t.Errorf("got %v, reported %v, want the error", err, reported)
+		}
+	}
+}
+
+func TestInvalidateDuringRefresh(t *testing.T) {
+	c, clock := newTestCache(t, nil)
+	f := &counter{}
+	getValue(t, c, f)
+	clock.Add(20 * time.Minute)
+
+	f.block = make(chan struct{})
+	getValue(t, c, f) // starts the revalidation
+	for f.calls.Load() != 2 {
+		time.Sleep(time.Millisecond)
+	}
+	c.Invalidate(KeyUserInfo)
+	close(f.block)
+	c.Wait()
+
+	// the refresh started before the invalidation, its value is outdated
+	if _, _, ok := c.memory.Get(KeyUserInfo); ok {
+		t.Error("the refresh stored a value fetched before the invalidation")
+	}
+	if got := getValue(t, c, f); got != "vvv" {
+		t.Errorf("got %q, want a new fetch", got)
+	}
+}
+
+func TestLRUEviction(t *testing.T) {
+	lru := NewLRU(2)
+	now := time.Now()
+	lru.Set("a", 1, now)
+	lru.Set("b", 2, now)
+	lru.Get("a")
+	lru.Set("c", 3, now) // b is the least recently used
+	if _, _, ok := lru.Get("b"); ok {
+		t.Error("b should have been evicted")
+	}
+	if got := strings.Join(lru.Keys(), ","); got != "c,a" {
+		t.Errorf("got keys %s", got)
+	}
+	lru.Set("a", 4, now)
+	lru.Set("d", 5, now) // c is the least recently used
+	if got := strings.Join(lru.Keys(), ","); got != "d,a" || lru.Len() != 2 {
+		t.Errorf("got keys %s", got)
+	}
+	if value, _, _ := lru.Get("a"); value != 4 {
+		t.Errorf("got %v, want the updated value", value)
+	}
+	lru.Delete("a")
+	if got := strings.Join(lru.Keys(), ","); got != "d" {
+		t.Errorf("got keys %s", got)
+	}
+}
+
+// roundTrip caches value with a first cache and reads it back from the disk with a second one.
+func roundTrip[T any](t *testing.T, dir, key string, value T) T {
+	t.Helper()
+	backend, err := NewDiskBackend(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _ := newTestCache(t, backend)
+	if _, err := get(first, key, func(*webaurion.WebAurion) (T, error) { return value, nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	second, _ := newTestCache(t, backend)
+	second.OnError = func(key string, err error) { t.Errorf("%s: %v", key, err) }
+	loaded, err := get(second, key, func(*webaurion.WebAurion) (T, error) {
+		var zero T
+		return zero, errors.New("the value should come from the disk")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := json.Marshal(value)
+	got, _ := json.Marshal(loaded)
+	if string(got) != string(want) {
+		t.Errorf("%s: got %s, want %s", key, got, want)
+	}
+	return loaded
+}
+
+func TestDiskBackendRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2024, time.October, 14, 8, 0, 0, 0, webaurion.ParisLocation)
+
+	roundTrip(t, dir, KeyUserInfo, &webaurion.UserInfo{FirstName: "Jean", LastName: "Dupont", Email: "jean.dupont@isen-ouest.yncrea.fr"})
+	grades := roundTrip(t, dir, KeyGrades, webaurion.NewGradeReport(14, []webaurion.Grade{{Date: "10/10/2024", Code: "MATH_DS1", Grade: 14, Instructors: []string{"Martin"}}}))
+	absences := roundTrip(t, dir, KeyAbsences, webaurion.NewAbsenceReport(1, 120, []webaurion.Absence{{Date: "10/10/2024", Duration: "02:00"}}))
+	planning := roundTrip(t, dir, KeyPlanning, webaurion.NewPlanningReport([]webaurion.Event{{ID: "1", Start: start, End: start.Add(time.Hour), Details: webaurion.Details{Room: "A101"}}}))
+	roundTrip(t, dir, KeyCatalogs, []cat.Catalog{*cat.NewCatalog("Catalogue des stages", "submenu_6", "6_0")})
+	page := roundTrip(t, dir, CatalogKey(0), &catalogPage{
+		Report: cat.NewCatalogReport([]cat.CatalogEntry{{Company: "ISEN", City: "Brest", RowIndex: 7}}),
+		Rows:   []int{7},
+	})
+	roundTrip(t, dir, CatalogEntryKey(0, 7), &cat.CatalogDetails{Title: "Stage", Company: "ISEN"})
+
+	// the timezone and the row indexes aren't in the JSON
+	for _, location := range []*time.Location{grades.Location, absences.Location, planning.Location} {
+		if location == nil || location.String() != "Europe/Paris" {
+			t.Errorf("got location %v, want the timezone of the campus", location)
+		}
+	}
+	if !planning.Events[0].Start.Equal(start) {
+		t.Errorf("got start %v", planning.Events[0].Start)
+	}
+	if page.Report.Entries[0].RowIndex != 7 {
+		t.Errorf("got row index %d", page.Report.Entries[0].RowIndex)
+	}
+}
@@ -0,0 +1,233 @@
+// Package campus describes the WebAurion deployments of the ISEN campuses. Hosts, labels of
+// the menus and IDs of the JSF components differ between deployments, so every request and
+// parser of isengo reads them from a Campus.
+package campus
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Campus is the configuration of a WebAurion deployment.
+type Campus struct {
+	Name        string     `json:"name"`
+	BaseURL     string     `json:"baseUrl"`     // WebAurion, e.g. "https://web.isen-ouest.fr"
+	CASURL      string     `json:"casUrl"`      // CAS shared by WebAurion and Moodle
+	MoodleURL   string     `json:"moodleUrl"`   // empty when the campus has no Moodle
+	EmailDomain string     `json:"emailDomain"` // domain of the student emails
+	Timezone    string     `json:"timezone"`    // IANA name of the timezone of the dates
+	Menu        MenuLabels `json:"menu"`
+	Components  Components `json:"components"`
+}
+
+// MenuLabels are the texts of the sidebar, matched as substrings.
+type MenuLabels struct {
+	Grades   string `json:"grades"`
+	Absences string `json:"absences"`
+	Planning string `json:"planning"`
+	Profile  string `json:"profile"`  // page of the personal data
+	Catalogs string `json:"catalogs"` // submenu of the catalogs
+	Catalog  string `json:"catalog"`  // word in the name of the catalogs
+}
+
+// Components are the IDs of the JSF components used by isengo. WebAurion generates most
+// of them (j_idtNNN), they change between deployments and versions.
+type Components struct {
+	LoginButton   string   `json:"loginButton"`   // submit button of the login form
+	RoleSelect    string   `json:"roleSelect"`    // role select of the main page
+	RoleValue     string   `json:"roleValue"`     // value posted for the role select
+	SidebarButton string   `json:"sidebarButton"` // button opening a submenu of the sidebar
+	SidebarSelect string   `json:"sidebarSelect"` // role select posted with the sidebar
+	Schedule      string   `json:"schedule"`      // schedule of the planning
+	PlanningForm  string   `json:"planningForm"`  // role select of the planning page
+	CatalogTable  string   `json:"catalogTable"`  // datatable of the catalog entries
+	CatalogFilter []string `json:"catalogFilter"` // column filters of the catalog table: company, city, postal code, year
+	CatalogButton string   `json:"catalogButton"` // "Consulter" button of the catalog rows
+	CatalogSelect string   `json:"catalogSelect"` // role select of the catalog pages
+	DetailsSelect string   `json:"detailsSelect"` // role select posted with the "Consulter" button
+}
+
+// ISENOuest returns the profile of ISEN-Ouest (Brest, Caen, Nantes, Rennes).
+func ISENOuest() *Campus {
+	return &Campus{
+		Name:        "ISEN-Ouest",
+		BaseURL:     "https://web.isen-ouest.fr",
+		CASURL:      "https://auth3.isen-ouest.fr/cas",
+		MoodleURL:   "https://moodle.isen-ouest.fr",
+		EmailDomain: "isen-ouest.yncrea.fr",
+		Timezone:    "Europe/Paris",
+		Menu: MenuLabels{
+			Grades:   "note",
+			Absences: "Absences",
+			Planning: "Planning",
+			Profile:  "Mon compte",
+			Catalogs: "Divers",
+			Catalog:  "Catalogue",
+		},
+		Components: Components{
+			LoginButton:   "j_idt27",
+			RoleSelect:    "form:j_idt820",
+			RoleValue:     "275805",
+			SidebarButton: "form:j_idt52",
+			SidebarSelect: "form:j_idt837",
+			Schedule:      "form:j_idt118",
+			PlanningForm:  "form:j_idt244",
+			CatalogTable:  "form:j_idt193",
+			CatalogFilter: []string{"j_idt198", "j_idt200", "j_idt202", "j_idt204"},
+			CatalogButton: "j_idt215",
+			CatalogSelect: "form:j_idt267",
+			DetailsSelect: "form:j_idt265",
+		},
+	}
+}
+
+// Default is the key of the campus used when none is given.
+const Default = "isen-ouest"
+
+var (
+	mu       sync.RWMutex
+	campuses = map[string]func() *Campus{Default: ISENOuest}
+)
+
+// Register adds or replaces a campus, e.g. from a JSON file. Get returns copies of it.
+func Register(key string, c *Campus) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	profile := c.clone()
+	mu.Lock()
+	defer mu.Unlock()
+	campuses[strings.ToLower(key)] = profile.clone
+	return nil
+}
+
+// Get returns a copy of a registered campus.
+func Get(key string) (*Campus, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	profile, ok := campuses[strings.ToLower(key)]
+	if !ok {
+		return nil, fmt.Errorf("unknown campus: %s, registered campuses are %s", key, strings.Join(keys(), ", "))
+	}
+	return profile(), nil
+}
+
+// Load returns the campus of a key or of a JSON profile file, which is registered under its
+// path so that it can be given to Get afterwards.
+func Load(keyOrFile string) (*Campus, error) {
+	if !strings.HasSuffix(keyOrFile, ".json") {
+		return Get(keyOrFile)
+	}
+	data, err := os.ReadFile(keyOrFile)
+	if err != nil {
+		return nil, fmt.Errorf("error reading campus profile: %v", err)
+	}
+	var profile Campus
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("error parsing campus profile: %v", err)
+	}
+	if err := Register(keyOrFile, &profile); err != nil {
+		return nil, err
+	}
+	return Get(keyOrFile)
+}
+
+// Keys returns the keys of the registered campuses, sorted.
+func Keys() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return keys()
+}
+
+func keys() []string {
+	names := make([]string, 0, len(campuses))
+	for key := range campuses {
+		names = append(names, key)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (c *Campus) clone() *Campus {
+	copied := *c
+	copied.Components.CatalogFilter = slices.Clone(c.Components.CatalogFilter)
+	return &copied
+}
+
+// Validate checks the URLs, the timezone, the menu labels and the component IDs of the campus.
+func (c *Campus) Validate() error {
+	if c == nil {
+		return fmt.Errorf("nil campus")
+	}
+	for name, value := range map[string]string{"baseUrl": c.BaseURL, "casUrl": c.CASURL, "moodleUrl": c.MoodleURL} {
+		if value == "" {
+			continue
+		}
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid %s: %q", name, value)
+		}
+	}
+	if c.BaseURL == "" {
+		return fmt.Errorf("the campus needs a baseUrl")
+	}
+	if _, err := time.LoadLocation(c.Timezone); c.Timezone != "" && err != nil {
+		return fmt.Errorf("invalid timezone: %v", err)
+	}
+
+	// an empty label would match every link of the sidebar
+	required := [][2]string{
+		{"menu.grades", c.Menu.Grades},
+		{"menu.absences", c.Menu.Absences},
+		{"menu.planning", c.Menu.Planning},
+		{"menu.profile", c.Menu.Profile},
+		{"menu.catalogs", c.Menu.Catalogs},
+		{"menu.catalog", c.Menu.Catalog},
+		{"components.loginButton", c.Components.LoginButton},
+		{"components.roleSelect", c.Components.RoleSelect},
+		{"components.roleValue", c.Components.RoleValue},
+		{"components.sidebarButton", c.Components.SidebarButton},
+		{"components.sidebarSelect", c.Components.SidebarSelect},
+		{"components.schedule", c.Components.Schedule},
+		{"components.planningForm", c.Components.PlanningForm},
+		{"components.catalogTable", c.Components.CatalogTable},
+		{"components.catalogButton", c.Components.CatalogButton},
+		{"components.catalogSelect", c.Components.CatalogSelect},
+		{"components.detailsSelect", c.Components.DetailsSelect},
+	}
+	for _, field := range required {
+		if strings.TrimSpace(field[1]) == "" {
+			return fmt.Errorf("the campus needs %s", field[0])
+		}
+	}
+	if len(c.Components.CatalogFilter) == 0 || slices.Contains(c.Components.CatalogFilter, "") {
+		return fmt.Errorf("the campus needs components.catalogFilter")
+	}
+	return nil
+}
+
+// Location returns the timezone of the campus, Europe/Paris when it is empty or unknown.
+func (c *Campus) Location() *time.Location {
+	name := c.Timezone
+	if name == "" {
+		name = "Europe/Paris"
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return time.FixedZone("CET", 3600)
+	}
+	return location
+}
+
+// Email returns the email address of a student on the campus domain, e.g. "jean.dupont@...".
+func (c *Campus) Email(firstName, lastName string) string {
+	normalize := func(name string) string {
+		return strings.ToLower(strings.Join(strings.Fields(name), "-"))
+	}
+	return fmt.Sprintf("%s.%s@%s", normalize(firstName), normalize(lastName), c.EmailDomain)
+}
@@ -0,0 +1,103 @@
+package campus
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(c *Campus)
+		err    string
+	}{
+		{"ISEN-Ouest", func(c *Campus) {}, ""},
+		{"no Moodle", func(c *Campus) { c.MoodleURL = "" }, ""},
+		{"no base URL", func(c *Campus) { c.BaseURL = "" }, "baseUrl"},
+		{"relative URL", func(c *Campus) { c.CASURL = "/cas" }, "invalid casUrl"},
+		{"unknown timezone", func(c *Campus) { c.Timezone = "Mars/Olympus" }, "invalid timezone"},
+		{"no profile label", func(c *Campus) { c.Menu.Profile = "" }, "menu.profile"},
+		{"blank grades label", func(c *Campus) { c.Menu.Grades = " " }, "menu.grades"},
+		{"no schedule", func(c *Campus) { c.Components.Schedule = "" }, "components.schedule"},
+		{"no catalog filters", func(c *Campus) { c.Components.CatalogFilter = nil }, "components.catalogFilter"},
+		{"empty catalog filter", func(c *Campus) { c.Components.CatalogFilter[1] = "" }, "components.catalogFilter"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := ISENOuest()
+			tt.change(c)
+			err := c.Validate()
+			if tt.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestRegisterRefusesPartialProfiles(t *testing.T) {
+	if err := Register("partial", &Campus{BaseURL: "https://webaurion.example.fr"}); err == nil {
+		t.Error("a profile without labels and components should be refused")
+	}
+	if _, err := Get("partial"); err == nil {
+		t.Error("the refused profile shouldn't be registered")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	profile := ISENOuest()
+	profile.Name = "ISEN Méditerranée"
+	profile.BaseURL = "https://webaurion.isen-mediterranee.fr"
+	data, err := json.Marshal(profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := filepath.Join(dir, "mediterranee.json")
+	partial := filepath.Join(dir, "partial.json")
+	broken := filepath.Join(dir, "broken.json")
+	for name, content := range map[string]string{valid: string(data), partial: `{"baseUrl":"https://webaurion.example.fr"}`, broken: "{"} {
+		if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		keyOrFile string
+		baseURL   string
+		err       string
+	}{
+		{Default, ISENOuest().BaseURL, ""},
+		{"ISEN-Ouest", ISENOuest().BaseURL, ""},
+		{"unknown", "", "unknown campus"},
+		{valid, profile.BaseURL, ""},
+		{filepath.Join(dir, "missing.json"), "", "error reading campus profile"},
+		{broken, "", "error parsing campus profile"},
+		{partial, "", "the campus needs"},
+	}
+	for _, tt := range tests {
+		c, err := Load(tt.keyOrFile)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Load(%s): got error %v, want %q", tt.keyOrFile, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || c.BaseURL != tt.baseURL {
+			t.Errorf("Load(%s): got %v, %v, want %s", tt.keyOrFile, c, err, tt.baseURL)
+		}
+	}
+
+	// the profile file is registered under its path
+	if c, err := Get(valid); err != nil || c.Name != profile.Name {
+		t.Errorf("Get(%s): got %v, %v", valid, c, err)
+	}
+}
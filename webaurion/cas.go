@@ -0,0 +1,85 @@
+package webaurion
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// casLogin logs in on a CAS server for service: it scrapes the login form (login ticket,
+// execution...), posts the credentials and follows the redirections back to the service
+// with the ticket, so the service session cookie ends in the jar of client.
+// It returns the final page of the service.
+func casLogin(client *http.Client, casURL, service, username, password string) (*goquery.Document, error) {
+	loginURL := strings.TrimSuffix(casURL, "/") + "/login?service=" + url.QueryEscape(service)
+	resp, err := client.Get(loginURL)
+	if err != nil {
+		return nil, fmt.Errorf("error getting CAS login page: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("CAS login page returned status %d", resp.StatusCode)
+	}
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing CAS login page: %v", err)
+	}
+
+	// already logged in: CAS redirected to the service
+	form := doc.Find("input[type='password']").Closest("form")
+	if form.Length() == 0 {
+		if sameHost(resp.Request.URL, casURL) {
+			return nil, fmt.Errorf("login form not found on CAS page")
+		}
+		return doc, nil
+	}
+
+	payload := url.Values{}
+	form.Find("input").Each(func(i int, s *goquery.Selection) {
+		if name, ok := s.Attr("name"); ok && name != "" {
+			value, _ := s.Attr("value")
+			payload.Set(name, value)
+		}
+	})
+	payload.Set("username", username)
+	payload.Set("password", password)
+	if payload.Get("_eventId") == "" {
+		payload.Set("_eventId", "submit")
+	}
+
+	action, _ := form.Attr("action")
+	target, err := resp.Request.URL.Parse(action)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CAS form action %q: %v", action, err)
+	}
+
+	resp, err = client.PostForm(target.String(), payload)
+	if err != nil {
+		return nil, fmt.Errorf("error during CAS login request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// the credentials are wrong when CAS shows its form again
+	if sameHost(resp.Request.URL, casURL) {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("username or password incorrect")
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("service returned status %d after CAS login", resp.StatusCode)
+	}
+	doc, err = goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing service page: %v", err)
+	}
+	return doc, nil
+}
+
+// sameHost reports whether u is on the host of rawURL.
+func sameHost(u *url.URL, rawURL string) bool {
+	other, err := url.Parse(rawURL)
+	return err == nil && strings.EqualFold(u.Host, other.Host)
+}
@@ -51,11 +51,11 @@ func (c *Catalog) JSON() string {
 
 // CatalogEntry represents an entry in a catalog (internship, apprenticeship, etc.)
 type CatalogEntry struct {
-	Company    string `json:"company"`
-	City       string `json:"city"`
-	PostalCode string `json:"postalCode"`
-	Year       string `json:"year"`
-	RowIndex   int    `json:"-"` // internal use only for fetching details
+	Company    string `json:"company" datatable:"Entreprise|Société|Organisme"`
+	City       string `json:"city" datatable:"Ville"`
+	PostalCode string `json:"postalCode" datatable:"Code postal|CP"`
+	Year       string `json:"year" datatable:"Année|Promotion"`
+	RowIndex   int    `json:"-" datatable:",index"` // internal use only for fetching details
 }
 
 // create a new instance of CatalogEntry
@@ -8,6 +8,11 @@ import (
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
+
+	"github.com/CorentinMre/isengo/webaurion/campus"
+	"github.com/CorentinMre/isengo/webaurion/datatable"
+	"github.com/CorentinMre/isengo/webaurion/menu"
+	"github.com/CorentinMre/isengo/webaurion/partial"
 )
 
 // WebAurionClient interface to avoid circular dependency
@@ -17,10 +22,34 @@ type WebAurionClient interface {
 	SetRequestHeaders(req *http.Request)
 	GetViewState(reader io.Reader, isInitial bool) (string, error)
 	GetPayload() string
+	PayloadValue(name string) string
+	GetCampus() *campus.Campus
+	MenuItem(path ...string) (*menu.Item, error)
+	PostPartial(page string, payload url.Values) (*partial.Response, error)
+}
+
+// searchFields returns the empty search fields and column filters of the catalog form.
+func searchFields(components campus.Components) url.Values {
+	fields := url.Values{}
+	fields.Set("form:messagesRubriqueInaccessible", "")
+	for _, name := range searchInputs {
+		fields.Set(name, "")
+	}
+	fields.Set(components.CatalogTable+"_reflowDD", "0_0")
+	for _, filter := range components.CatalogFilter {
+		fields.Set(components.CatalogTable+":"+filter+":filter", "")
+	}
+	return fields
 }
 
 // retrieve all entries from a catalog (handles pagination automatically)
 func GetCatalogEntries(w WebAurionClient, catalogIndex int, catalogs []Catalog, doRequest func(string, ...string) ([]byte, error)) (*CatalogReport, error) {
+	return GetCatalogEntriesWithQuery(w, catalogIndex, catalogs, doRequest, CatalogQuery{})
+}
+
+// retrieve the entries of a catalog matching a query, the search is run by WebAurion
+// (handles pagination automatically)
+func GetCatalogEntriesWithQuery(w WebAurionClient, catalogIndex int, catalogs []Catalog, doRequest func(string, ...string) ([]byte, error), query CatalogQuery) (*CatalogReport, error) {
 	// get the payload for the catalog
 	payload, err := GetCatalogPayload(w, catalogIndex, catalogs)
 	if err != nil {
@@ -38,146 +67,69 @@ func GetCatalogEntries(w WebAurionClient, catalogIndex int, catalogs []Catalog,
 	if err != nil {
 		return nil, fmt.Errorf("error parsing catalog HTML: %v", err)
 	}
+	table, err := datatable.Parse(doc, w.GetCampus().Components.CatalogTable)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing catalog: %v", err)
+	}
 
-	entries := ParseCatalogEntries(doc)
-
-	// check if there are more pages
-	hasMorePages := doc.Find("a.ui-paginator-next:not(.ui-state-disabled)").Length() > 0
-
-	if hasMorePages {
-		// get ViewState and idInit for pagination requests
+	if table.HasNext || !query.IsEmpty() {
+		// get ViewState and idInit for pagination and search requests
 		viewState, _ := w.GetViewState(strings.NewReader(string(data)), false)
 		idInit := ""
 		if input := doc.Find("input[name='form:idInit']"); input.Length() > 0 {
 			idInit, _ = input.Attr("value")
 		}
+		pager := getCatalogPager(w, viewState, idInit, query)
 
-		// fetch all subsequent pages
-		first := 20 // first element of the next page
-		pageNum := 2
-		for hasMorePages {
-			moreEntries, more, err := getCatalogPage(w, first, viewState, idInit)
+		if !query.IsEmpty() {
+			// the search replaces the first page with the first matching rows
+			rows, total, err := pager.Filter(searchInputs...)
 			if err != nil {
-				break
+				return nil, fmt.Errorf("error searching catalog: %v", err)
 			}
-
-			if len(moreEntries) == 0 {
-				break
-			}
-
-			entries = append(entries, moreEntries...)
-			hasMorePages = more
-			first += 20
-			pageNum++
-
-			// safety: limit to 100 pages max
-			if pageNum > 100 {
-				break
+			table.Rows = rows
+			table.RowCount = max(total, 0)
+			if total >= 0 {
+				table.HasNext = total > len(rows)
+			} else {
+				table.HasNext = table.PageSize > 0 && len(rows) >= table.PageSize
 			}
 		}
+
+		// fetch all subsequent pages, the entries loaded before an error are kept
+		// (safety: limit to 100 pages max)
+		table.Rows, _ = pager.All(table, 100)
 	}
 
+	// the entries are filtered by WebAurion (without case and accents), they aren't checked again
+	entries := []CatalogEntry{}
+	if err := table.Decode(&entries); err != nil {
+		return nil, fmt.Errorf("error decoding catalog entries: %v", err)
+	}
 	return NewCatalogReport(entries), nil
 }
 
-// fetch a specific page of the catalog (AJAX pagination)
-func getCatalogPage(w WebAurionClient, first int, viewState, idInit string) ([]CatalogEntry, bool, error) {
+// pager of the catalog table (AJAX pagination)
+func getCatalogPager(w WebAurionClient, viewState, idInit string, query CatalogQuery) *datatable.Pager {
 	// extract necessary parameters
-	payloadParts := strings.Split(w.GetPayload(), "&")
-	var largeurDivCenter, jIdt267Input string
-
-	for _, part := range payloadParts {
-		if strings.HasPrefix(part, "form:largeurDivCenter=") {
-			largeurDivCenter = strings.TrimPrefix(part, "form:largeurDivCenter=")
-		} else if strings.HasPrefix(part, "form:j_idt820_input=") {
-			jIdt267Input = strings.TrimPrefix(part, "form:j_idt820_input=")
-		}
-	}
-
-	// build AJAX payload for pagination
-	payload := fmt.Sprintf("javax.faces.partial.ajax=true&javax.faces.source=form:j_idt193&javax.faces.partial.execute=form:j_idt193&javax.faces.partial.render=form:j_idt193&form:j_idt193=form:j_idt193&form:j_idt193_pagination=true&form:j_idt193_first=%d&form:j_idt193_rows=20&form:j_idt193_skipChildren=true&form:j_idt193_encodeFeature=true&form=form&form:largeurDivCenter=%s&form:idInit=%s&form:messagesRubriqueInaccessible=&form:search-texte=&form:search-texte-avancer=&form:input-expression-exacte=&form:input-un-des-mots=&form:input-aucun-des-mots=&form:input-nombre-debut=&form:input-nombre-fin=&form:calendarDebut_input=&form:calendarFin_input=&form:j_idt193_reflowDD=0_0&form:j_idt193:j_idt198:filter=&form:j_idt193:j_idt200:filter=&form:j_idt193:j_idt202:filter=&form:j_idt193:j_idt204:filter=&form:j_idt267_focus=&form:j_idt267_input=%s&javax.faces.ViewState=%s",
-		first, largeurDivCenter, idInit, jIdt267Input, url.QueryEscape(viewState))
-
-	// make the AJAX request
-	req, err := http.NewRequest("POST", w.GetBaseURL()+"/webAurion/faces/ChoixEvenementDUnFormulaire.xhtml", strings.NewReader(payload))
-	if err != nil {
-		return nil, false, fmt.Errorf("error creating pagination request: %v", err)
-	}
-
-	w.SetRequestHeaders(req)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=UTF-8")
-	req.Header.Set("Faces-Request", "partial/ajax")
-	req.Header.Set("X-Requested-With", "XMLHttpRequest")
-	req.Header.Set("Accept", "application/xml, text/xml, */*; q=0.01")
-
-	resp, err := w.GetClient().Do(req)
-	if err != nil {
-		return nil, false, fmt.Errorf("error getting page: %v", err)
+	components := w.GetCampus().Components
+
+	// the search is sent again with every page
+	fields := query.fields(components)
+	fields.Set("form", "form")
+	fields.Set("form:largeurDivCenter", w.PayloadValue("form:largeurDivCenter"))
+	fields.Set("form:idInit", idInit)
+	fields.Set(components.CatalogSelect+"_focus", "")
+	fields.Set(components.CatalogSelect+"_input", w.PayloadValue(components.RoleSelect+"_input"))
+	fields.Set("javax.faces.ViewState", viewState)
+
+	return &datatable.Pager{
+		Table:  components.CatalogTable,
+		Fields: fields,
+		Post: func(payload url.Values) (*partial.Response, error) {
+			return w.PostPartial("/webAurion/faces/ChoixEvenementDUnFormulaire.xhtml", payload)
+		},
 	}
-	defer resp.Body.Close()
-
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, false, fmt.Errorf("error reading page body: %v", err)
-	}
-
-	// extract HTML from CDATA containing form:j_idt193
-	responseStr := string(bodyBytes)
-
-	// find the section <update id="form:j_idt193">
-	tableUpdateStart := strings.Index(responseStr, `<update id="form:j_idt193">`)
-	if tableUpdateStart == -1 {
-		return nil, false, fmt.Errorf("table update section not found in pagination response")
-	}
-
-	// extract CDATA from this section
-	cdataStart := strings.Index(responseStr[tableUpdateStart:], "<![CDATA[")
-	if cdataStart == -1 {
-		return nil, false, fmt.Errorf("CDATA not found in table update section")
-	}
-	cdataStart += tableUpdateStart
-
-	cdataEnd := strings.Index(responseStr[cdataStart:], "]]>")
-	if cdataEnd == -1 {
-		return nil, false, fmt.Errorf("CDATA end not found")
-	}
-	cdataEnd += cdataStart
-
-	htmlContent := responseStr[cdataStart+9 : cdataEnd]
-
-	// parse the new entries - need to wrap in table/tbody since these are fragments
-	wrappedHTML := "<table><tbody>" + htmlContent + "</tbody></table>"
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(wrappedHTML))
-	if err != nil {
-		return nil, false, fmt.Errorf("error parsing page HTML: %v", err)
-	}
-
-	entries := []CatalogEntry{}
-	doc.Find("tr").Each(func(i int, row *goquery.Selection) {
-		rowIndex, exists := row.Attr("data-ri")
-		if !exists {
-			return
-		}
-
-		cells := row.Find("td")
-		if cells.Length() < 4 {
-			return
-		}
-
-		company := strings.TrimSpace(cells.Eq(0).Find("span.preformatted").Text())
-		city := strings.TrimSpace(cells.Eq(1).Find("span.preformatted").Text())
-		postalCode := strings.TrimSpace(cells.Eq(2).Find("span.preformatted").Text())
-		year := strings.ReplaceAll(strings.TrimSpace(cells.Eq(3).Find("span.preformatted").Text()), "\u00a0", " ")
-
-		var idx int
-		fmt.Sscanf(rowIndex, "%d", &idx)
-		entries = append(entries, *NewCatalogEntry(company, city, postalCode, year, idx))
-	})
-
-	// check if there are more pages (consider there are if we got 20 entries)
-	hasMore := len(entries) == 20
-
-	return entries, hasMore, nil
 }
 
 // retrieve the details of a catalog entry
@@ -211,18 +163,10 @@ func GetCatalogEntryDetails(w WebAurionClient, entry CatalogEntry) (*CatalogDeta
 	}
 
 	// extract necessary parameters from payload
-	payloadParts := strings.Split(w.GetPayload(), "&")
-	var largeurDivCenter, idInit, jIdt267Input string
-
-	for _, part := range payloadParts {
-		if strings.HasPrefix(part, "form:largeurDivCenter=") {
-			largeurDivCenter = strings.TrimPrefix(part, "form:largeurDivCenter=")
-		} else if strings.HasPrefix(part, "form:idInit=") {
-			idInit = strings.TrimPrefix(part, "form:idInit=")
-		} else if strings.HasPrefix(part, "form:j_idt820_input=") {
-			jIdt267Input = strings.TrimPrefix(part, "form:j_idt820_input=")
-		}
-	}
+	components := w.GetCampus().Components
+	largeurDivCenter := w.PayloadValue("form:largeurDivCenter")
+	idInit := w.PayloadValue("form:idInit")
+	roleInput := w.PayloadValue(components.RoleSelect + "_input")
 
 	// find idInit from current page
 	idInitInput := doc.Find("input[name='form:idInit']")
@@ -233,8 +177,15 @@ func GetCatalogEntryDetails(w WebAurionClient, entry CatalogEntry) (*CatalogDeta
 	}
 
 	// build payload for "Consulter" button
-	payload := fmt.Sprintf("form=form&form:largeurDivCenter=%s&form:idInit=%s&form:messagesRubriqueInaccessible=&form:search-texte=&form:search-texte-avancer=&form:input-expression-exacte=&form:input-un-des-mots=&form:input-aucun-des-mots=&form:input-nombre-debut=&form:input-nombre-fin=&form:calendarDebut_input=&form:calendarFin_input=&form:j_idt193_reflowDD=0_0&form:j_idt193:j_idt198:filter=&form:j_idt193:j_idt200:filter=&form:j_idt193:j_idt202:filter=&form:j_idt193:j_idt204:filter=&form:j_idt193:%d:j_idt215=&form:j_idt265_focus=&form:j_idt265_input=%s&javax.faces.ViewState=%s",
-		largeurDivCenter, idInit, entry.RowIndex, jIdt267Input, url.QueryEscape(viewState))
+	fields := searchFields(components)
+	fields.Set("form", "form")
+	fields.Set("form:largeurDivCenter", largeurDivCenter)
+	fields.Set("form:idInit", idInit)
+	fields.Set(fmt.Sprintf("%s:%d:%s", components.CatalogTable, entry.RowIndex, components.CatalogButton), "")
+	fields.Set(components.DetailsSelect+"_focus", "")
+	fields.Set(components.DetailsSelect+"_input", roleInput)
+	fields.Set("javax.faces.ViewState", viewState)
+	payload := fields.Encode()
 
 	// make the POST request
 	req2, err := http.NewRequest("POST", w.GetBaseURL()+"/webAurion/faces/ChoixEvenementDUnFormulaire.xhtml", strings.NewReader(payload))
@@ -0,0 +1,31 @@
+package catalog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestParseCatalogEntries(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<div id="form:table"><table>
+		<thead><tr><th>Organisme</th><th>Ville de l'établissement</th><th>Ville</th><th>CP</th><th>Promotion</th></tr></thead>
+		<tbody id="form:table_data"><tr data-ri="7"><td>ISEN</td><td>Lille</td><td>Brest</td><td>29200</td><td>2025</td></tr></tbody>
+	</table></div>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := ParseCatalogEntries(doc, "form:table")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := CatalogEntry{Company: "ISEN", City: "Brest", PostalCode: "29200", Year: "2025", RowIndex: 7}
+	if len(entries) != 1 || entries[0] != want {
+		t.Errorf("got %+v, want %+v", entries, want)
+	}
+
+	if _, err := ParseCatalogEntries(doc, "form:missing"); err == nil {
+		t.Error("ParseCatalogEntries should fail for a missing table")
+	}
+}
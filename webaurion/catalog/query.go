@@ -0,0 +1,71 @@
+package catalog
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/CorentinMre/isengo/webaurion/campus"
+)
+
+// CatalogQuery is a search in a catalog, run by WebAurion with its advanced search and the
+// column filters of the table. Empty fields aren't used.
+type CatalogQuery struct {
+	Text        string    `json:"text,omitempty"`        // search in every field
+	ExactPhrase string    `json:"exactPhrase,omitempty"` // this exact phrase
+	AnyWords    string    `json:"anyWords,omitempty"`    // at least one of these words
+	NoneWords   string    `json:"noneWords,omitempty"`   // none of these words
+	From        time.Time `json:"from,omitempty"`        // offers from this date
+	To          time.Time `json:"to,omitempty"`          // offers until this date
+	Company     string    `json:"company,omitempty"`     // column filters
+	City        string    `json:"city,omitempty"`
+	PostalCode  string    `json:"postalCode,omitempty"`
+}
+
+// the advanced search fields of the catalog form
+var searchInputs = []string{
+	"form:search-texte",
+	"form:search-texte-avancer",
+	"form:input-expression-exacte",
+	"form:input-un-des-mots",
+	"form:input-aucun-des-mots",
+	"form:input-nombre-debut",
+	"form:input-nombre-fin",
+	"form:calendarDebut_input",
+	"form:calendarFin_input",
+}
+
+// IsEmpty reports whether the query has no criteria.
+func (q CatalogQuery) IsEmpty() bool {
+	return !q.hasSearch() && !q.hasFilters()
+}
+
+func (q CatalogQuery) hasSearch() bool {
+	return q.Text != "" || q.ExactPhrase != "" || q.AnyWords != "" || q.NoneWords != "" || !q.From.IsZero() || !q.To.IsZero()
+}
+
+func (q CatalogQuery) hasFilters() bool {
+	return q.Company != "" || q.City != "" || q.PostalCode != ""
+}
+
+// fields returns the search fields and the column filters of the catalog form for the query.
+// The filters of the campus are the columns company, city, postal code and year, in order.
+func (q CatalogQuery) fields(components campus.Components) url.Values {
+	fields := searchFields(components)
+	fields.Set("form:search-texte", strings.TrimSpace(q.Text))
+	fields.Set("form:input-expression-exacte", strings.TrimSpace(q.ExactPhrase))
+	fields.Set("form:input-un-des-mots", strings.TrimSpace(q.AnyWords))
+	fields.Set("form:input-aucun-des-mots", strings.TrimSpace(q.NoneWords))
+	if !q.From.IsZero() {
+		fields.Set("form:calendarDebut_input", q.From.Format("02/01/2006"))
+	}
+	if !q.To.IsZero() {
+		fields.Set("form:calendarFin_input", q.To.Format("02/01/2006"))
+	}
+
+	filters := []string{q.Company, q.City, q.PostalCode}
+	for i, filter := range components.CatalogFilter[:min(len(filters), len(components.CatalogFilter))] {
+		fields.Set(components.CatalogTable+":"+filter+":filter", strings.TrimSpace(filters[i]))
+	}
+	return fields
+}
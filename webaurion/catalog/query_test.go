@@ -0,0 +1,89 @@
+package catalog
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/CorentinMre/isengo/webaurion/campus"
+)
+
+func TestQueryFields(t *testing.T) {
+	components := campus.ISENOuest().Components
+	tests := []struct {
+		name  string
+		query CatalogQuery
+		want  map[string]string
+	}{
+		{
+			"empty",
+			CatalogQuery{},
+			map[string]string{
+				"form:search-texte":                 "",
+				"form:calendarDebut_input":          "",
+				"form:j_idt193_reflowDD":            "0_0",
+				"form:j_idt193:j_idt198:filter":     "",
+				"form:j_idt193:j_idt204:filter":     "",
+				"form:messagesRubriqueInaccessible": "",
+				"form:input-aucun-des-mots":         "",
+			},
+		},
+		{
+			"search",
+			CatalogQuery{
+				Text:        " robotique ",
+				ExactPhrase: "systèmes embarqués",
+				AnyWords:    "C Go",
+				NoneWords:   "stage",
+				From:        time.Date(2025, time.March, 3, 0, 0, 0, 0, time.UTC),
+				To:          time.Date(2025, time.August, 29, 0, 0, 0, 0, time.UTC),
+			},
+			map[string]string{
+				"form:search-texte":            "robotique",
+				"form:input-expression-exacte": "systèmes embarqués",
+				"form:input-un-des-mots":       "C Go",
+				"form:input-aucun-des-mots":    "stage",
+				"form:calendarDebut_input":     "03/03/2025",
+				"form:calendarFin_input":       "29/08/2025",
+			},
+		},
+		{
+			// company, city and postal code are the first filters, the year stays empty
+			"filters",
+			CatalogQuery{Company: "École Navale", City: "Brest ", PostalCode: "29200"},
+			map[string]string{
+				"form:j_idt193:j_idt198:filter": "École Navale",
+				"form:j_idt193:j_idt200:filter": "Brest",
+				"form:j_idt193:j_idt202:filter": "29200",
+				"form:j_idt193:j_idt204:filter": "",
+				"form:search-texte":             "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := tt.query.fields(components)
+			for name, value := range tt.want {
+				if !fields.Has(name) || fields.Get(name) != value {
+					t.Errorf("%s: got %q, want %q", name, fields.Get(name), value)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryFieldsWithFewerFilters(t *testing.T) {
+	components := campus.ISENOuest().Components
+	components.CatalogFilter = []string{"company"}
+	fields := CatalogQuery{Company: "Thales", City: "Brest"}.fields(components)
+
+	set := url.Values{}
+	for name, values := range fields {
+		if len(values) == 1 && values[0] != "" {
+			set[name] = values
+		}
+	}
+	if got := set.Encode(); got != "form%3Aj_idt193%3Acompany%3Afilter=Thales&form%3Aj_idt193_reflowDD=0_0" {
+		t.Errorf("got the non-empty fields %s", got)
+	}
+}
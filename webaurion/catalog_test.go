@@ -0,0 +1,41 @@
+package webaurion
+
+import (
+	"testing"
+
+	cat "github.com/CorentinMre/isengo/webaurion/catalog"
+)
+
+func TestCatalogSearchKeepsTheEntriesOfWebAurion(t *testing.T) {
+	f := newFakeWebAurion(t)
+	w := f.client(CookieLogin{Cookie: "s1"})
+	if _, err := w.Login("", ""); err != nil {
+		t.Fatal(err)
+	}
+	w.Catalogs = []cat.Catalog{*cat.NewCatalog("Catalogue des stages", "submenu_6", "6_0")}
+
+	report, err := w.GetCatalogEntriesWithQuery(0, cat.CatalogQuery{Company: "Ecole"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// WebAurion matched "École" to "Ecole", the entry is kept
+	if len(report.Entries) != 1 || report.Entries[0].Company != "École Navale" {
+		t.Fatalf("got entries %+v", report.Entries)
+	}
+
+	search := f.posts[len(f.posts)-1]
+	want := map[string]string{
+		"form:j_idt193:j_idt198:filter": "Ecole",
+		"form:j_idt193_filtering":       "true",
+		"form:idInit":                   "init-2",
+		"javax.faces.ViewState":         "vs-catalog",
+	}
+	for name, value := range want {
+		if got := search.Get(name); got != value {
+			t.Errorf("%s: got %q, want %q", name, got, value)
+		}
+	}
+	if w.ViewState != "vs-search" {
+		t.Errorf("got ViewState %q, want the one of the search", w.ViewState)
+	}
+}
@@ -4,15 +4,21 @@ import (
 	"encoding/json"
 	"time"
 	"fmt"
-	"strings"
 )
 
 
+// UserInfo is the identity of the student, read from the personal data page of WebAurion
+// (Source "profile") or guessed from the displayed name (Source "name").
 type UserInfo struct {
-	FirstName string `json:"firstName"`
-	LastName  string `json:"lastName"`
-	Name      string `json:"name"`
-	Email     string `json:"email"`
+	FirstName     string `json:"firstName"`
+	LastName      string `json:"lastName"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	StudentNumber string `json:"studentNumber,omitempty"`
+	Promotion     string `json:"promotion,omitempty"`
+	Group         string `json:"group,omitempty"`
+	PhotoURL      string `json:"photoUrl,omitempty"`
+	Source        string `json:"source"`
 }
 
 // NewUserInfo creates a new instance of UserInfo.
@@ -91,6 +97,8 @@ func (g *Grade) Get(key string) (interface{}, error) {
 type GradeReport struct {
 	Average float64 `json:"average"`
 	Grades  []Grade `json:"data"`
+	// Location is the timezone of the dates, Europe/Paris when nil.
+	Location *time.Location `json:"-"`
 }
 
 // NewGradeReport creates a new instance of GradeReport.
@@ -183,6 +191,10 @@ type AbsenceReport struct {
 	NbAbsences int       `json:"nbAbsences"`
 	Duration       int    `json:"duration"`
 	Data       []Absence `json:"data"`
+	// Warnings lists the rows whose duration or schedule couldn't be parsed.
+	Warnings   []string  `json:"warnings,omitempty"`
+	// Location is the timezone of the dates, Europe/Paris when nil.
+	Location   *time.Location `json:"-"`
 }
 
 // NewAbsenceReport creates a new instance of AbsenceReport.
@@ -208,8 +220,10 @@ func (ar *AbsenceReport) Get(key string) (interface{}, error) {
 		return ar.Duration, nil
 	case "data":
 		return ar.Data, nil
+	case "warnings":
+		return ar.Warnings, nil
 	default:
-		return nil, fmt.Errorf("invalid key: %s, valid keys are 'nbAbsences', 'time', and 'data'", key)
+		return nil, fmt.Errorf("invalid key: %s, valid keys are 'nbAbsences', 'duration', 'data' and 'warnings'", key)
 	}
 }
 
@@ -239,109 +253,9 @@ type Details struct {
     Description  string `json:"description"`
     Instructors  []string `json:"instructors"`
     ClassGroups  []string `json:"classGroups"`
+    Link         string   `json:"link,omitempty"` // Moodle course of deadlines, see WithDeadlines
 }
 
-// parseEventTitle parses the event title into Details.
-func parseEventTitle(title string) (Details, error) {
-    // Diviser le titre en parties par " - "
-    parts := strings.Split(title, " - ")
-
-    // V�rifier la longueur minimale
-    if len(parts) < 6 {
-        return Details{}, fmt.Errorf("incomplete event title: %v", parts)
-    }
-
-    // Extraire et nettoyer les différentes parties du titre
-    time := strings.TrimSpace(parts[0])
-    room := strings.TrimSpace(parts[1])
-    eventType := strings.TrimSpace(parts[2])
-    subject := strings.TrimSpace(parts[3])
-    description := strings.TrimSpace(parts[4])
-
-    // Gérer les groupes de classes s'ils sont disponibles
-	var instructors []string
-    var classGroups []string
-    if len(parts) > 5 {
-		instructors = strings.Split(strings.TrimSpace(parts[5]), " / ")
-		classGroups = strings.Split(strings.TrimSpace(parts[6]), " / ")
-    }
-
-    // Si les parties essentielles sont manquantes
-    if time == "" || eventType == "" || subject == "" {
-        return Details{}, fmt.Errorf("incomplete details extracted from title: %v", title)
-    }
-
-    return Details{
-        Time:        time,
-        Room:        room,
-        Type:        eventType,
-        Subject:     subject,
-		Description: description,
-        Instructors: instructors,
-        ClassGroups: classGroups,
-    }, nil
-}
-
-
-func NewEvent(data map[string]interface{}) (*Event, error) {
-    title, ok := data["title"].(string)
-    if !ok {
-        return nil, fmt.Errorf("invalid or missing 'title' field")
-    }
-
-    details, err := parseEventTitle(title)
-    if err != nil {
-        return nil, fmt.Errorf("error parsing event title: %v", err)
-    }
-
-    startStr, ok := data["start"].(string)
-    if !ok {
-        return nil, fmt.Errorf("invalid or missing 'start' field")
-    }
-    start, err := time.Parse("2006-01-02T15:04:05-0700", startStr)
-    if err != nil {
-        return nil, fmt.Errorf("error parsing start time: %v", err)
-    }
-
-    endStr, ok := data["end"].(string)
-    if !ok {
-        return nil, fmt.Errorf("invalid or missing 'end' field")
-    }
-    end, err := time.Parse("2006-01-02T15:04:05-0700", endStr)
-    if err != nil {
-        return nil, fmt.Errorf("error parsing end time: %v", err)
-    }
-
-    allDayStr, ok := data["allDay"].(bool)
-    if !ok {
-		allDayStr = false
-        // allDayStr, ok = data["allDay"].(string)
-        // if !ok {
-        //     return nil, fmt.Errorf("invalid or missing 'allDay' field")
-        // }
-        // allDay, err := strconv.ParseBool(allDayStr)
-        // if err != nil {
-        //     return nil, fmt.Errorf("error parsing allDay field: %v", err)
-        // }
-        // allDayStr = allDay
-    }
-
-    className, ok := data["className"].(string)
-    if !ok {
-        return nil, fmt.Errorf("invalid or missing 'className' field")
-    }
-
-    return &Event{
-        ID:          data["id"].(string),
-        Start:       start,
-        End:         end,
-        AllDay:      allDayStr,
-        ClassName:   className,
-        Details:     details,
-    }, nil
-}
-
-
 // String returns a string representation of the Event.
 func (e *Event) String() string {
     return fmt.Sprintf("Event(id='%s', start='%s', end='%s', all_day='%t', class_name='%s', time='%s', room='%s', type='%s', subject='%s', instructors='%v', class_groups='%v')",
@@ -356,6 +270,7 @@ func (e *Event) MarshalJSON() ([]byte, error) {
         End       time.Time `json:"end"`
         AllDay    bool      `json:"allDay"`
         ClassName string    `json:"className"`
+        Kind      EventKind `json:"kind"`
         Details   Details   `json:"details"`
     }{
         ID:        e.ID,
@@ -363,6 +278,7 @@ func (e *Event) MarshalJSON() ([]byte, error) {
         End:       e.End,
         AllDay:    e.AllDay,
         ClassName: e.ClassName,
+        Kind:      e.Kind(),
         Details:   e.Details,
     })
 }
@@ -370,6 +286,10 @@ func (e *Event) MarshalJSON() ([]byte, error) {
 // PlanningReport represents a report about planning events.
 type PlanningReport struct {
     Events []Event `json:"events"`
+    // Warnings lists the events that were skipped or whose title couldn't be fully parsed.
+    Warnings []string `json:"warnings,omitempty"`
+    // Location is the timezone of the calendar days, Europe/Paris when nil.
+    Location *time.Location `json:"-"`
 }
 
 // NewPlanningReport creates a new instance of PlanningReport.
@@ -0,0 +1,229 @@
+// Package datatable scrapes the PrimeFaces datatables of WebAurion (catalogs, documents,
+// inscriptions...): headers, rows, paginator, and the AJAX requests of the next pages.
+package datatable
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Row is a row of a datatable.
+type Row struct {
+	Index int      `json:"index"`         // data-ri, the index of the row in the whole table
+	Key   string   `json:"key,omitempty"` // data-rk, when the table has a row key
+	Cells []string `json:"cells"`
+}
+
+// Table is a datatable of a page.
+type Table struct {
+	ID       string   `json:"id"` // client ID, e.g. "form:j_idt193"
+	Headers  []string `json:"headers"`
+	Rows     []Row    `json:"rows"`
+	PageSize int      `json:"pageSize,omitempty"` // rows per page, 0 without paginator
+	RowCount int      `json:"rowCount,omitempty"` // rows of the whole table, 0 when unknown
+	HasNext  bool     `json:"hasNext"`            // the paginator has a next page
+}
+
+// Selector returns the CSS selector of the element with the client ID id ("form:x" has a colon).
+func Selector(id string) string {
+	return "[id='" + id + "']"
+}
+
+// Parse parses the datatable with the client ID id in doc.
+func Parse(doc *goquery.Document, id string) (*Table, error) {
+	container := doc.Find(Selector(id))
+	if container.Length() == 0 {
+		return nil, fmt.Errorf("datatable %s not found", id)
+	}
+
+	table := &Table{ID: id, Headers: headers(container)}
+	table.Rows = ParseRows(doc.Find(Selector(id + "_data")).Find("tr"))
+	if len(table.Headers) == 0 {
+		table.Headers = rowHeaders(doc.Find(Selector(id + "_data")).Find("tr").First())
+	}
+
+	if paginator := container.Find(".ui-paginator").First(); paginator.Length() > 0 {
+		table.HasNext = paginator.Find("a.ui-paginator-next:not(.ui-state-disabled)").Length() > 0
+		table.PageSize = len(table.Rows)
+	}
+	table.PageSize, table.RowCount = widgetPaginator(doc, id, table.PageSize)
+	return table, nil
+}
+
+// FindAll parses every datatable of doc.
+func FindAll(doc *goquery.Document) []*Table {
+	var tables []*Table
+	doc.Find("div.ui-datatable[id]").Each(func(_ int, s *goquery.Selection) {
+		if table, err := Parse(doc, s.AttrOr("id", "")); err == nil {
+			tables = append(tables, table)
+		}
+	})
+	return tables
+}
+
+// headers reads the column titles of the thead of a datatable.
+func headers(container *goquery.Selection) []string {
+	var titles []string
+	container.Find("thead").First().Find("th").Each(func(_ int, th *goquery.Selection) {
+		title := th.Find("span.ui-column-title").First()
+		if title.Length() == 0 {
+			title = th
+		}
+		titles = append(titles, cleanText(title.Text()))
+	})
+	return titles
+}
+
+// rowHeaders reads the column titles that responsive (reflow) tables repeat in every cell.
+func rowHeaders(row *goquery.Selection) []string {
+	var titles []string
+	row.ChildrenFiltered("td").Each(func(_ int, td *goquery.Selection) {
+		titles = append(titles, cleanText(td.Find("span.ui-column-title").First().Text()))
+	})
+	return titles
+}
+
+// ParseRows parses the rows of a datatable, e.g. the tr of a partial response. Rows without
+// data-ri ("no records found") are skipped.
+func ParseRows(rows *goquery.Selection) []Row {
+	parsed := []Row{}
+	rows.Each(func(_ int, tr *goquery.Selection) {
+		ri, ok := tr.Attr("data-ri")
+		if !ok {
+			return
+		}
+		index, err := strconv.Atoi(ri)
+		if err != nil {
+			return
+		}
+		row := Row{Index: index, Key: tr.AttrOr("data-rk", "")}
+		tr.ChildrenFiltered("td").Each(func(_ int, td *goquery.Selection) {
+			row.Cells = append(row.Cells, cellText(td))
+		})
+		parsed = append(parsed, row)
+	})
+	return parsed
+}
+
+// cellText returns the text of a cell without the column title of reflow tables.
+func cellText(td *goquery.Selection) string {
+	cell := td.Clone()
+	cell.Find("span.ui-column-title").Remove()
+	if preformatted := cell.Find("span.preformatted"); preformatted.Length() > 0 {
+		return cleanText(preformatted.Text())
+	}
+	return cleanText(cell.Text())
+}
+
+func cleanText(text string) string {
+	return strings.TrimSpace(strings.ReplaceAll(text, "\u00a0", " "))
+}
+
+var (
+	rowsRegex     = regexp.MustCompile(`\brows\s*:\s*(\d+)`)
+	rowCountRegex = regexp.MustCompile(`\browCount\s*:\s*(\d+)`)
+)
+
+// widgetPaginator reads the page size and the row count in the PrimeFaces widget script of
+// the table, e.g. PrimeFaces.cw("DataTable", ..., {id:"form:x", paginator:{rows:20, rowCount:57}}).
+func widgetPaginator(doc *goquery.Document, id string, pageSize int) (int, int) {
+	rowCount := 0
+	doc.Find("script").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		script := s.Text()
+		if !strings.Contains(script, `"`+id+`"`) || !strings.Contains(script, "paginator") {
+			return true
+		}
+		if match := rowsRegex.FindStringSubmatch(script); match != nil {
+			pageSize, _ = strconv.Atoi(match[1])
+		}
+		if match := rowCountRegex.FindStringSubmatch(script); match != nil {
+			rowCount, _ = strconv.Atoi(match[1])
+		}
+		return false
+	})
+	return pageSize, rowCount
+}
+
+// Column returns the index of the column titled title (case insensitive, an exact title
+// wins over a title containing it), -1 when there is none.
+func (t *Table) Column(title string) int {
+	return column(t.Headers, title)
+}
+
+func column(headers []string, titles ...string) int {
+	if i := exactColumn(headers, titles); i >= 0 {
+		return i
+	}
+	for _, title := range titles {
+		title = strings.ToLower(strings.TrimSpace(title))
+		for i, header := range headers {
+			if title != "" && strings.Contains(strings.ToLower(header), title) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// exactColumn returns the index of the first column titled one of titles, -1 when there is none.
+func exactColumn(headers []string, titles []string) int {
+	for _, title := range titles {
+		for i, header := range headers {
+			if strings.EqualFold(header, strings.TrimSpace(title)) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// Map returns the cells of the row by column title, untitled columns are skipped.
+func (r Row) Map(headers []string) map[string]string {
+	values := make(map[string]string, len(headers))
+	for i, header := range headers {
+		if header != "" && i < len(r.Cells) {
+			values[header] = r.Cells[i]
+		}
+	}
+	return values
+}
+
+// Maps returns the rows of the table by column title.
+func (t *Table) Maps() []map[string]string {
+	maps := make([]map[string]string, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		maps = append(maps, row.Map(t.Headers))
+	}
+	return maps
+}
+
+// String returns a string representation of the Table.
+func (t *Table) String() string {
+	return fmt.Sprintf("Table(id='%s', columns=%d, rows=%d, hasNext=%t)", t.ID, len(t.Headers), len(t.Rows), t.HasNext)
+}
+
+// Get returns the value of a specific key for the Table.
+func (t *Table) Get(key string) (interface{}, error) {
+	switch key {
+	case "headers":
+		return t.Headers, nil
+	case "rows":
+		return t.Maps(), nil
+	default:
+		return nil, fmt.Errorf("invalid key: %s, valid keys are 'headers' and 'rows'", key)
+	}
+}
+
+// JSON returns the JSON representation of the Table.
+func (t *Table) JSON() string {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("Error marshaling to JSON: %v", err)
+	}
+	return string(data)
+}
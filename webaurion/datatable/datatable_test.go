@@ -0,0 +1,84 @@
+package datatable
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// catalogPage is the first page of a catalog, a reflow datatable with a paginator.
+const catalogPage = `<html><body><form id="form">
+<div id="form:table" class="ui-datatable">
+	<div class="ui-paginator"><a class="ui-paginator-prev ui-state-disabled"></a><a class="ui-paginator-next"></a></div>
+	<table>
+		<thead><tr>
+			<th><span class="ui-column-title">Entreprise</span></th>
+			<th><span class="ui-column-title">Adresse CP Ville</span></th>
+			<th><span class="ui-column-title">Code postal</span></th>
+			<th><span class="ui-column-title">Effectif</span></th>
+			<th></th>
+		</tr></thead>
+		<tbody id="form:table_data">
+			<tr data-ri="0" data-rk="a1"><td><span class="ui-column-title">Entreprise</span>ISEN&nbsp;Yncréa</td><td>20 rue Cuirassé Bretagne 29200 Brest</td><td>29200</td><td>1 200</td><td><button>Consulter</button></td></tr>
+			<tr data-ri="1"><td>Thales</td><td></td><td>35000</td><td></td><td></td></tr>
+			<tr><td colspan="5">Aucun résultat</td></tr>
+		</tbody>
+	</table>
+</div>
+<script>PrimeFaces.cw("DataTable","widget_form_table",{id:"form:table",paginator:{rows:2,rowCount:5}});</script>
+</form></body></html>`
+
+func parseDocument(t *testing.T, html string) *goquery.Document {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestParse(t *testing.T) {
+	table, err := Parse(parseDocument(t, catalogPage), "form:table")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(table.Headers, ","); got != "Entreprise,Adresse CP Ville,Code postal,Effectif," {
+		t.Errorf("got headers %s", got)
+	}
+	if len(table.Rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(table.Rows))
+	}
+	if row := table.Rows[0]; row.Index != 0 || row.Key != "a1" || row.Cells[0] != "ISEN Yncréa" || row.Cells[4] != "Consulter" {
+		t.Errorf("unexpected row: %+v", row)
+	}
+	if table.Rows[1].Index != 1 || table.Rows[1].Key != "" {
+		t.Errorf("unexpected row: %+v", table.Rows[1])
+	}
+	if !table.HasNext || table.PageSize != 2 || table.RowCount != 5 {
+		t.Errorf("unexpected paginator: hasNext=%v pageSize=%d rowCount=%d", table.HasNext, table.PageSize, table.RowCount)
+	}
+
+	if _, err := Parse(parseDocument(t, catalogPage), "form:missing"); err == nil {
+		t.Error("Parse should fail for a missing table")
+	}
+}
+
+func TestColumn(t *testing.T) {
+	table := &Table{Headers: []string{"Entreprise", "Adresse CP Ville", "Code postal", "Ville"}}
+	tests := []struct {
+		title string
+		want  int
+	}{
+		{"entreprise", 0},
+		{"Ville", 3},
+		{"adresse", 1},
+		{"Pays", -1},
+		{"", -1},
+	}
+	for _, tt := range tests {
+		if got := table.Column(tt.title); got != tt.want {
+			t.Errorf("Column(%q) = %d, want %d", tt.title, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package datatable
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Decode decodes rows into dst, a pointer to a slice of structs. The fields are matched to
+// the columns by their datatable tag, a title or titles separated by "|" (an exact title wins
+// over a title containing one of them):
+//
+//	type Entry struct {
+//		Company string `datatable:"Entreprise"`
+//		Year    string `datatable:"Année|Promotion"`
+//		Row     int    `datatable:",index"` // index of the row (data-ri)
+//	}
+//
+// Fields of kind string, int, float64 and bool are supported, fields without tag or whose
+// column is missing are left empty.
+func Decode(headers []string, rows []Row, dst interface{}) error {
+	slice := reflect.ValueOf(dst)
+	if slice.Kind() != reflect.Pointer || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("datatable: Decode needs a pointer to a slice, got %T", dst)
+	}
+	slice = slice.Elem()
+	elem := slice.Type().Elem()
+	pointer := elem.Kind() == reflect.Pointer
+	if pointer {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return fmt.Errorf("datatable: Decode needs a slice of structs, got %s", slice.Type())
+	}
+
+	// column of each field, -1 for the row index
+	type binding struct {
+		field  int
+		column int
+	}
+	var bindings []binding
+	for i := 0; i < elem.NumField(); i++ {
+		tag, ok := elem.Field(i).Tag.Lookup("datatable")
+		if !ok || tag == "-" || !elem.Field(i).IsExported() {
+			continue
+		}
+		if tag == ",index" {
+			bindings = append(bindings, binding{field: i, column: -1})
+			continue
+		}
+		// a title containing an alternative is only used when no alternative is exact
+		if col := column(headers, strings.Split(tag, "|")...); col >= 0 {
+			bindings = append(bindings, binding{field: i, column: col})
+		}
+	}
+
+	for _, row := range rows {
+		value := reflect.New(elem).Elem()
+		for _, b := range bindings {
+			field := value.Field(b.field)
+			if b.column == -1 {
+				if err := setField(field, strconv.Itoa(row.Index)); err != nil {
+					return fmt.Errorf("datatable: row %d: %s: %v", row.Index, elem.Field(b.field).Name, err)
+				}
+				continue
+			}
+			if b.column >= len(row.Cells) {
+				continue
+			}
+			if err := setField(field, row.Cells[b.column]); err != nil {
+				return fmt.Errorf("datatable: row %d, column %q: %v", row.Index, headers[b.column], err)
+			}
+		}
+		if pointer {
+			value = value.Addr()
+		}
+		slice.Set(reflect.Append(slice, value))
+	}
+	return nil
+}
+
+// Decode decodes the rows of the table into dst, see Decode.
+func (t *Table) Decode(dst interface{}) error {
+	return Decode(t.Headers, t.Rows, dst)
+}
+
+// setField sets a field from the text of a cell, numbers may use a decimal comma.
+func setField(field reflect.Value, text string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(text)
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		if text == "" {
+			return nil
+		}
+		n, err := strconv.ParseInt(strings.ReplaceAll(text, " ", ""), 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float64, reflect.Float32:
+		if text == "" {
+			return nil
+		}
+		f, err := strconv.ParseFloat(strings.ReplaceAll(strings.ReplaceAll(text, " ", ""), ",", "."), 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		switch strings.ToLower(text) {
+		case "oui", "yes", "true", "x", "1":
+			field.SetBool(true)
+		}
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package datatable
+
+import (
+	"strings"
+	"testing"
+)
+
+type entry struct {
+	Company    string  `datatable:"Entreprise|Société"`
+	PostalCode string  `datatable:"CP|Code postal"`
+	Address    string  `datatable:"Adresse"`
+	Staff      int     `datatable:"Effectif"`
+	Rate       float64 `datatable:"Taux"`
+	Paid       bool    `datatable:"Rémunéré"`
+	Country    string  `datatable:"Pays"`
+	Row        int     `datatable:",index"`
+	Ignored    string
+}
+
+func TestDecode(t *testing.T) {
+	headers := []string{"Société", "Adresse CP Ville", "Code postal", "Effectif", "Taux", "Rémunéré"}
+	rows := []Row{
+		{Index: 3, Cells: []string{"ISEN", "Brest", "29200", "1 200", "12,5", "Oui"}},
+		{Index: 4, Cells: []string{"Thales", "", "35000", "", "", "non"}},
+		{Index: 5, Cells: []string{"Court"}},
+	}
+
+	var entries []entry
+	if err := Decode(headers, rows, &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries", len(entries))
+	}
+	// "Code postal" is exact, "CP" mustn't bind the address containing it
+	want := entry{Company: "ISEN", PostalCode: "29200", Address: "Brest", Staff: 1200, Rate: 12.5, Paid: true, Row: 3}
+	if entries[0] != want {
+		t.Errorf("got %+v, want %+v", entries[0], want)
+	}
+	if e := entries[1]; e.Staff != 0 || e.Paid || e.Row != 4 {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+	if e := entries[2]; e.Company != "Court" || e.PostalCode != "" || e.Row != 5 {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+
+	var pointers []*entry
+	if err := Decode(headers, rows[:1], &pointers); err != nil || len(pointers) != 1 || pointers[0].Company != "ISEN" {
+		t.Errorf("got %v, %v", pointers, err)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	headers := []string{"Effectif"}
+	tests := []struct {
+		name string
+		dst  interface{}
+		err  string
+	}{
+		{"not a pointer", []entry{}, "pointer to a slice"},
+		{"not structs", &[]string{}, "slice of structs"},
+		{"invalid number", &[]entry{}, `column "Effectif"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Decode(headers, []Row{{Index: 0, Cells: []string{"beaucoup"}}}, tt.dst)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got %v, want an error with %q", err, tt.err)
+			}
+		})
+	}
+}
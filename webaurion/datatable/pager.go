@@ -0,0 +1,137 @@
+package datatable
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/CorentinMre/isengo/webaurion/partial"
+)
+
+// DefaultPageSize is the page size of the tables of WebAurion.
+const DefaultPageSize = 20
+
+// Pager loads the pages of a datatable through the AJAX requests of its paginator.
+type Pager struct {
+	Table    string     // client ID of the table
+	PageSize int        // rows per page, DefaultPageSize when 0
+	Fields   url.Values // the other fields of the form: ViewState, filters...
+	// Post sends a partial request and returns its decoded response, with the server error
+	// of the response if any.
+	Post func(payload url.Values) (*partial.Response, error)
+}
+
+func (p *Pager) pageSize() int {
+	if p.PageSize > 0 {
+		return p.PageSize
+	}
+	return DefaultPageSize
+}
+
+// Payload returns the fields of the request of the page starting at the row first.
+func (p *Pager) Payload(first int) url.Values {
+	payload := url.Values{}
+	for name, values := range p.Fields {
+		payload[name] = append([]string{}, values...)
+	}
+	payload.Set("javax.faces.partial.ajax", "true")
+	payload.Set("javax.faces.source", p.Table)
+	payload.Set("javax.faces.partial.execute", p.Table)
+	payload.Set("javax.faces.partial.render", p.Table)
+	payload.Set(p.Table, p.Table)
+	payload.Set(p.Table+"_pagination", "true")
+	payload.Set(p.Table+"_first", strconv.Itoa(first))
+	payload.Set(p.Table+"_rows", strconv.Itoa(p.pageSize()))
+	payload.Set(p.Table+"_skipChildren", "true")
+	payload.Set(p.Table+"_encodeFeature", "true")
+	return payload
+}
+
+// Page loads the rows of the page starting at the row first.
+func (p *Pager) Page(first int) ([]Row, error) {
+	rows, _, err := p.send(p.Payload(first))
+	return rows, err
+}
+
+// Filter sends the filtering request of the table: the column filters and the other fields
+// are taken from Fields, execute lists the components processed with the table (e.g. search
+// inputs of the form). It returns the first page of the matching rows and their count, -1
+// when the response doesn't tell it. The next pages are loaded with Page or All.
+func (p *Pager) Filter(execute ...string) ([]Row, int, error) {
+	payload := p.Payload(0)
+	for _, name := range []string{p.Table + "_pagination", p.Table + "_first", p.Table + "_rows", p.Table + "_skipChildren"} {
+		payload.Del(name)
+	}
+	payload.Set("javax.faces.partial.execute", strings.Join(append([]string{p.Table}, execute...), " "))
+	payload.Set(p.Table+"_filtering", "true")
+	return p.send(payload)
+}
+
+// send posts a request of the table and parses the rows of its update. The count of rows is
+// read in the callback parameters of PrimeFaces ({"totalRecords":12}), -1 without them.
+func (p *Pager) send(payload url.Values) ([]Row, int, error) {
+	response, err := p.Post(payload)
+	if err != nil {
+		return nil, -1, fmt.Errorf("error getting page: %v", err)
+	}
+	fragment, ok := response.Update(p.Table)
+	if !ok {
+		return nil, -1, fmt.Errorf("update of %s not found in partial response", p.Table)
+	}
+	// the next pages are requested with the new ViewState
+	if response.ViewState != "" {
+		if p.Fields == nil {
+			p.Fields = url.Values{}
+		}
+		p.Fields.Set("javax.faces.ViewState", response.ViewState)
+	}
+
+	total := -1
+	for _, extension := range response.Extensions {
+		var args struct {
+			TotalRecords *int `json:"totalRecords"`
+		}
+		if extension.Attrs["type"] == "args" && json.Unmarshal([]byte(extension.Content), &args) == nil && args.TotalRecords != nil {
+			total = *args.TotalRecords
+		}
+	}
+
+	// the update holds the rows only, they need a table around them to be parsed
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader("<table><tbody>" + fragment + "</tbody></table>"))
+	if err != nil {
+		return nil, -1, fmt.Errorf("error parsing page HTML: %v", err)
+	}
+	return ParseRows(doc.Find("tr")), total, nil
+}
+
+// All returns the rows of table (the first page, parsed from a document) and of the pages
+// after it. At most maxPages pages are loaded, 0 for no limit. On error, the rows loaded
+// so far are returned with it.
+func (p *Pager) All(table *Table, maxPages int) ([]Row, error) {
+	rows := append([]Row{}, table.Rows...)
+	if !table.HasNext {
+		return rows, nil
+	}
+	if p.PageSize == 0 {
+		p.PageSize = table.PageSize
+	}
+
+	size := p.pageSize()
+	for page := 2; maxPages == 0 || page <= maxPages; page++ {
+		more, err := p.Page(len(rows))
+		if err != nil {
+			return rows, err
+		}
+		rows = append(rows, more...)
+
+		// the row count of the widget is exact, else a short page is the last one
+		if len(more) == 0 || (table.RowCount > 0 && len(rows) >= table.RowCount) || (table.RowCount == 0 && len(more) < size) {
+			break
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,122 @@
+package datatable
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/CorentinMre/isengo/webaurion/partial"
+)
+
+// decode decodes a partial response like the AJAX helper of the client.
+func decode(data string) (*partial.Response, error) {
+	response, err := partial.Parse([]byte(data))
+	if err != nil {
+		return nil, err
+	}
+	return response, response.Err()
+}
+
+// fakePager returns a pager of a table of count rows, its posts are recorded.
+func fakePager(count int, posts *[]url.Values) *Pager {
+	return &Pager{
+		Table:  "form:table",
+		Fields: url.Values{"javax.faces.ViewState": {"vs-1"}, "form:filter": {"brest"}},
+		Post: func(payload url.Values) (*partial.Response, error) {
+			*posts = append(*posts, payload)
+			if payload.Get("javax.faces.ViewState") == "expired" {
+				return decode(`<partial-response><redirect url="/webAurion/login"/></partial-response>`)
+			}
+			first, _ := strconv.Atoi(payload.Get("form:table_first"))
+			size, _ := strconv.Atoi(payload.Get("form:table_rows"))
+			if payload.Get("form:table_filtering") == "true" {
+				first, size = 0, 2
+			}
+			var rows strings.Builder
+			for i := first; i < min(first+size, count); i++ {
+				fmt.Fprintf(&rows, `<tr data-ri="%d"><td>row %d</td></tr>`, i, i)
+			}
+			return decode(fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<partial-response><changes>
+<update id="form:table"><![CDATA[%s]]></update>
+<update id="j_id1:javax.faces.ViewState:0"><![CDATA[vs-%d]]></update>
+<extension ln="primefaces" type="args">{"totalRecords":%d}</extension>
+</changes></partial-response>`, rows.String(), len(*posts)+1, count))
+		},
+	}
+}
+
+func TestPagerPayload(t *testing.T) {
+	var posts []url.Values
+	p := fakePager(0, &posts)
+	payload := p.Payload(40)
+	want := map[string]string{
+		"javax.faces.source":    "form:table",
+		"form:table_pagination": "true",
+		"form:table_first":      "40",
+		"form:table_rows":       strconv.Itoa(DefaultPageSize),
+		"form:filter":           "brest",
+		"javax.faces.ViewState": "vs-1",
+	}
+	for name, value := range want {
+		if got := payload.Get(name); got != value {
+			t.Errorf("%s: got %q, want %q", name, got, value)
+		}
+	}
+	payload.Set("form:filter", "changed")
+	if p.Fields.Get("form:filter") != "brest" {
+		t.Error("Payload shouldn't change the fields of the pager")
+	}
+}
+
+func TestPagerAll(t *testing.T) {
+	var posts []url.Values
+	p := fakePager(5, &posts)
+	table := &Table{ID: "form:table", Rows: []Row{{Index: 0}, {Index: 1}}, PageSize: 2, RowCount: 5, HasNext: true}
+	rows, err := p.All(table, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 5 || rows[4].Index != 4 || rows[4].Cells[0] != "row 4" {
+		t.Fatalf("got rows %+v", rows)
+	}
+	if len(posts) != 2 || posts[0].Get("form:table_first") != "2" || posts[1].Get("form:table_first") != "4" {
+		t.Errorf("unexpected posts %v", posts)
+	}
+	// the next page is requested with the ViewState of the previous response
+	if got := posts[1].Get("javax.faces.ViewState"); got != "vs-2" {
+		t.Errorf("second page sent with ViewState %q, want vs-2", got)
+	}
+
+	posts = nil
+	if rows, _ := p.All(table, 2); len(rows) != 4 || len(posts) != 1 {
+		t.Errorf("maxPages 2: got %d rows in %d posts", len(rows), len(posts))
+	}
+}
+
+func TestPagerFilter(t *testing.T) {
+	var posts []url.Values
+	p := fakePager(7, &posts)
+	rows, total, err := p.Filter("form:search")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || total != 7 {
+		t.Errorf("got %d rows of %d", len(rows), total)
+	}
+	post := posts[0]
+	if post.Get("javax.faces.partial.execute") != "form:table form:search" || post.Has("form:table_first") {
+		t.Errorf("unexpected filter request %v", post)
+	}
+}
+
+func TestPagerExpiredSession(t *testing.T) {
+	var posts []url.Values
+	p := fakePager(5, &posts)
+	p.Fields.Set("javax.faces.ViewState", "expired")
+	if _, err := p.Page(0); err == nil || !strings.Contains(err.Error(), "redirected") {
+		t.Errorf("got %v", err)
+	}
+}
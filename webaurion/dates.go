@@ -0,0 +1,76 @@
+package webaurion
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// ParisLocation is the timezone WebAurion dates are expressed in.
+// It falls back to a fixed CET offset when the tz database is not available.
+var ParisLocation = loadLocation("Europe/Paris", 3600)
+
+// orParis returns location, ParisLocation when it is nil.
+func orParis(location *time.Location) *time.Location {
+	if location == nil {
+		return ParisLocation
+	}
+	return location
+}
+
+func loadLocation(name string, fallbackOffset int) *time.Location {
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return time.FixedZone(name, fallbackOffset)
+	}
+	return location
+}
+
+// WebAurion dates look like "12/02/2024", "12/02/24" or "12/02/2024 14:30",
+// sometimes prefixed by the day name ("lun. 12/02/2024").
+var frenchDateRegex = regexp.MustCompile(`(\d{1,2})/(\d{1,2})/(\d{2,4})(?:\s+(\d{1,2})[:h](\d{2}))?`)
+
+// ParseFrenchDate parses a dd/mm/yyyy date (with an optional hh:mm time) in the given location.
+func ParseFrenchDate(value string, location *time.Location) (time.Time, error) {
+	match := frenchDateRegex.FindStringSubmatch(value)
+	if match == nil {
+		return time.Time{}, fmt.Errorf("invalid date: %q", value)
+	}
+
+	day, _ := strconv.Atoi(match[1])
+	month, _ := strconv.Atoi(match[2])
+	year, _ := strconv.Atoi(match[3])
+	if len(match[3]) == 2 {
+		year += 2000
+	}
+
+	hour, minute := 0, 0
+	if match[4] != "" {
+		hour, _ = strconv.Atoi(match[4])
+		minute, _ = strconv.Atoi(match[5])
+	}
+
+	if month < 1 || month > 12 || day < 1 || day > 31 || hour > 23 || minute > 59 {
+		return time.Time{}, fmt.Errorf("invalid date: %q", value)
+	}
+
+	date := time.Date(year, time.Month(month), day, hour, minute, 0, 0, location)
+	if date.Day() != day {
+		// time.Date normalizes 31/02 into March
+		return time.Time{}, fmt.Errorf("invalid date: %q", value)
+	}
+	return date, nil
+}
+
+// ParsedDate returns the date of the Grade in Europe/Paris. Reports parse the dates in
+// their Location.
+func (g *Grade) ParsedDate() (time.Time, error) {
+	return ParseFrenchDate(g.Date, ParisLocation)
+}
+
+// ParsedDate returns the date of the Absence in Europe/Paris. Reports parse the dates in
+// their Location.
+func (a *Absence) ParsedDate() (time.Time, error) {
+	return ParseFrenchDate(a.Date, ParisLocation)
+}
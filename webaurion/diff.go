@@ -0,0 +1,277 @@
+package webaurion
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	cat "github.com/CorentinMre/isengo/webaurion/catalog"
+)
+
+func marshalChanges(v interface{}) string {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("Error marshaling to JSON: %v", err)
+	}
+	return string(data)
+}
+
+// keyed indexes items by key, numbering duplicates so they can still be matched one by one.
+func keyed[T any](items []T, key func(T) string) (map[string]T, []string) {
+	index := make(map[string]T, len(items))
+	order := make([]string, 0, len(items))
+	seen := make(map[string]int)
+	for _, item := range items {
+		k := key(item)
+		seen[k]++
+		if seen[k] > 1 {
+			k = fmt.Sprintf("%s#%d", k, seen[k])
+		}
+		index[k] = item
+		order = append(order, k)
+	}
+	return index, order
+}
+
+// GradeChange is a grade present in both snapshots with different values.
+type GradeChange struct {
+	Old Grade `json:"old"`
+	New Grade `json:"new"`
+}
+
+// GradeChanges represents the differences between two GradeReport.
+type GradeChanges struct {
+	Added   []Grade       `json:"added"`
+	Removed []Grade       `json:"removed"`
+	Changed []GradeChange `json:"changed"`
+}
+
+// IsEmpty reports whether nothing changed.
+func (gc *GradeChanges) IsEmpty() bool {
+	return len(gc.Added) == 0 && len(gc.Removed) == 0 && len(gc.Changed) == 0
+}
+
+func (gc *GradeChanges) JSON() string {
+	return marshalChanges(gc)
+}
+
+// GradeKey identifies a grade across two snapshots.
+func GradeKey(g Grade) string {
+	if g.Code != "" {
+		return g.Code
+	}
+	return g.Date + "|" + g.Name
+}
+
+// DiffGrades returns the grades added, removed or changed (matched by code) between two reports.
+func DiffGrades(old, new *GradeReport) *GradeChanges {
+	changes := &GradeChanges{Changed: []GradeChange{}}
+	var oldGrades, newGrades []Grade
+	if old != nil {
+		oldGrades = old.Grades
+	}
+	if new != nil {
+		newGrades = new.Grades
+	}
+
+	changes.Added, changes.Removed = diffKeyed(oldGrades, newGrades, GradeKey)
+
+	oldIndex, _ := keyed(oldGrades, GradeKey)
+	newIndex, newOrder := keyed(newGrades, GradeKey)
+	for _, key := range newOrder {
+		newGrade := newIndex[key]
+		oldGrade, ok := oldIndex[key]
+		if !ok {
+			continue
+		}
+		if oldGrade.Grade != newGrade.Grade || oldGrade.Absence != newGrade.Absence ||
+			oldGrade.Appreciation != newGrade.Appreciation || oldGrade.Name != newGrade.Name {
+			changes.Changed = append(changes.Changed, GradeChange{Old: oldGrade, New: newGrade})
+		}
+	}
+	return changes
+}
+
+// AbsenceChanges represents the differences between two AbsenceReport.
+type AbsenceChanges struct {
+	Added   []Absence `json:"added"`
+	Removed []Absence `json:"removed"`
+}
+
+// IsEmpty reports whether nothing changed.
+func (ac *AbsenceChanges) IsEmpty() bool {
+	return len(ac.Added) == 0 && len(ac.Removed) == 0
+}
+
+func (ac *AbsenceChanges) JSON() string {
+	return marshalChanges(ac)
+}
+
+// AbsenceKey identifies an absence across two snapshots, including its reason.
+func AbsenceKey(a Absence) string {
+	return absenceKey(a) + "|" + a.Reason
+}
+
+// DiffAbsences returns the absences added or removed between two reports.
+// An absence whose reason changed (e.g. once justified) shows up as removed and added.
+func DiffAbsences(old, new *AbsenceReport) *AbsenceChanges {
+	var oldAbsences, newAbsences []Absence
+	if old != nil {
+		oldAbsences = old.Data
+	}
+	if new != nil {
+		newAbsences = new.Data
+	}
+
+	added, removed := diffKeyed(oldAbsences, newAbsences, AbsenceKey)
+	return &AbsenceChanges{Added: added, Removed: removed}
+}
+
+// diffKeyed returns the items of new missing from old and the items of old missing from new.
+func diffKeyed[T any](old, new []T, key func(T) string) (added, removed []T) {
+	oldIndex, oldOrder := keyed(old, key)
+	newIndex, newOrder := keyed(new, key)
+
+	added, removed = []T{}, []T{}
+	for _, k := range newOrder {
+		if _, ok := oldIndex[k]; !ok {
+			added = append(added, newIndex[k])
+		}
+	}
+	for _, k := range oldOrder {
+		if _, ok := newIndex[k]; !ok {
+			removed = append(removed, oldIndex[k])
+		}
+	}
+	return added, removed
+}
+
+// EventChange is an event present in both snapshots (same ID) with different values.
+type EventChange struct {
+	ID     string   `json:"id"`
+	Old    Event    `json:"old"`
+	New    Event    `json:"new"`
+	Fields []string `json:"fields"`
+}
+
+// PlanningChanges represents the differences between two PlanningReport.
+// An event can be both in Moved and RoomChanged.
+type PlanningChanges struct {
+	Added       []Event       `json:"added"`
+	Removed     []Event       `json:"removed"`
+	Moved       []EventChange `json:"moved"`
+	RoomChanged []EventChange `json:"roomChanged"`
+	Changed     []EventChange `json:"changed"` // any other field
+}
+
+// IsEmpty reports whether nothing changed.
+func (pc *PlanningChanges) IsEmpty() bool {
+	return len(pc.Added) == 0 && len(pc.Removed) == 0 && len(pc.Moved) == 0 &&
+		len(pc.RoomChanged) == 0 && len(pc.Changed) == 0
+}
+
+func (pc *PlanningChanges) JSON() string {
+	return marshalChanges(pc)
+}
+
+// changedEventFields returns the names of the fields that differ between two events, except start, end and room.
+func changedEventFields(old, new Event) []string {
+	var fields []string
+	if old.AllDay != new.AllDay {
+		fields = append(fields, "allDay")
+	}
+	if old.ClassName != new.ClassName {
+		fields = append(fields, "className")
+	}
+	if old.Details.Type != new.Details.Type {
+		fields = append(fields, "type")
+	}
+	if old.Details.Subject != new.Details.Subject {
+		fields = append(fields, "subject")
+	}
+	if old.Details.Description != new.Details.Description {
+		fields = append(fields, "description")
+	}
+	if !slices.Equal(old.Details.Instructors, new.Details.Instructors) {
+		fields = append(fields, "instructors")
+	}
+	if !slices.Equal(old.Details.ClassGroups, new.Details.ClassGroups) {
+		fields = append(fields, "classGroups")
+	}
+	return fields
+}
+
+// DiffPlanning returns the events added, removed, moved, or whose room or details changed
+// between two reports. Events are matched by ID.
+func DiffPlanning(old, new *PlanningReport) *PlanningChanges {
+	var oldEvents, newEvents []Event
+	if old != nil {
+		oldEvents = old.Events
+	}
+	if new != nil {
+		newEvents = new.Events
+	}
+
+	eventID := func(e Event) string { return e.ID }
+	changes := &PlanningChanges{
+		Moved:       []EventChange{},
+		RoomChanged: []EventChange{},
+		Changed:     []EventChange{},
+	}
+	changes.Added, changes.Removed = diffKeyed(oldEvents, newEvents, eventID)
+
+	oldIndex, _ := keyed(oldEvents, eventID)
+	newIndex, newOrder := keyed(newEvents, eventID)
+	for _, key := range newOrder {
+		newEvent := newIndex[key]
+		oldEvent, ok := oldIndex[key]
+		if !ok {
+			continue
+		}
+
+		if !oldEvent.Start.Equal(newEvent.Start) || !oldEvent.End.Equal(newEvent.End) {
+			changes.Moved = append(changes.Moved, EventChange{ID: newEvent.ID, Old: oldEvent, New: newEvent, Fields: []string{"start", "end"}})
+		}
+		if oldEvent.Details.Room != newEvent.Details.Room {
+			changes.RoomChanged = append(changes.RoomChanged, EventChange{ID: newEvent.ID, Old: oldEvent, New: newEvent, Fields: []string{"room"}})
+		}
+		if fields := changedEventFields(oldEvent, newEvent); len(fields) > 0 {
+			changes.Changed = append(changes.Changed, EventChange{ID: newEvent.ID, Old: oldEvent, New: newEvent, Fields: fields})
+		}
+	}
+	return changes
+}
+
+// CatalogChanges represents the differences between two CatalogReport.
+type CatalogChanges struct {
+	Added   []cat.CatalogEntry `json:"added"`
+	Removed []cat.CatalogEntry `json:"removed"`
+}
+
+// IsEmpty reports whether nothing changed.
+func (cc *CatalogChanges) IsEmpty() bool {
+	return len(cc.Added) == 0 && len(cc.Removed) == 0
+}
+
+func (cc *CatalogChanges) JSON() string {
+	return marshalChanges(cc)
+}
+
+// CatalogEntryKey identifies a catalog entry across two snapshots (the row index is not stable).
+func CatalogEntryKey(e cat.CatalogEntry) string {
+	return e.Company + "|" + e.City + "|" + e.PostalCode + "|" + e.Year
+}
+
+// DiffCatalog returns the entries added or removed between two reports.
+func DiffCatalog(old, new *cat.CatalogReport) *CatalogChanges {
+	var oldEntries, newEntries []cat.CatalogEntry
+	if old != nil {
+		oldEntries = old.Entries
+	}
+	if new != nil {
+		newEntries = new.Entries
+	}
+
+	added, removed := diffKeyed(oldEntries, newEntries, CatalogEntryKey)
+	return &CatalogChanges{Added: added, Removed: removed}
+}
@@ -0,0 +1,110 @@
+package webaurion
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// diffEvent returns an event of one hour on 14/10/2024 at hour.
+func diffEvent(id string, hour int, room, subject string) Event {
+	start := time.Date(2024, time.October, 14, hour, 0, 0, 0, ParisLocation)
+	return Event{ID: id, Start: start, End: start.Add(time.Hour), Details: Details{Room: room, Subject: subject}}
+}
+
+func eventIDs(changes []EventChange) string {
+	var ids []string
+	for _, change := range changes {
+		ids = append(ids, change.ID)
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestDiffPlanning(t *testing.T) {
+	old := NewPlanningReport([]Event{
+		diffEvent("1", 8, "A101", "Maths"),
+		diffEvent("2", 10, "A102", "Physique"),
+		diffEvent("3", 13, "B201", "Java"),
+		diffEvent("4", 15, "B202", "Anglais"),
+		diffEvent("5", 17, "C301", "Sport"),
+	})
+	moved := diffEvent("2", 11, "A102", "Physique")
+	movedAndRoom := diffEvent("3", 14, "B205", "Java")
+	renamed := diffEvent("4", 15, "B202", "Anglais")
+	renamed.Details.Instructors = []string{"Smith"}
+	renamed.ClassName = "EXAMEN"
+	current := NewPlanningReport([]Event{
+		diffEvent("1", 8, "A101", "Maths"),
+		moved,
+		movedAndRoom,
+		renamed,
+		diffEvent("6", 17, "C302", "Projet"),
+	})
+
+	changes := DiffPlanning(old, current)
+	if len(changes.Added) != 1 || changes.Added[0].ID != "6" || len(changes.Removed) != 1 || changes.Removed[0].ID != "5" {
+		t.Errorf("got added %v, removed %v", changes.Added, changes.Removed)
+	}
+	if got := eventIDs(changes.Moved); got != "2,3" {
+		t.Errorf("got moved %s", got)
+	}
+	if got := eventIDs(changes.RoomChanged); got != "3" || changes.RoomChanged[0].Old.Details.Room != "B201" || changes.RoomChanged[0].New.Details.Room != "B205" {
+		t.Errorf("got room changes %+v", changes.RoomChanged)
+	}
+	if got := eventIDs(changes.Changed); got != "4" || strings.Join(changes.Changed[0].Fields, ",") != "className,instructors" {
+		t.Errorf("got changes %+v", changes.Changed)
+	}
+
+	if !DiffPlanning(old, old).IsEmpty() {
+		t.Error("a report doesn't differ from itself")
+	}
+	if changes := DiffPlanning(nil, current); len(changes.Added) != 5 || changes.IsEmpty() {
+		t.Errorf("without an old report, every event is added: %+v", changes)
+	}
+}
+
+func TestDiffPlanningDuplicatedIDs(t *testing.T) {
+	// WebAurion may give the same ID to the sessions of a course, they are matched in order
+	old := NewPlanningReport([]Event{
+		diffEvent("7", 8, "A101", "TP"),
+		diffEvent("7", 10, "A101", "TP"),
+	})
+	current := NewPlanningReport([]Event{
+		diffEvent("7", 8, "A101", "TP"),
+		diffEvent("7", 10, "A103", "TP"),
+		diffEvent("7", 14, "A101", "TP"),
+	})
+
+	changes := DiffPlanning(old, current)
+	if len(changes.Added) != 1 || changes.Added[0].Start.Hour() != 14 || len(changes.Removed) != 0 {
+		t.Errorf("got added %v, removed %v", changes.Added, changes.Removed)
+	}
+	if len(changes.RoomChanged) != 1 || changes.RoomChanged[0].New.Start.Hour() != 10 || len(changes.Moved) != 0 {
+		t.Errorf("got room changes %+v, moves %+v", changes.RoomChanged, changes.Moved)
+	}
+
+	if changes := DiffPlanning(current, old); len(changes.Removed) != 1 || changes.Removed[0].Start.Hour() != 14 {
+		t.Errorf("got removed %v", changes.Removed)
+	}
+}
+
+func TestDiffGrades(t *testing.T) {
+	old := NewGradeReport(0, []Grade{
+		{Code: "MATH_DS1", Grade: 12},
+		{Code: "INFO_TP1", Grade: 15},
+		{Date: "10/10/2024", Name: "Oral", Grade: 10},
+	})
+	current := NewGradeReport(0, []Grade{
+		{Code: "MATH_DS1", Grade: 13},
+		{Date: "10/10/2024", Name: "Oral", Grade: 10},
+		{Code: "PHYS_DS1", Grade: 9},
+	})
+
+	changes := DiffGrades(old, current)
+	if len(changes.Added) != 1 || changes.Added[0].Code != "PHYS_DS1" || len(changes.Removed) != 1 || changes.Removed[0].Code != "INFO_TP1" {
+		t.Errorf("got added %v, removed %v", changes.Added, changes.Removed)
+	}
+	if len(changes.Changed) != 1 || changes.Changed[0].Old.Grade != 12 || changes.Changed[0].New.Grade != 13 {
+		t.Errorf("got changes %+v", changes.Changed)
+	}
+}
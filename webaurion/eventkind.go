@@ -0,0 +1,199 @@
+package webaurion
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// EventKind is the normalized kind of a planning event.
+type EventKind int
+
+const (
+	EventKindOther EventKind = iota
+	EventKindLecture
+	EventKindTutorial
+	EventKindLab
+	EventKindExam
+	EventKindProject
+	EventKindHoliday
+	EventKindDeadline
+)
+
+var eventKindNames = map[EventKind]string{
+	EventKindOther:    "other",
+	EventKindLecture:  "lecture",
+	EventKindTutorial: "tutorial",
+	EventKindLab:      "lab",
+	EventKindExam:     "exam",
+	EventKindProject:  "project",
+	EventKindHoliday:  "holiday",
+	EventKindDeadline: "deadline",
+}
+
+// String returns the name of the EventKind.
+func (k EventKind) String() string {
+	if name, ok := eventKindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("EventKind(%d)", int(k))
+}
+
+// MarshalJSON implements the json.Marshaler interface for EventKind.
+func (k EventKind) MarshalJSON() ([]byte, error) {
+	return json.Marshal(k.String())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface for EventKind.
+func (k *EventKind) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	kind, err := ParseEventKind(name)
+	if err != nil {
+		return err
+	}
+	*k = kind
+	return nil
+}
+
+// ParseEventKind returns the EventKind of a name ("lecture", "exam"...).
+func ParseEventKind(name string) (EventKind, error) {
+	for kind, kindName := range eventKindNames {
+		if kindName == strings.ToLower(strings.TrimSpace(name)) {
+			return kind, nil
+		}
+	}
+	return EventKindOther, fmt.Errorf("invalid event kind: %s", name)
+}
+
+// EventKindStyle holds display hints of a kind for UIs.
+type EventKindStyle struct {
+	Color    string `json:"color"`    // CSS hex colour
+	Category string `json:"category"` // label, also used as ICS category
+}
+
+// EventKindStyles are the display hints of every kind, they can be changed.
+var EventKindStyles = map[EventKind]EventKindStyle{
+	EventKindOther:    {Color: "#9e9e9e", Category: "Autre"},
+	EventKindLecture:  {Color: "#1e88e5", Category: "Cours"},
+	EventKindTutorial: {Color: "#43a047", Category: "TD"},
+	EventKindLab:      {Color: "#fb8c00", Category: "TP"},
+	EventKindExam:     {Color: "#e53935", Category: "Examen"},
+	EventKindProject:  {Color: "#8e24aa", Category: "Projet"},
+	EventKindHoliday:  {Color: "#fdd835", Category: "Vacances"},
+	EventKindDeadline: {Color: "#00897b", Category: "Échéance"},
+}
+
+// Style returns the display hints of the kind.
+func (k EventKind) Style() EventKindStyle {
+	if style, ok := EventKindStyles[k]; ok {
+		return style
+	}
+	return EventKindStyles[EventKindOther]
+}
+
+// EventKindTable maps the type or the class name of events (lowercase, without accents)
+// to a kind. Whole values are looked up first ("travaux diriges", "est-epreuve"), then
+// their words ("td").
+type EventKindTable map[string]EventKind
+
+// DefaultEventKinds returns the mapping of the types and class names used by WebAurion.
+func DefaultEventKinds() EventKindTable {
+	return EventKindTable{
+		"cours": EventKindLecture, "cm": EventKindLecture, "cours magistral": EventKindLecture,
+		"amphi": EventKindLecture, "conference": EventKindLecture, "seminaire": EventKindLecture,
+
+		"td": EventKindTutorial, "travaux diriges": EventKindTutorial, "tutorat": EventKindTutorial,
+
+		"tp": EventKindLab, "travaux pratiques": EventKindLab, "atelier": EventKindLab,
+
+		"ds": EventKindExam, "exam": EventKindExam, "examen": EventKindExam, "examens": EventKindExam,
+		"partiel": EventKindExam, "partiels": EventKindExam, "soutenance": EventKindExam,
+		"evaluation": EventKindExam, "controle": EventKindExam, "rattrapage": EventKindExam,
+		"oral": EventKindExam, "epreuve": EventKindExam, "est-epreuve": EventKindExam,
+
+		"projet": EventKindProject, "project": EventKindProject,
+
+		"vacances": EventKindHoliday, "ferie": EventKindHoliday, "conge": EventKindHoliday,
+		"fermeture": EventKindHoliday,
+
+		"deadline": EventKindDeadline, "echeance": EventKindDeadline,
+	}
+}
+
+// EventKinds is the table used by Event.Kind, it can be changed or replaced.
+var EventKinds = DefaultEventKinds()
+
+func normalizeKindKey(value string) string {
+	return strings.ToLower(strings.TrimSpace(removeAccents(value)))
+}
+
+// Classify returns the kind of an event. Exams and holidays marked by the class name win over
+// the type, e.g. a "TD" with the class "est-epreuve" is an exam. Unknown all day events are
+// holidays.
+func (t EventKindTable) Classify(e Event) EventKind {
+	if kind := t.lookup(e.ClassName); kind == EventKindExam || kind == EventKindHoliday {
+		return kind
+	}
+	for _, value := range []string{e.Details.Type, e.ClassName} {
+		if kind, ok := t[normalizeKindKey(value)]; ok {
+			return kind
+		}
+	}
+	for _, value := range []string{e.Details.Type, e.ClassName} {
+		for _, word := range normalizedWords(value) {
+			if kind, ok := t[word]; ok {
+				return kind
+			}
+		}
+	}
+	if e.AllDay {
+		return EventKindHoliday
+	}
+	return EventKindOther
+}
+
+// lookup returns the kind of the whole value, or else of its first known word.
+func (t EventKindTable) lookup(value string) EventKind {
+	if kind, ok := t[normalizeKindKey(value)]; ok {
+		return kind
+	}
+	for _, word := range normalizedWords(value) {
+		if kind, ok := t[word]; ok {
+			return kind
+		}
+	}
+	return EventKindOther
+}
+
+// Kind returns the kind of the event according to EventKinds.
+func (e *Event) Kind() EventKind {
+	return EventKinds.Classify(*e)
+}
+
+// Only returns the events of the given kinds.
+func (pr *PlanningReport) Only(kinds ...EventKind) *PlanningReport {
+	return pr.filterEvents(func(e Event) bool { return slices.Contains(kinds, e.Kind()) })
+}
+
+// Except returns the events that are not of the given kinds.
+func (pr *PlanningReport) Except(kinds ...EventKind) *PlanningReport {
+	return pr.filterEvents(func(e Event) bool { return !slices.Contains(kinds, e.Kind()) })
+}
+
+// filterEvents returns a new PlanningReport with the events matching keep.
+func (pr *PlanningReport) filterEvents(keep func(Event) bool) *PlanningReport {
+	events := []Event{}
+	for _, event := range pr.Events {
+		if keep(event) {
+			events = append(events, event)
+		}
+	}
+	report := NewPlanningReport(events)
+	report.Warnings = pr.Warnings
+	report.Location = pr.Location
+	return report
+}
@@ -0,0 +1,31 @@
+package webaurion
+
+import "testing"
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name      string
+		typ       string
+		className string
+		allDay    bool
+		want      EventKind
+	}{
+		{"type", "TD", "", false, EventKindTutorial},
+		{"class name", "", "TP", false, EventKindLab},
+		{"type before class name", "CM", "projet", false, EventKindLecture},
+		{"exam class wins over TD", "TD", "est-epreuve", false, EventKindExam},
+		{"exam class wins over CM", "CM", "est-epreuve", false, EventKindExam},
+		{"holiday class wins", "Cours", "Vacances", true, EventKindHoliday},
+		{"words", "Séance de TP", "", false, EventKindLab},
+		{"unknown all day", "", "", true, EventKindHoliday},
+		{"unknown", "Réunion", "", false, EventKindOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := Event{ClassName: tt.className, AllDay: tt.allDay, Details: Details{Type: tt.typ}}
+			if got := DefaultEventKinds().Classify(e); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
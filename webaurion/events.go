@@ -0,0 +1,276 @@
+package webaurion
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RawEvent is an event of the planning as sent by WebAurion (PrimeFaces schedule JSON).
+type RawEvent struct {
+	ID        flexString `json:"id"`
+	Title     string     `json:"title"`
+	Start     string     `json:"start"`
+	End       string     `json:"end"`
+	AllDay    flexBool   `json:"allDay"`
+	ClassName string     `json:"className"`
+}
+
+// flexString decodes a JSON string, number or null as a string (event IDs are sometimes numbers).
+type flexString string
+
+func (s *flexString) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*s = ""
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		*s = flexString(value)
+		return nil
+	}
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("expected a string or a number, got %s", data)
+	}
+	*s = flexString(number.String())
+	return nil
+}
+
+// flexBool decodes a JSON boolean, a "true"/"false" string or null.
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case nil:
+		*b = false
+	case bool:
+		*b = flexBool(v)
+	case string:
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("expected a boolean, got %q", v)
+		}
+		*b = flexBool(parsed)
+	default:
+		return fmt.Errorf("expected a boolean, got %s", data)
+	}
+	return nil
+}
+
+// event time layouts, the first one is the one WebAurion uses
+var eventTimeLayouts = []string{
+	"2006-01-02T15:04:05-0700",
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// parseEventTime parses a start or end of event, times without offset are in location.
+func parseEventTime(value string, location *time.Location) (time.Time, error) {
+	for _, layout := range eventTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, location); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time: %q", value)
+}
+
+// NewEvent creates an Event from the JSON of WebAurion. Problems in the title don't fail:
+// they are returned as warnings and the missing details are left empty.
+func NewEvent(raw RawEvent) (*Event, []string, error) {
+	return newEvent(raw, ParisLocation)
+}
+
+// newEvent is NewEvent with the timezone of the times without offset.
+func newEvent(raw RawEvent, location *time.Location) (*Event, []string, error) {
+	start, err := parseEventTime(raw.Start, location)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing start time: %v", err)
+	}
+	end, err := parseEventTime(raw.End, location)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error parsing end time: %v", err)
+	}
+
+	details, warnings := parseEventTitle(raw.Title)
+	return &Event{
+		ID:        string(raw.ID),
+		Start:     start,
+		End:       end,
+		AllDay:    bool(raw.AllDay),
+		ClassName: raw.ClassName,
+		Details:   details,
+	}, warnings, nil
+}
+
+// event types written by WebAurion in the titles (lowercase, without accents)
+var eventTypeTokens = map[string]bool{
+	"cours": true, "cm": true, "cours magistral": true,
+	"td": true, "travaux diriges": true,
+	"tp": true, "travaux pratiques": true,
+	"ds": true, "examen": true, "exam": true, "evaluation": true, "controle": true,
+	"partiel": true, "rattrapage": true, "soutenance": true, "oral": true,
+	"projet": true, "reunion": true, "conference": true, "seminaire": true,
+	"atelier": true, "tutorat": true, "stage": true, "entretien": true,
+	"ferie": true, "vacances": true, "conge": true, "autre": true,
+}
+
+// isEventType reports whether a part of a title is an event type, e.g. "TD" or "Cours".
+func isEventType(part string) bool {
+	return eventTypeTokens[strings.ToLower(removeAccents(part))]
+}
+
+var eventTimeRegex = regexp.MustCompile(`^\d{1,2}[:h]\d{2}`)
+
+// looksLikeNames reports whether every " / " item of a part looks like "SURNAME Firstname".
+func looksLikeNames(part string) bool {
+	if part == "" {
+		return false
+	}
+	for _, name := range splitList(part) {
+		hasUpper, hasLower := false, false
+		for _, word := range strings.Fields(name) {
+			letters := strings.Trim(word, "-'.")
+			switch {
+			case len([]rune(letters)) > 1 && letters == strings.ToUpper(letters) && letters != strings.ToLower(letters) && !strings.ContainsAny(letters, "0123456789"):
+				hasUpper = true
+			case letters != strings.ToUpper(letters):
+				hasLower = true
+			}
+		}
+		if !hasUpper || !hasLower {
+			return false
+		}
+	}
+	return true
+}
+
+// splitList splits "A / B" lists, dropping empty items.
+func splitList(part string) []string {
+	var items []string
+	for _, item := range strings.Split(part, "/") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseEventTitle parses the event title into Details. Titles look like
+// "time - room - type - subject - description - instructors - groups", but the room,
+// the description, the instructors and the groups may be missing and the subject may
+// contain " - ", so the parser anchors on the type token.
+func parseEventTitle(title string) (Details, []string) {
+	var warnings []string
+	parts := strings.Split(title, " - ")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	if strings.TrimSpace(title) == "" {
+		return Details{}, []string{"empty event title"}
+	}
+
+	var details Details
+
+	// time, a range may have been split by " - "
+	rest := parts
+	if eventTimeRegex.MatchString(rest[0]) {
+		details.Time = rest[0]
+		rest = rest[1:]
+		if len(rest) > 0 && eventTimeRegex.MatchString(rest[0]) && !isEventType(rest[0]) {
+			details.Time += " - " + rest[0]
+			rest = rest[1:]
+		}
+	} else {
+		warnings = append(warnings, fmt.Sprintf("no time in event title %q", title))
+	}
+
+	// room and type, the room is what comes before the type token
+	typeIndex := -1
+	for i, part := range rest {
+		if isEventType(part) {
+			typeIndex = i
+			break
+		}
+	}
+	if typeIndex < 0 && len(rest) >= 2 {
+		// unknown type: fall back on the positions of the full title
+		typeIndex = 1
+		warnings = append(warnings, fmt.Sprintf("unknown event type %q in title %q", rest[1], title))
+	}
+	if typeIndex < 0 {
+		warnings = append(warnings, fmt.Sprintf("no event type in title %q", title))
+		details.Subject = strings.Join(rest, " - ")
+		return details, warnings
+	}
+	details.Room = strings.Join(rest[:typeIndex], " - ")
+	details.Type = rest[typeIndex]
+	rest = rest[typeIndex+1:]
+
+	// instructors and groups, from the end
+	switch {
+	case len(rest) >= 2 && looksLikeNames(rest[len(rest)-1]):
+		details.Instructors = splitList(rest[len(rest)-1])
+		rest = rest[:len(rest)-1]
+	case len(rest) >= 3 && looksLikeNames(rest[len(rest)-2]):
+		details.Instructors = splitList(rest[len(rest)-2])
+		details.ClassGroups = splitList(rest[len(rest)-1])
+		rest = rest[:len(rest)-2]
+	case len(rest) >= 4:
+		// too many parts for a subject and a description, but none looks like names:
+		// they are kept in the subject rather than guessed
+		warnings = append(warnings, fmt.Sprintf("no instructors in event title %q", title))
+	}
+
+	// subject and description, the subject takes the extra parts
+	switch len(rest) {
+	case 0:
+	case 1:
+		details.Subject = rest[0]
+	default:
+		details.Subject = strings.Join(rest[:len(rest)-1], " - ")
+		details.Description = rest[len(rest)-1]
+	}
+	if details.Subject == "" {
+		warnings = append(warnings, fmt.Sprintf("no subject in event title %q", title))
+	}
+	return details, warnings
+}
+
+// parseEvents decodes the events one by one so that an invalid event is skipped with a
+// warning instead of failing the planning.
+func parseEvents(rawEvents []json.RawMessage, location *time.Location) ([]Event, []string) {
+	var events []Event
+	var warnings []string
+	for i, data := range rawEvents {
+		var raw RawEvent
+		if err := json.Unmarshal(data, &raw); err != nil {
+			warnings = append(warnings, fmt.Sprintf("event %d: %v", i, err))
+			continue
+		}
+		event, eventWarnings, err := newEvent(raw, location)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("event %d (%s): %v", i, raw.ID, err))
+			continue
+		}
+		for _, warning := range eventWarnings {
+			warnings = append(warnings, fmt.Sprintf("event %d (%s): %s", i, raw.ID, warning))
+		}
+		events = append(events, *event)
+	}
+	return events, warnings
+}
@@ -0,0 +1,194 @@
+package webaurion
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseEventTitle(t *testing.T) {
+	tests := []struct {
+		name     string
+		title    string
+		want     Details
+		warnings int
+	}{
+		{
+			"4 parts",
+			"08:00 - A101 - Cours - Mathématiques",
+			Details{Time: "08:00", Room: "A101", Type: "Cours", Subject: "Mathématiques"},
+			0,
+		},
+		{
+			"5 parts",
+			"08:00 - A101 - TD - Mathématiques - DUPONT Jean",
+			Details{Time: "08:00", Room: "A101", Type: "TD", Subject: "Mathématiques", Instructors: []string{"DUPONT Jean"}},
+			0,
+		},
+		{
+			"6 parts",
+			"08:00 - A101 - TP - Java - POO - MARTIN Paul / DURAND Léa",
+			Details{Time: "08:00", Room: "A101", Type: "TP", Subject: "Java", Description: "POO", Instructors: []string{"MARTIN Paul", "DURAND Léa"}},
+			0,
+		},
+		{
+			"7 parts",
+			"08:00 - A101 - TP - Java - POO - MARTIN Paul - CIR1 / CIR2",
+			Details{Time: "08:00", Room: "A101", Type: "TP", Subject: "Java", Description: "POO", Instructors: []string{"MARTIN Paul"}, ClassGroups: []string{"CIR1", "CIR2"}},
+			0,
+		},
+		{
+			"time range",
+			"08:00 - 10:00 - A101 - Cours - Physique",
+			Details{Time: "08:00 - 10:00", Room: "A101", Type: "Cours", Subject: "Physique"},
+			0,
+		},
+		{
+			"missing room",
+			"08:00 - DS - Électronique - LEBLANC Marie",
+			Details{Time: "08:00", Type: "DS", Subject: "Électronique", Instructors: []string{"LEBLANC Marie"}},
+			0,
+		},
+		{
+			"subject containing a dash",
+			"08:00 - A101 - Cours - Anglais - Business English - Oral - SMITH John - CIR2",
+			Details{Time: "08:00", Room: "A101", Type: "Cours", Subject: "Anglais - Business English", Description: "Oral", Instructors: []string{"SMITH John"}, ClassGroups: []string{"CIR2"}},
+			0,
+		},
+		{
+			"no names",
+			"08:00 - A101 - Cours - Java - POO - desc2 - CIR1",
+			Details{Time: "08:00", Room: "A101", Type: "Cours", Subject: "Java - POO - desc2", Description: "CIR1"},
+			1,
+		},
+		{
+			"unknown type",
+			"08:00 - A101 - Hackathon - Projet web",
+			Details{Time: "08:00", Room: "A101", Type: "Hackathon", Subject: "Projet web"},
+			1,
+		},
+		{
+			"no time",
+			"A101 - Cours - Maths",
+			Details{Room: "A101", Type: "Cours", Subject: "Maths"},
+			1,
+		},
+		{
+			"no type",
+			"08:00 - Réunion d'information",
+			Details{Time: "08:00", Subject: "Réunion d'information"},
+			1,
+		},
+		{"empty", "  ", Details{}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warnings := parseEventTitle(tt.title)
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(tt.want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("got %s\nwant %s", gotJSON, wantJSON)
+			}
+			if len(warnings) != tt.warnings {
+				t.Errorf("got warnings %q, want %d", warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestLooksLikeNames(t *testing.T) {
+	tests := map[string]bool{
+		"DUPONT Jean":                     true,
+		"LE GALL Anne-Marie / SMITH John": true,
+		"Jean":                            false,
+		"CIR1":                            false,
+		"CIR1 / CIR2":                     false,
+		"desc2":                           false,
+		"":                                false,
+	}
+	for part, want := range tests {
+		if got := looksLikeNames(part); got != want {
+			t.Errorf("looksLikeNames(%q) = %v, want %v", part, got, want)
+		}
+	}
+}
+
+func TestRawEventJSON(t *testing.T) {
+	tests := []struct {
+		json   string
+		id     string
+		allDay bool
+		ok     bool
+	}{
+		{`{"id":"123","allDay":true}`, "123", true, true},
+		{`{"id":123,"allDay":"true"}`, "123", true, true},
+		{`{"id":1.5e3,"allDay":"false"}`, "1.5e3", false, true},
+		{`{"id":null,"allDay":null}`, "", false, true},
+		{`{"id":true}`, "", false, false},
+		{`{"id":"1","allDay":"oui"}`, "", false, false},
+		{`{"id":"1","allDay":1}`, "", false, false},
+	}
+	for _, tt := range tests {
+		var raw RawEvent
+		err := json.Unmarshal([]byte(tt.json), &raw)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: got error %v, want ok=%v", tt.json, err, tt.ok)
+			continue
+		}
+		if tt.ok && (string(raw.ID) != tt.id || bool(raw.AllDay) != tt.allDay) {
+			t.Errorf("%s: got id %q, allDay %v", tt.json, raw.ID, raw.AllDay)
+		}
+	}
+}
+
+func TestParseEventTime(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+		ok    bool
+	}{
+		{"2024-10-14T08:00:00+0200", time.Date(2024, time.October, 14, 6, 0, 0, 0, time.UTC), true},
+		{"2024-10-14T08:00:00+02:00", time.Date(2024, time.October, 14, 6, 0, 0, 0, time.UTC), true},
+		{"2024-10-14T08:00:00", time.Date(2024, time.October, 14, 8, 0, 0, 0, ParisLocation), true},
+		{"2024-10-14T08:00", time.Date(2024, time.October, 14, 8, 0, 0, 0, ParisLocation), true},
+		{"2024-10-14", time.Date(2024, time.October, 14, 0, 0, 0, 0, ParisLocation), true},
+		{"14/10/2024 08:00", time.Time{}, false},
+		{"1728892800000", time.Time{}, false},
+		{"", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, err := parseEventTime(tt.value, ParisLocation)
+		if (err == nil) != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("parseEventTime(%q) = %v, %v, want %v", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseEventsWarnings(t *testing.T) {
+	var rawEvents []json.RawMessage
+	for _, event := range []string{
+		`{"id":1,"title":"08:00 - A101 - Cours - Maths - DUPONT Jean","start":"2024-10-14T08:00:00+0200","end":"2024-10-14T10:00:00+0200","allDay":false}`,
+		`{"id":2,"title":"10:00 - A101 - Cours - Maths","start":"demain","end":"2024-10-14T12:00:00+0200"}`,
+		`{"id":[3],"title":"x"}`,
+		`{"id":"4","title":"08:00 - A101 - Cours - Java - POO - desc2 - CIR1","start":"2024-10-15T08:00:00","end":"2024-10-15T10:00:00","allDay":"false"}`,
+	} {
+		rawEvents = append(rawEvents, json.RawMessage(event))
+	}
+
+	events, warnings := parseEvents(rawEvents, ParisLocation)
+	if len(events) != 2 || events[0].ID != "1" || events[1].ID != "4" {
+		t.Fatalf("got events %+v", events)
+	}
+	if len(events[1].Details.Instructors) != 0 {
+		t.Errorf("got instructors %v", events[1].Details.Instructors)
+	}
+	if len(warnings) != 3 {
+		t.Fatalf("got warnings %q", warnings)
+	}
+	for i, prefix := range []string{"event 1 (2): error parsing start time", "event 2: ", "event 3 (4): no instructors"} {
+		if !strings.HasPrefix(warnings[i], prefix) {
+			t.Errorf("warning %d: got %q, want %q", i, warnings[i], prefix)
+		}
+	}
+}
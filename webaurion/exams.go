@@ -0,0 +1,155 @@
+package webaurion
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// normalizedWords splits text into lowercase words without accents.
+func normalizedWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(removeAccents(text)), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// IsExam reports whether the event is an exam (DS, examen, partiel, soutenance...).
+func (e *Event) IsExam() bool {
+	return e.Kind() == EventKindExam
+}
+
+// Exams returns the exams of the planning, sorted by start.
+func (pr *PlanningReport) Exams() []Event {
+	exams := pr.Only(EventKindExam).Events
+	slices.SortStableFunc(exams, func(a, b Event) int { return a.Start.Compare(b.Start) })
+	return exams
+}
+
+// Exam is an exam of the planning with the grades of its subject.
+type Exam struct {
+	Event         Event   `json:"event"`
+	DaysRemaining int     `json:"daysRemaining"` // calendar days until the exam, negative once past
+	Grades        []Grade `json:"grades"`        // grades already received in the subject
+	Conflicts     []Event `json:"conflicts,omitempty"`
+}
+
+// ExamSchedule is the exam timetable of a planning.
+type ExamSchedule struct {
+	Exams []Exam `json:"exams"`
+}
+
+// daysBetween returns the number of calendar days of location from a to b.
+func daysBetween(a, b time.Time, location *time.Location) int {
+	return int(math.Round(startOfDay(b, location).Sub(startOfDay(a, location)).Hours() / 24))
+}
+
+// gradeMatchesSubject reports whether a grade belongs to the subject of an event: the
+// subject is in the grade name, or a segment of the module code starts a word of the subject
+// (e.g. "24_CIR3_MATH_DS1" and "Mathématiques").
+func gradeMatchesSubject(grade Grade, subject string) bool {
+	if strings.TrimSpace(subject) == "" {
+		return false
+	}
+	if containsFold(grade.Name, subject) {
+		return true
+	}
+
+	words := normalizedWords(subject)
+	for _, segment := range strings.Split(ModuleCode(grade.Code), "_") {
+		segment = strings.ToLower(removeAccents(segment))
+		// skip years and classes ("24", "CIR3"), they aren't subjects
+		if len(segment) < 3 || strings.ContainsAny(segment, "0123456789") {
+			continue
+		}
+		for _, word := range words {
+			if strings.HasPrefix(word, segment) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ExamSchedule returns the exams of the planning with the days remaining from now,
+// the grades of their subject in grades (nil for none) and the events they overlap.
+func (pr *PlanningReport) ExamSchedule(grades *GradeReport, now time.Time) *ExamSchedule {
+	schedule := &ExamSchedule{Exams: []Exam{}}
+	for _, event := range pr.Exams() {
+		exam := Exam{
+			Event:         event,
+			DaysRemaining: daysBetween(now, event.Start, orParis(pr.Location)),
+			Grades:        []Grade{},
+		}
+		if grades != nil {
+			for _, grade := range grades.Grades {
+				if gradeMatchesSubject(grade, event.Details.Subject) {
+					exam.Grades = append(exam.Grades, grade)
+				}
+			}
+		}
+		for _, other := range pr.Events {
+			if other.ID == event.ID && other.Start.Equal(event.Start) {
+				continue
+			}
+			if !other.AllDay && other.Slot().Overlaps(event.Slot()) {
+				exam.Conflicts = append(exam.Conflicts, other)
+			}
+		}
+		schedule.Exams = append(schedule.Exams, exam)
+	}
+	return schedule
+}
+
+// Upcoming returns the exams from today on.
+func (es *ExamSchedule) Upcoming() []Exam {
+	exams := []Exam{}
+	for _, exam := range es.Exams {
+		if exam.DaysRemaining >= 0 {
+			exams = append(exams, exam)
+		}
+	}
+	return exams
+}
+
+// Conflicts returns the exams overlapping another event.
+func (es *ExamSchedule) Conflicts() []Exam {
+	exams := []Exam{}
+	for _, exam := range es.Exams {
+		if len(exam.Conflicts) > 0 {
+			exams = append(exams, exam)
+		}
+	}
+	return exams
+}
+
+// String returns a string representation of the ExamSchedule.
+func (es *ExamSchedule) String() string {
+	return fmt.Sprintf("ExamSchedule(exams=%d, upcoming=%d, conflicts=%d)", len(es.Exams), len(es.Upcoming()), len(es.Conflicts()))
+}
+
+// Get returns the value of a specific key for the ExamSchedule.
+func (es *ExamSchedule) Get(key string) (interface{}, error) {
+	switch key {
+	case "exams":
+		return es.Exams, nil
+	case "upcoming":
+		return es.Upcoming(), nil
+	case "conflicts":
+		return es.Conflicts(), nil
+	default:
+		return nil, fmt.Errorf("invalid key: %s, valid keys are 'exams', 'upcoming' and 'conflicts'", key)
+	}
+}
+
+// JSON returns the JSON representation of the ExamSchedule.
+func (es *ExamSchedule) JSON() string {
+	data, err := json.MarshalIndent(es, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("Error marshaling to JSON: %v", err)
+	}
+	return string(data)
+}
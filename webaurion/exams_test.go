@@ -0,0 +1,104 @@
+package webaurion
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGradeMatchesSubject(t *testing.T) {
+	tests := []struct {
+		code, name, subject string
+		want                bool
+	}{
+		{"24_CIR3_MATH_DS1", "DS1", "Mathématiques", true},
+		{"24_CIR3_MATH_DS1", "DS1", "mathematiques appliquees", true},
+		{"24_CIR3_XX_DS1", "DS de Physique", "physique", true},
+		// MATHAPP shares the MATH prefix but isn't a prefix of "Mathématiques"
+		{"24_CIR3_MATHAPP_TP1", "TP1", "Mathématiques", false},
+		{"24_CIR3_PHYS_DS1", "DS1", "Mathématiques", false},
+		// years and classes aren't subjects
+		{"24_CIR3_XX_DS1", "DS1", "CIR3 24h", false},
+		{"24_CIR3_MATH_DS1", "DS1", " ", false},
+	}
+	for _, tt := range tests {
+		grade := Grade{Code: tt.code, Name: tt.name}
+		if got := gradeMatchesSubject(grade, tt.subject); got != tt.want {
+			t.Errorf("gradeMatchesSubject(%s %q, %q) = %v, want %v", tt.code, tt.name, tt.subject, got, tt.want)
+		}
+	}
+}
+
+func TestDaysBetween(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b time.Time
+		want int
+	}{
+		{"same day", day(28, 8), day(28, 23), 0},
+		// the 27th has 25 hours
+		{"across the switch", day(25, 12), day(28, 8), 3},
+		{"late evening", time.Date(2024, time.October, 26, 23, 30, 0, 0, ParisLocation), day(28, 0), 2},
+		{"past", day(28, 8), day(25, 12), -3},
+	}
+	for _, tt := range tests {
+		if got := daysBetween(tt.a, tt.b, ParisLocation); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestExamSchedule(t *testing.T) {
+	planning := NewPlanningReport([]Event{
+		{ID: "info", Start: day(28, 10), End: day(28, 12), Details: Details{Type: "Cours", Subject: "Informatique"}},
+		{ID: "phys", Start: day(28, 9), End: day(28, 11), Details: Details{Type: "Examen", Subject: "Physique"}},
+		{ID: "math", Start: day(28, 8), End: day(28, 10), Details: Details{Type: "DS", Subject: "Mathématiques"}},
+		{ID: "old", Start: day(14, 8), End: day(14, 10), Details: Details{Type: "Partiel", Subject: "Informatique"}},
+		{ID: "holiday", Start: day(28, 0), End: day(29, 0), AllDay: true, Details: Details{Type: "Férié"}},
+	})
+	grades := NewGradeReport(12, []Grade{
+		{Code: "24_CIR3_MATH_DS1", Name: "DS1", Grade: 12},
+		{Code: "24_CIR3_MATHAPP_TP1", Name: "TP1", Grade: 15},
+		{Code: "24_CIR3_PHYS_DS1", Name: "DS1", Grade: 9},
+	})
+
+	schedule := planning.ExamSchedule(grades, day(25, 12))
+	type want struct {
+		id        string
+		days      int
+		grades    string
+		conflicts string
+	}
+	wants := []want{
+		{"old", -11, "", ""},
+		{"math", 3, "24_CIR3_MATH_DS1", "phys"},
+		{"phys", 3, "24_CIR3_PHYS_DS1", "info,math"},
+	}
+	if len(schedule.Exams) != len(wants) {
+		t.Fatalf("got %d exams, want %d", len(schedule.Exams), len(wants))
+	}
+	for i, w := range wants {
+		exam := schedule.Exams[i]
+		var codes, conflicts []string
+		for _, grade := range exam.Grades {
+			codes = append(codes, grade.Code)
+		}
+		for _, event := range exam.Conflicts {
+			conflicts = append(conflicts, event.ID)
+		}
+		got := want{exam.Event.ID, exam.DaysRemaining, strings.Join(codes, ","), strings.Join(conflicts, ",")}
+		if got != w {
+			t.Errorf("exam %d: got %+v, want %+v", i, got, w)
+		}
+	}
+
+	if got := len(schedule.Upcoming()); got != 2 {
+		t.Errorf("got %d upcoming exams, want 2", got)
+	}
+	if got := len(schedule.Conflicts()); got != 2 {
+		t.Errorf("got %d exams in conflict, want 2", got)
+	}
+	if exam := planning.ExamSchedule(nil, day(25, 12)).Exams[1]; exam.Grades == nil || len(exam.Grades) != 0 {
+		t.Errorf("got grades %v without a grade report, want an empty list", exam.Grades)
+	}
+}
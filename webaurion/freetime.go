@@ -0,0 +1,117 @@
+package webaurion
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// MeetingConstraints restricts the search of common free time.
+type MeetingConstraints struct {
+	From          time.Time      // first day searched
+	To            time.Time      // last day searched (inclusive)
+	Workday       Workday        // hours of the day searched, zero for DefaultWorkday
+	MinDuration   time.Duration  // shortest slot returned
+	ExcludedDays  []time.Weekday // e.g. time.Saturday, time.Sunday
+	ExcludedDates []time.Time    // e.g. bank holidays, compared by calendar day
+	Limit         int            // maximum number of slots returned, <= 0 for all
+}
+
+// MeetingSlot is a slot where everyone is free.
+type MeetingSlot struct {
+	TimeSlot
+	Rank int `json:"rank"` // 1 for the best slot
+}
+
+// String returns a string representation of the MeetingSlot.
+func (s MeetingSlot) String() string {
+	return fmt.Sprintf("MeetingSlot(rank=%d, start='%s', end='%s')", s.Rank, s.Start.Format(time.RFC3339), s.End.Format(time.RFC3339))
+}
+
+// excluded reports whether the calendar day of date, a midnight, is excluded.
+func (c MeetingConstraints) excluded(date time.Time) bool {
+	if slices.Contains(c.ExcludedDays, date.Weekday()) {
+		return true
+	}
+	for _, excluded := range c.ExcludedDates {
+		if startOfDay(excluded, date.Location()).Equal(date) {
+			return true
+		}
+	}
+	return false
+}
+
+// CommonFreeSlots returns the slots where none of the plannings has an event, e.g. the
+// plannings of the members of a group project fetched from different sessions.
+//
+// Days are the calendar days of the Location of the first planning. Slots are ranked longest
+// first, then earliest first. All day events don't block any time.
+func CommonFreeSlots(plannings []*PlanningReport, constraints MeetingConstraints) ([]MeetingSlot, error) {
+	if constraints.From.IsZero() || constraints.To.IsZero() {
+		return nil, fmt.Errorf("the search period needs a From and a To")
+	}
+	if constraints.To.Before(constraints.From) {
+		return nil, fmt.Errorf("invalid search period: %s is before %s", constraints.To.Format("2006-01-02"), constraints.From.Format("2006-01-02"))
+	}
+	workday := constraints.Workday
+	if workday == (Workday{}) {
+		workday = DefaultWorkday
+	}
+	if workday.End <= workday.Start {
+		return nil, fmt.Errorf("invalid workday: it ends before it starts")
+	}
+
+	var events []Event
+	var location *time.Location
+	for _, planning := range plannings {
+		if planning != nil {
+			events = append(events, planning.Events...)
+			if location == nil {
+				location = planning.Location
+			}
+		}
+	}
+	location = orParis(location)
+
+	slots := []MeetingSlot{}
+	last := startOfDay(constraints.To, location)
+	for day := startOfDay(constraints.From, location); !day.After(last); day = day.AddDate(0, 0, 1) {
+		if constraints.excluded(day) {
+			continue
+		}
+		window := workday.On(day)
+		for _, free := range freeSlots(busySlots(events, window), window, constraints.MinDuration) {
+			slots = append(slots, MeetingSlot{TimeSlot: free})
+		}
+	}
+
+	slices.SortStableFunc(slots, func(a, b MeetingSlot) int {
+		if c := cmp.Compare(b.Duration(), a.Duration()); c != 0 {
+			return c
+		}
+		return a.Start.Compare(b.Start)
+	})
+	if constraints.Limit > 0 && len(slots) > constraints.Limit {
+		slots = slots[:constraints.Limit]
+	}
+	for i := range slots {
+		slots[i].Rank = i + 1
+	}
+	return slots, nil
+}
+
+// MeetingICS returns an iCalendar of the proposed meeting slots, titled summary.
+func MeetingICS(slots []MeetingSlot, summary string) *ICSCalendar {
+	calendar := &ICSCalendar{Name: summary}
+	for _, slot := range slots {
+		calendar.Events = append(calendar.Events, ICSEvent{
+			UID:         icsUID("meeting", slot.Start.UTC().Format("20060102T150405Z")),
+			Start:       slot.Start,
+			End:         slot.End,
+			Summary:     summary,
+			Description: fmt.Sprintf("Proposed slot #%d (%s)", slot.Rank, slot.Duration()),
+		})
+	}
+	return calendar
+}
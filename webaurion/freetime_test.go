@@ -0,0 +1,116 @@
+package webaurion
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// day returns the time of day of the October 2024 day in Paris, 14 is a Monday.
+func day(d, hour int) time.Time {
+	return time.Date(2024, time.October, d, hour, 0, 0, 0, ParisLocation)
+}
+
+func meetingPlannings() []*PlanningReport {
+	return []*PlanningReport{
+		NewPlanningReport([]Event{
+			{ID: "a1", Start: day(14, 8), End: day(14, 12)},
+			{ID: "a2", Start: day(16, 8), End: day(16, 18)},
+		}),
+		NewPlanningReport([]Event{
+			{ID: "b1", Start: day(14, 11), End: day(14, 14)},
+			{ID: "b2", Start: day(14, 16), End: day(14, 19)},
+		}),
+		NewPlanningReport([]Event{
+			{ID: "c1", Start: day(14, 14), End: day(14, 15)},
+			{ID: "c2", Start: day(17, 0), End: day(18, 0), AllDay: true}, // doesn't block
+		}),
+	}
+}
+
+func formatMeetingSlots(slots []MeetingSlot) string {
+	var parts []string
+	for _, slot := range slots {
+		parts = append(parts, slot.Start.Format("02 15:04")+"-"+slot.End.Format("15:04"))
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestCommonFreeSlots(t *testing.T) {
+	base := MeetingConstraints{From: day(14, 0), To: day(17, 0), ExcludedDates: []time.Time{day(15, 12)}}
+	tests := []struct {
+		name   string
+		modify func(*MeetingConstraints)
+		want   string
+	}{
+		// longest first, then earliest first, the 15th is excluded
+		{"all", func(*MeetingConstraints) {}, "17 08:00-19:00,14 15:00-16:00,16 18:00-19:00"},
+		{"min duration", func(c *MeetingConstraints) { c.MinDuration = 90 * time.Minute }, "17 08:00-19:00"},
+		{"limit", func(c *MeetingConstraints) { c.Limit = 2 }, "17 08:00-19:00,14 15:00-16:00"},
+		{"excluded day", func(c *MeetingConstraints) { c.ExcludedDays = []time.Weekday{time.Thursday} }, "14 15:00-16:00,16 18:00-19:00"},
+		{"no excluded date", func(c *MeetingConstraints) { c.ExcludedDates = nil }, "15 08:00-19:00,17 08:00-19:00,14 15:00-16:00,16 18:00-19:00"},
+		{"workday", func(c *MeetingConstraints) { c.Workday = Workday{Start: 14 * time.Hour, End: 17 * time.Hour} }, "17 14:00-17:00,14 15:00-16:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			constraints := base
+			tt.modify(&constraints)
+			slots, err := CommonFreeSlots(meetingPlannings(), constraints)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := formatMeetingSlots(slots); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+			for i, slot := range slots {
+				if slot.Rank != i+1 {
+					t.Errorf("slot %d has rank %d", i, slot.Rank)
+				}
+			}
+		})
+	}
+}
+
+func TestCommonFreeSlotsErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		constraints MeetingConstraints
+		err         string
+	}{
+		{"no end", MeetingConstraints{From: day(14, 0)}, "needs a From and a To"},
+		{"reversed period", MeetingConstraints{From: day(17, 0), To: day(14, 0)}, "invalid search period"},
+		{"reversed workday", MeetingConstraints{From: day(14, 0), To: day(17, 0), Workday: Workday{Start: 18 * time.Hour, End: 8 * time.Hour}}, "invalid workday"},
+	}
+	for _, tt := range tests {
+		if _, err := CommonFreeSlots(meetingPlannings(), tt.constraints); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: got %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestMeetingICS(t *testing.T) {
+	slots, err := CommonFreeSlots(meetingPlannings(), MeetingConstraints{From: day(14, 0), To: day(17, 0), Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	calendar := MeetingICS(slots, "Réunion projet, S5")
+	calendar.Stamp = day(1, 0)
+	ics := calendar.String()
+
+	if got := strings.Count(ics, "BEGIN:VEVENT"); got != 2 {
+		t.Fatalf("got %d events:\n%s", got, ics)
+	}
+	for _, want := range []string{
+		"X-WR-CALNAME:Réunion projet\\, S5\r\n",
+		"UID:meeting-20241015T060000Z@isengo\r\n",
+		"DTSTART:20241015T060000Z\r\nDTEND:20241015T170000Z\r\n",
+		"SUMMARY:Réunion projet\\, S5\r\n",
+		"DESCRIPTION:Proposed slot #1 (11h0m0s)\r\n",
+		"DESCRIPTION:Proposed slot #2 (11h0m0s)\r\n",
+		"DTSTART:20241017T060000Z\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("missing %q in:\n%s", want, ics)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	cat "github.com/CorentinMre/isengo/webaurion/catalog"
+)
+
+// Error is a GraphQL error of a response.
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+// Response is the result of a query.
+type Response struct {
+	Data   *Object `json:"data,omitempty"`
+	Errors []Error `json:"errors,omitempty"`
+}
+
+// JSON returns the JSON representation of the response.
+func (r *Response) JSON() string {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("Error marshaling to JSON: %v", err)
+	}
+	return string(data)
+}
+
+// Object is a JSON object keeping the order of the selected fields.
+type Object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func newObject() *Object {
+	return &Object{values: make(map[string]interface{})}
+}
+
+func (o *Object) set(key string, value interface{}) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = value
+}
+
+// Get returns the value of a field of the object.
+func (o *Object) Get(key string) (interface{}, error) {
+	value, ok := o.values[key]
+	if !ok {
+		return nil, fmt.Errorf("invalid key: %s", key)
+	}
+	return value, nil
+}
+
+func (o *Object) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		v, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// call is a memoized fetch of the dataloader.
+type call struct {
+	once  sync.Once
+	value interface{}
+	err   error
+}
+
+// execution is the state of one request. Its loader memoizes the fetches so that a
+// resource selected by several fields (or aliases) is scraped once.
+type execution struct {
+	source Source
+	mu     sync.Mutex
+	calls  map[string]*call
+}
+
+// load returns the result of fetch for key, calling it at most once per request.
+func (ex *execution) load(key string, fetch func() (interface{}, error)) (interface{}, error) {
+	ex.mu.Lock()
+	c, ok := ex.calls[key]
+	if !ok {
+		c = &call{}
+		ex.calls[key] = c
+	}
+	ex.mu.Unlock()
+
+	c.once.Do(func() {
+		c.value, c.err = fetch()
+	})
+	return c.value, c.err
+}
+
+func (ex *execution) catalogs() ([]cat.Catalog, error) {
+	catalogs, err := ex.load("catalogs", func() (interface{}, error) { return ex.source.Catalogs() })
+	if err != nil {
+		return nil, err
+	}
+	return catalogs.([]cat.Catalog), nil
+}
+
+func (ex *execution) catalogEntries(idx int) (*cat.CatalogReport, error) {
+	catalogs, err := ex.catalogs()
+	if err != nil {
+		return nil, err
+	}
+	if idx < 0 || idx >= len(catalogs) {
+		return nil, fmt.Errorf("catalog %d not found", idx)
+	}
+
+	report, err := ex.load(fmt.Sprintf("catalogs/%d", idx), func() (interface{}, error) {
+		return ex.source.CatalogEntries(idx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report.(*cat.CatalogReport), nil
+}
+
+// Execute runs a query against source. The root fields are resolved in parallel;
+// a field that fails is null in the data and reported in the errors.
+func Execute(source Source, query, operationName string, variables map[string]interface{}) *Response {
+	operation, err := Parse(query, operationName)
+	if err != nil {
+		return &Response{Errors: []Error{{Message: err.Error()}}}
+	}
+	if operation.Type != "query" {
+		return &Response{Errors: []Error{{Message: fmt.Sprintf("%s operations are not supported", operation.Type)}}}
+	}
+
+	// validate everything before fetching anything
+	args := make([]arguments, len(operation.Selections))
+	for i, field := range operation.Selections {
+		if field.Name == "__typename" {
+			continue
+		}
+		def, ok := queryFields[field.Name]
+		if !ok {
+			return &Response{Errors: []Error{{Message: fmt.Sprintf("cannot query field %q on type Query", field.Name)}}}
+		}
+		if err := validate(field.Selections, def.typ, field.Key()); err != nil {
+			return &Response{Errors: []Error{{Message: err.Error()}}}
+		}
+		if args[i], err = coerceArguments(field, def, variables); err != nil {
+			return &Response{Errors: []Error{{Message: err.Error()}}}
+		}
+	}
+
+	ex := &execution{source: source, calls: make(map[string]*call)}
+	values := make([]interface{}, len(operation.Selections))
+	errs := make([]error, len(operation.Selections))
+
+	var wg sync.WaitGroup
+	for i, field := range operation.Selections {
+		if field.Name == "__typename" {
+			values[i] = "Query"
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			def := queryFields[field.Name]
+			value, err := def.resolve(ex, args[i])
+			if err == nil {
+				value, err = project(value, field.Selections, def.typ)
+			}
+			values[i], errs[i] = value, err
+		}()
+	}
+	wg.Wait()
+
+	response := &Response{Data: newObject()}
+	for i, field := range operation.Selections {
+		if errs[i] != nil {
+			response.Errors = append(response.Errors, Error{Message: errs[i].Error(), Path: []interface{}{field.Key()}})
+		}
+		response.Data.set(field.Key(), values[i])
+	}
+	return response
+}
+
+// project keeps the selected fields of value, read from its JSON representation.
+func project(value interface{}, fields []*Field, typ typeRef) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding %s: %v", typ.named(), err)
+	}
+	var raw interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("error decoding %s: %v", typ.named(), err)
+	}
+	return selectFields(raw, fields, typ), nil
+}
+
+func selectFields(raw interface{}, fields []*Field, typ typeRef) interface{} {
+	if raw == nil {
+		return nil
+	}
+	if typ.isList() {
+		items, ok := raw.([]interface{})
+		if !ok {
+			return nil
+		}
+		itemType := typeRef(typ.named())
+		values := make([]interface{}, len(items))
+		for i, item := range items {
+			values[i] = selectFields(item, fields, itemType)
+		}
+		return values
+	}
+	if typ.isScalar() {
+		return raw
+	}
+
+	object, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	result := newObject()
+	fieldTypes := objectTypes[typ.named()]
+	for _, field := range fields {
+		if field.Name == "__typename" {
+			result.set(field.Key(), typ.named())
+			continue
+		}
+		// omitted (omitempty) fields are null
+		result.set(field.Key(), selectFields(object[field.Name], field.Selections, fieldTypes[field.Name]))
+	}
+	return result
+}
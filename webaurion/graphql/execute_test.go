@@ -0,0 +1,331 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CorentinMre/isengo/webaurion"
+	cat "github.com/CorentinMre/isengo/webaurion/catalog"
+)
+
+// fakeSource serves fixed data and counts the fetches.
+type fakeSource struct {
+	mu    sync.Mutex
+	calls map[string]int
+	fail  map[string]bool
+}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{calls: map[string]int{}, fail: map[string]bool{}}
+}
+
+func (s *fakeSource) fetch(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls[name]++
+	if s.fail[name] {
+		return errors.New(name + " unavailable")
+	}
+	return nil
+}
+
+func (s *fakeSource) Location() *time.Location {
+	return webaurion.ParisLocation
+}
+
+func (s *fakeSource) UserInfo() (*webaurion.UserInfo, error) {
+	return webaurion.NewUserInfo("Jean", "Dupont", "Jean Dupont", "jean.dupont@isen-ouest.yncrea.fr"), s.fetch("me")
+}
+
+func (s *fakeSource) Grades() (*webaurion.GradeReport, error) {
+	return webaurion.NewGradeReport(13, []webaurion.Grade{
+		{Date: "06/01/2025", Code: "24_MATH_DS1", Name: "Mathématiques DS1", Grade: 12},
+		{Date: "13/01/2025", Code: "24_PHYS_DS1", Name: "Physique DS1", Grade: 14.5, Instructors: []string{"M. Curie"}},
+	}), s.fetch("grades")
+}
+
+func (s *fakeSource) Absences() (*webaurion.AbsenceReport, error) {
+	return webaurion.NewAbsenceReport(1, 2, []webaurion.Absence{{Date: "07/01/2025", Subject: "Physique"}}), s.fetch("absences")
+}
+
+func (s *fakeSource) Planning() (*webaurion.PlanningReport, error) {
+	start := time.Date(2025, 1, 6, 8, 0, 0, 0, webaurion.ParisLocation)
+	return webaurion.NewPlanningReport([]webaurion.Event{
+		{ID: "1", Start: start, End: start.Add(2 * time.Hour), ClassName: "est-epreuve", Details: webaurion.Details{Subject: "Maths", Type: "DS"}},
+	}), s.fetch("planning")
+}
+
+func (s *fakeSource) Catalogs() ([]cat.Catalog, error) {
+	return []cat.Catalog{{Name: "Stages M1", SubmenuID: "submenu_1", MenuID: "1_0"}}, s.fetch("catalogs")
+}
+
+func (s *fakeSource) CatalogEntries(idx int) (*cat.CatalogReport, error) {
+	return cat.NewCatalogReport([]cat.CatalogEntry{{Company: "Acme", City: "Brest", PostalCode: "29200", Year: "2025", RowIndex: 3}}), s.fetch(fmt.Sprintf("catalog %d", idx))
+}
+
+func (s *fakeSource) CatalogEntryDetails(idx, row int) (*cat.CatalogDetails, error) {
+	return &cat.CatalogDetails{Title: "Stage", Company: "Acme"}, s.fetch(fmt.Sprintf("details %d/%d", idx, row))
+}
+
+func toJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestExecute(t *testing.T) {
+	source := newFakeSource()
+	response := Execute(source, `query ($subject: String) {
+		__typename
+		all: grades { average }
+		physics: grades(subject: $subject) { data { name instructors } }
+		planning(from: "2025-01-06") { events { kind details { subject } } }
+		catalogEntries(catalog: 0) { totalEntries entries { row company } }
+	}`, "", map[string]interface{}{"subject": "physique"})
+
+	if len(response.Errors) > 0 {
+		t.Fatalf("unexpected errors: %+v", response.Errors)
+	}
+	want := `{"__typename":"Query","all":{"average":13.25},` +
+		`"physics":{"data":[{"name":"Physique DS1","instructors":["M. Curie"]}]},` +
+		`"planning":{"events":[{"kind":"exam","details":{"subject":"Maths"}}]},` +
+		`"catalogEntries":{"totalEntries":1,"entries":[{"row":3,"company":"Acme"}]}}`
+	if got := toJSON(t, response.Data); got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	// the grades are fetched once for both aliases
+	if source.calls["grades"] != 1 || source.calls["catalogs"] != 1 || source.calls["catalog 0"] != 1 {
+		t.Errorf("unexpected fetches: %v", source.calls)
+	}
+}
+
+func TestExecuteFieldError(t *testing.T) {
+	source := newFakeSource()
+	source.fail["absences"] = true
+	response := Execute(source, `{ me { name } absences { nbAbsences } }`, "", nil)
+
+	if got := toJSON(t, response.Data); got != `{"me":{"name":"Jean Dupont"},"absences":null}` {
+		t.Errorf("unexpected data: %s", got)
+	}
+	if len(response.Errors) != 1 || response.Errors[0].Message != "absences unavailable" || !reflect.DeepEqual(response.Errors[0].Path, []interface{}{"absences"}) {
+		t.Errorf("unexpected errors: %+v", response.Errors)
+	}
+}
+
+func TestExecuteValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		err       string
+	}{
+		{"unknown root field", `{ foo }`, nil, `cannot query field "foo" on type Query`},
+		{"unknown field", `{ grades { data { foo } } }`, nil, `cannot query field "foo" on type Grade`},
+		{"missing selection", `{ grades }`, nil, "must have a selection"},
+		{"selection on scalar", `{ grades { average { x } } }`, nil, "must not have a selection"},
+		{"unknown argument", `{ grades(foo: 1) { average } }`, nil, `unknown argument "foo"`},
+		{"argument on object field", `{ grades { data(first: 1) { code } } }`, nil, "unknown arguments"},
+		{"missing required argument", `{ catalogEntries { totalEntries } }`, nil, `missing argument "catalog"`},
+		{"wrong argument type", `{ catalogEntries(catalog: "0") { totalEntries } }`, nil, "must be an Int"},
+		{"wrong variable type", `query ($c: Int) { catalogEntries(catalog: $c) { totalEntries } }`, map[string]interface{}{"c": 1.5}, "must be an Int"},
+		{"mutation", `mutation { grades { average } }`, nil, "mutation operations are not supported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := newFakeSource()
+			response := Execute(source, tt.query, "", tt.variables)
+			if response.Data != nil || len(response.Errors) != 1 || !strings.Contains(response.Errors[0].Message, tt.err) {
+				t.Fatalf("got %s, want error %q", toJSON(t, response), tt.err)
+			}
+			if len(source.calls) > 0 {
+				t.Errorf("nothing should be fetched for an invalid query, got %v", source.calls)
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	handler := NewHandler(func(r *http.Request) (Source, error) {
+		if r.Header.Get("Authorization") == "" {
+			return nil, errors.New("missing bearer token")
+		}
+		return newFakeSource(), nil
+	})
+	handler.MaxBodyBytes = 256
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		auth   bool
+		status int
+	}{
+		{"post", "POST", "/", `{"query":"{ me { email } }"}`, true, http.StatusOK},
+		{"get", "GET", "/?query=" + strings.ReplaceAll("{ me { email } }", " ", "%20"), "", true, http.StatusOK},
+		{"unauthorized", "POST", "/", `{"query":"{ me { email } }"}`, false, http.StatusUnauthorized},
+		{"invalid query", "POST", "/", `{"query":"{ me }"}`, true, http.StatusBadRequest},
+		{"missing query", "POST", "/", `{}`, true, http.StatusBadRequest},
+		{"too large", "POST", "/", `{"query":"{ me { ` + strings.Repeat("email ", 100) + `} }"}`, true, http.StatusRequestEntityTooLarge},
+		{"method", "PUT", "/", ``, true, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.auth {
+				req.Header.Set("Authorization", "Bearer token")
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.status {
+				t.Errorf("got status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+}
+
+// fill sets every exported field of v to a non-zero value, so that no field is omitted
+// from its JSON. Floats get a fraction to be told apart from the ints.
+func fill(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString("x")
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		v.SetInt(1)
+	case reflect.Float64, reflect.Float32:
+		v.SetFloat(1.5)
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		fill(v.Index(0))
+	case reflect.Pointer:
+		v.Set(reflect.New(v.Type().Elem()))
+		fill(v.Elem())
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				fill(v.Field(i))
+			}
+		}
+	}
+}
+
+// checkType compares a JSON value to its type in the schema and records the checked types.
+func checkType(t *testing.T, path string, raw interface{}, typ typeRef, checked map[string]bool) {
+	t.Helper()
+	if typ.isList() {
+		items, ok := raw.([]interface{})
+		if !ok || len(items) == 0 {
+			t.Errorf("%s: %s expected, got %#v", path, typ, raw)
+			return
+		}
+		checkType(t, path+"[0]", items[0], typeRef(typ.named()), checked)
+		return
+	}
+
+	var kind string
+	switch value := raw.(type) {
+	case string:
+		kind = "String"
+	case bool:
+		kind = "Boolean"
+	case float64:
+		kind = "Float"
+		if value == float64(int64(value)) {
+			kind = "Int"
+		}
+	case map[string]interface{}:
+		name := typ.named()
+		fields, ok := objectTypes[name]
+		if !ok {
+			t.Errorf("%s: object type %s is not in the schema", path, name)
+			return
+		}
+		checked[name] = true
+		for key := range value {
+			if _, ok := fields[key]; !ok {
+				t.Errorf("%s: JSON field %q is missing from the schema type %s", path, key, name)
+			}
+		}
+		for key, fieldType := range fields {
+			fieldValue, ok := value[key]
+			if !ok {
+				t.Errorf("%s: schema field %s.%s is not in the JSON", path, name, key)
+				continue
+			}
+			checkType(t, path+"."+key, fieldValue, fieldType, checked)
+		}
+		return
+	default:
+		t.Errorf("%s: unexpected JSON value %#v", path, raw)
+		return
+	}
+	if kind != typ.named() {
+		t.Errorf("%s: JSON value is a %s, the schema says %s", path, kind, typ)
+	}
+}
+
+// TestObjectTypes checks objectTypes against the JSON of the Go types and of the resolvers,
+// so that a field added to a type without updating the schema is caught.
+func TestObjectTypes(t *testing.T) {
+	checked := map[string]bool{}
+	check := func(name string, value interface{}) {
+		data, err := json.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var raw interface{}
+		if err := json.Unmarshal(data, &raw); err != nil {
+			t.Fatal(err)
+		}
+		checkType(t, name, raw, typeRef(name), checked)
+	}
+
+	for name, value := range map[string]interface{}{
+		"UserInfo":       &webaurion.UserInfo{},
+		"GradeReport":    &webaurion.GradeReport{},
+		"AbsenceReport":  &webaurion.AbsenceReport{},
+		"PlanningReport": &webaurion.PlanningReport{},
+		"CatalogDetails": &cat.CatalogDetails{},
+	} {
+		fill(reflect.ValueOf(value).Elem())
+		check(name, value)
+	}
+
+	// the catalog types are built by the resolvers
+	ex := &execution{source: newFakeSource(), calls: map[string]*call{}}
+	catalogs, err := queryFields["catalogs"].resolve(ex, arguments{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("[Catalog]", catalogs)
+	entries, err := queryFields["catalogEntries"].resolve(ex, arguments{"catalog": 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("CatalogReport", entries)
+
+	for name := range objectTypes {
+		if !checked[name] {
+			t.Errorf("object type %s isn't checked against a Go type", name)
+		}
+	}
+	for name, field := range queryFields {
+		if !field.typ.isScalar() && objectTypes[field.typ.named()] == nil {
+			t.Errorf("root field %s: unknown type %s", name, field.typ)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Package graphql serves the WebAurion data through a small GraphQL endpoint, so that a
+// client can fetch grades, absences and the planning in one round-trip and pick the fields.
+//
+// Only a subset of GraphQL is implemented: queries with selection sets, aliases,
+// arguments and variables. Fragments, directives, mutations and introspection are not;
+// the schema is available with Schema(). Documents are limited in depth and size, see
+// MaxDepth and MaxFields.
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Handler serves GraphQL queries over HTTP: POST with a JSON body
+// {"query", "operationName", "variables"}, or GET with the same query parameters.
+type Handler struct {
+	// Source returns the Source of a request, an error answers 401 Unauthorized
+	Source func(r *http.Request) (Source, error)
+	// MaxBodyBytes is the size limit of the POST bodies, DefaultMaxBodyBytes when <= 0
+	MaxBodyBytes int64
+}
+
+// DefaultMaxBodyBytes is the default size limit of the POST bodies.
+const DefaultMaxBodyBytes = 64 << 10
+
+// NewHandler creates a new instance of Handler
+func NewHandler(source func(r *http.Request) (Source, error)) *Handler {
+	return &Handler{Source: source}
+}
+
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	switch r.Method {
+	case http.MethodPost:
+		limit := h.MaxBodyBytes
+		if limit <= 0 {
+			limit = DefaultMaxBodyBytes
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, limit)).Decode(&req); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeResponse(w, http.StatusRequestEntityTooLarge, &Response{Errors: []Error{{Message: fmt.Sprintf("request body larger than %d bytes", limit)}}})
+				return
+			}
+			writeResponse(w, http.StatusBadRequest, &Response{Errors: []Error{{Message: "expected a JSON body with a query"}}})
+			return
+		}
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if variables := r.URL.Query().Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				writeResponse(w, http.StatusBadRequest, &Response{Errors: []Error{{Message: "invalid variables"}}})
+				return
+			}
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeResponse(w, http.StatusMethodNotAllowed, &Response{Errors: []Error{{Message: "method not allowed"}}})
+		return
+	}
+	if req.Query == "" {
+		writeResponse(w, http.StatusBadRequest, &Response{Errors: []Error{{Message: "missing query"}}})
+		return
+	}
+
+	source, err := h.Source(r)
+	if err != nil {
+		writeResponse(w, http.StatusUnauthorized, &Response{Errors: []Error{{Message: err.Error()}}})
+		return
+	}
+
+	response := Execute(source, req.Query, req.OperationName, req.Variables)
+	status := http.StatusOK
+	if response.Data == nil {
+		status = http.StatusBadRequest // the query was rejected before execution
+	}
+	writeResponse(w, status, response)
+}
+
+func writeResponse(w http.ResponseWriter, status int, response *Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,508 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Field is a selected field of a query.
+type Field struct {
+	Alias      string
+	Name       string
+	Arguments  map[string]interface{} // literals, or variable references
+	Selections []*Field
+}
+
+// Key returns the name of the field in the response.
+func (f *Field) Key() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// variable is a $name reference in an argument.
+type variable string
+
+// enumValue is an unquoted enum literal in an argument.
+type enumValue string
+
+// Operation is a parsed query operation.
+type Operation struct {
+	Type       string // "query" or "mutation"
+	Name       string
+	Selections []*Field
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+// Limits of a query document, so that a client can't exhaust the server with a huge or
+// deeply nested query. The schema itself is 4 levels deep.
+const (
+	MaxDepth  = 15    // nesting of selection sets and of argument values
+	MaxFields = 500   // selected fields of the document
+	maxTokens = 20000 // tokens of the document
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+type lexer struct {
+	src    string
+	pos    int
+	tokens []token
+}
+
+func tokenize(src string) ([]token, error) {
+	l := &lexer{src: src}
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		l.tokens = append(l.tokens, tok)
+		if tok.kind == tokenEOF {
+			return l.tokens, nil
+		}
+		if len(l.tokens) > maxTokens {
+			return nil, fmt.Errorf("query too large: more than %d tokens", maxTokens)
+		}
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	// skip ignored tokens: whitespace, commas and comments
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			l.pos++
+		} else if c == '#' {
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+		} else if strings.HasPrefix(l.src[l.pos:], "\uFEFF") {
+			l.pos += len("\uFEFF")
+		} else {
+			break
+		}
+	}
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, pos: l.pos}, nil
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		l.pos += 3
+		return token{kind: tokenPunct, value: "...", pos: start}, nil
+	case strings.ContainsRune("!$()[]{}:=@|&", rune(c)):
+		l.pos++
+		return token{kind: tokenPunct, value: string(c), pos: start}, nil
+	case c == '_' || isLetter(c):
+		for l.pos < len(l.src) && (l.src[l.pos] == '_' || isLetter(l.src[l.pos]) || isDigit(l.src[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokenName, value: l.src[start:l.pos], pos: start}, nil
+	case c == '-' || isDigit(c):
+		return l.number()
+	case c == '"':
+		return l.string()
+	}
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	return token{}, fmt.Errorf("syntax error at %d: unexpected character %q", l.pos, r)
+}
+
+func (l *lexer) number() (token, error) {
+	start := l.pos
+	kind := tokenInt
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+	for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+		l.pos++
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		kind = tokenFloat
+		l.pos++
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		kind = tokenFloat
+		l.pos++
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+	}
+	value := l.src[start:l.pos]
+	if value == "-" {
+		return token{}, fmt.Errorf("syntax error at %d: invalid number", start)
+	}
+	return token{kind: kind, value: value, pos: start}, nil
+}
+
+func (l *lexer) string() (token, error) {
+	start := l.pos
+	if strings.HasPrefix(l.src[l.pos:], `"""`) {
+		end := strings.Index(l.src[l.pos+3:], `"""`)
+		if end < 0 {
+			return token{}, fmt.Errorf("syntax error at %d: unterminated block string", start)
+		}
+		value := l.src[l.pos+3 : l.pos+3+end]
+		l.pos += end + 6
+		return token{kind: tokenString, value: strings.TrimSpace(value), pos: start}, nil
+	}
+
+	l.pos++ // opening quote
+	for l.pos < len(l.src) {
+		switch l.src[l.pos] {
+		case '\\':
+			l.pos += 2
+		case '"':
+			l.pos++
+			// GraphQL string escapes are a subset of JSON's
+			value, err := strconv.Unquote(l.src[start:l.pos])
+			if err != nil {
+				return token{}, fmt.Errorf("syntax error at %d: invalid string", start)
+			}
+			return token{kind: tokenString, value: value, pos: start}, nil
+		case '\n':
+			return token{}, fmt.Errorf("syntax error at %d: unterminated string", start)
+		default:
+			l.pos++
+		}
+	}
+	return token{}, fmt.Errorf("syntax error at %d: unterminated string", start)
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// parser builds operations from tokens. Fragments and directives are not supported.
+type parser struct {
+	tokens []token
+	pos    int
+	depth  int // current nesting, see MaxDepth
+	fields int // fields parsed so far, see MaxFields
+}
+
+// Parse parses a query document and returns the operation named operationName
+// (or the only operation when operationName is empty).
+func Parse(query, operationName string) (*Operation, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	var operations []*Operation
+	for p.peek().kind != tokenEOF {
+		operation, err := p.operation()
+		if err != nil {
+			return nil, err
+		}
+		operations = append(operations, operation)
+	}
+
+	if len(operations) == 0 {
+		return nil, fmt.Errorf("no operation found")
+	}
+	if operationName == "" {
+		if len(operations) > 1 {
+			return nil, fmt.Errorf("operationName is required when the document has several operations")
+		}
+		return operations[0], nil
+	}
+	for _, operation := range operations {
+		if operation.Name == operationName {
+			return operation, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation: %s", operationName)
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) advance() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) isPunct(value string) bool {
+	tok := p.peek()
+	return tok.kind == tokenPunct && tok.value == value
+}
+
+func (p *parser) expectPunct(value string) error {
+	tok := p.advance()
+	if tok.kind != tokenPunct || tok.value != value {
+		return p.unexpected(tok, fmt.Sprintf("%q", value))
+	}
+	return nil
+}
+
+func (p *parser) expectName() (string, error) {
+	tok := p.advance()
+	if tok.kind != tokenName {
+		return "", p.unexpected(tok, "a name")
+	}
+	return tok.value, nil
+}
+
+func (p *parser) unexpected(tok token, expected string) error {
+	if tok.kind == tokenEOF {
+		return fmt.Errorf("syntax error: unexpected end of document, expected %s", expected)
+	}
+	return fmt.Errorf("syntax error at %d: unexpected %q, expected %s", tok.pos, tok.value, expected)
+}
+
+func (p *parser) operation() (*Operation, error) {
+	operation := &Operation{Type: "query"}
+
+	// shorthand query: { ... }
+	if p.isPunct("{") {
+		selections, err := p.selectionSet()
+		if err != nil {
+			return nil, err
+		}
+		operation.Selections = selections
+		return operation, nil
+	}
+
+	tok := p.advance()
+	if tok.kind != tokenName {
+		return nil, p.unexpected(tok, "an operation")
+	}
+	switch tok.value {
+	case "query", "mutation":
+		operation.Type = tok.value
+	case "fragment":
+		return nil, fmt.Errorf("fragments are not supported")
+	default:
+		return nil, p.unexpected(tok, "an operation")
+	}
+
+	if p.peek().kind == tokenName {
+		operation.Name = p.advance().value
+	}
+	if p.isPunct("(") {
+		if err := p.skipVariableDefinitions(); err != nil {
+			return nil, err
+		}
+	}
+
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	operation.Selections = selections
+	return operation, nil
+}
+
+// skipVariableDefinitions skips "($a: Int = 1, $b: [String!])", variables are typed by the resolvers.
+func (p *parser) skipVariableDefinitions() error {
+	depth := 0
+	for {
+		tok := p.advance()
+		switch {
+		case tok.kind == tokenEOF:
+			return p.unexpected(tok, `")"`)
+		case tok.kind == tokenPunct && tok.value == "(":
+			depth++
+		case tok.kind == tokenPunct && tok.value == ")":
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+}
+
+// enter checks the nesting of a selection set or an argument value, leave must be called after it.
+func (p *parser) enter() error {
+	p.depth++
+	if p.depth > MaxDepth {
+		return fmt.Errorf("query too deep: more than %d levels", MaxDepth)
+	}
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) selectionSet() ([]*Field, error) {
+	if err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+
+	var fields []*Field
+	for !p.isPunct("}") {
+		if p.isPunct("...") {
+			return nil, fmt.Errorf("fragments are not supported")
+		}
+		field, err := p.field()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	p.advance() // }
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("syntax error: empty selection set")
+	}
+	return fields, nil
+}
+
+func (p *parser) field() (*Field, error) {
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+
+	p.fields++
+	if p.fields > MaxFields {
+		return nil, fmt.Errorf("query too large: more than %d fields", MaxFields)
+	}
+
+	field := &Field{Name: name}
+	if p.isPunct(":") {
+		p.advance()
+		field.Alias = name
+		if field.Name, err = p.expectName(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.isPunct("(") {
+		p.advance()
+		field.Arguments = make(map[string]interface{})
+		for !p.isPunct(")") {
+			argName, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectPunct(":"); err != nil {
+				return nil, err
+			}
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			field.Arguments[argName] = value
+		}
+		p.advance() // )
+	}
+
+	if p.isPunct("@") {
+		return nil, fmt.Errorf("directives are not supported")
+	}
+
+	if p.isPunct("{") {
+		if field.Selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return field, nil
+}
+
+func (p *parser) value() (interface{}, error) {
+	tok := p.advance()
+	switch tok.kind {
+	case tokenInt:
+		return strconv.ParseInt(tok.value, 10, 64)
+	case tokenFloat:
+		return strconv.ParseFloat(tok.value, 64)
+	case tokenString:
+		return tok.value, nil
+	case tokenName:
+		switch tok.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return enumValue(tok.value), nil
+	case tokenPunct:
+		switch tok.value {
+		case "$":
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			return variable(name), nil
+		case "[":
+			if err := p.enter(); err != nil {
+				return nil, err
+			}
+			defer p.leave()
+			list := []interface{}{}
+			for !p.isPunct("]") {
+				item, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, item)
+			}
+			p.advance()
+			return list, nil
+		case "{":
+			if err := p.enter(); err != nil {
+				return nil, err
+			}
+			defer p.leave()
+			object := map[string]interface{}{}
+			for !p.isPunct("}") {
+				key, err := p.expectName()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expectPunct(":"); err != nil {
+					return nil, err
+				}
+				if object[key], err = p.value(); err != nil {
+					return nil, err
+				}
+			}
+			p.advance()
+			return object, nil
+		}
+	}
+	return nil, p.unexpected(tok, "a value")
+}
@@ -0,0 +1,126 @@
+package graphql
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	operation, err := Parse(`
+		# comment
+		query Dashboard($from: String = "2025-01-01") {
+			me { name }
+			maths: grades(from: $from, subject: "Mathsé") { average data { code grade } }
+			planning(from: "2025-01-06", to: """ 2025-01-12 """) { events { details { instructors } } }
+		}`, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if operation.Type != "query" || operation.Name != "Dashboard" || len(operation.Selections) != 3 {
+		t.Fatalf("unexpected operation: %+v", operation)
+	}
+
+	grades := operation.Selections[1]
+	if grades.Alias != "maths" || grades.Name != "grades" || grades.Key() != "maths" {
+		t.Errorf("unexpected alias: %+v", grades)
+	}
+	wantArgs := map[string]interface{}{"from": variable("from"), "subject": "Mathsé"}
+	if !reflect.DeepEqual(grades.Arguments, wantArgs) {
+		t.Errorf("got arguments %#v, want %#v", grades.Arguments, wantArgs)
+	}
+	if len(grades.Selections) != 2 || grades.Selections[1].Name != "data" || len(grades.Selections[1].Selections) != 2 {
+		t.Errorf("unexpected selections: %+v", grades.Selections)
+	}
+	if to := operation.Selections[2].Arguments["to"]; to != "2025-01-12" {
+		t.Errorf("block string: got %q", to)
+	}
+}
+
+func TestParseValues(t *testing.T) {
+	operation, err := Parse(`{ f(i: -12, f: 1.5e3, b: true, n: null, e: ASC, l: [1, "a", [false]], o: {k: $v}) { x } }`, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"i": int64(-12),
+		"f": 1500.0,
+		"b": true,
+		"n": nil,
+		"e": enumValue("ASC"),
+		"l": []interface{}{int64(1), "a", []interface{}{false}},
+		"o": map[string]interface{}{"k": variable("v")},
+	}
+	if got := operation.Selections[0].Arguments; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v, want %#v", got, want)
+	}
+}
+
+func TestParseOperationName(t *testing.T) {
+	document := `query A { me { name } } query B { grades { average } }`
+	if _, err := Parse(document, ""); err == nil {
+		t.Error("several operations without operationName should fail")
+	}
+	operation, err := Parse(document, "B")
+	if err != nil || operation.Selections[0].Name != "grades" {
+		t.Errorf("got %+v, %v", operation, err)
+	}
+	if _, err := Parse(document, "C"); err == nil {
+		t.Error("an unknown operationName should fail")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		err   string
+	}{
+		{"empty", ``, "no operation"},
+		{"empty selection", `{ }`, "empty selection set"},
+		{"unclosed", `{ me { name }`, "unexpected end of document"},
+		{"fragment spread", `{ me { ...F } }`, "fragments are not supported"},
+		{"fragment definition", `fragment F on UserInfo { name }`, "fragments are not supported"},
+		{"directive", `{ me @skip(if: true) { name } }`, "directives are not supported"},
+		{"unterminated string", `{ grades(subject: "maths) { average } }`, "unterminated string"},
+		{"bad character", `{ me { name% } }`, "unexpected character"},
+		{"bad number", `{ grades(from: -) { average } }`, "invalid number"},
+		{"missing value", `{ grades(from: ) { average } }`, "expected a value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.query, "")
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	nested := func(depth int) string {
+		return strings.Repeat("{ a ", depth) + strings.Repeat("}", depth)
+	}
+	if _, err := Parse(nested(MaxDepth), ""); err != nil {
+		t.Errorf("%d levels should be accepted: %v", MaxDepth, err)
+	}
+	if _, err := Parse(nested(MaxDepth+1), ""); err == nil || !strings.Contains(err.Error(), "too deep") {
+		t.Errorf("%d levels: got %v", MaxDepth+1, err)
+	}
+
+	// a huge document is refused while it is tokenized, before building anything
+	if _, err := Parse(nested(2_000_000), ""); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("huge document: got %v", err)
+	}
+
+	// argument values are nested too
+	values := "{ f(a: " + strings.Repeat("[", MaxDepth+1) + strings.Repeat("]", MaxDepth+1) + ") { x } }"
+	if _, err := Parse(values, ""); err == nil || !strings.Contains(err.Error(), "too deep") {
+		t.Errorf("nested values: got %v", err)
+	}
+
+	fields := "{ " + strings.Repeat("a ", MaxFields+1) + "}"
+	if _, err := Parse(fields, ""); err == nil || !strings.Contains(err.Error(), "more than") {
+		t.Errorf("%d fields: got %v", MaxFields+1, err)
+	}
+}
@@ -0,0 +1,386 @@
+package graphql
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/CorentinMre/isengo/webaurion"
+)
+
+// typeRef is the type of a field, e.g. "[Grade]" or "Int!".
+type typeRef string
+
+// named returns the type without list and non-null markers.
+func (t typeRef) named() string {
+	return strings.Trim(string(t), "[]!")
+}
+
+func (t typeRef) isList() bool {
+	return strings.HasPrefix(string(t), "[")
+}
+
+func (t typeRef) isScalar() bool {
+	switch t.named() {
+	case "String", "Int", "Float", "Boolean":
+		return true
+	}
+	return false
+}
+
+// object types, fields are read from the JSON representation of the values (TestObjectTypes
+// checks them against the JSON of the Go types)
+var objectTypes = map[string]map[string]typeRef{
+	"UserInfo": {
+		"firstName":     "String",
+		"lastName":      "String",
+		"name":          "String",
+		"email":         "String",
+		"studentNumber": "String",
+		"promotion":     "String",
+		"group":         "String",
+		"photoUrl":      "String",
+		"source":        "String",
+	},
+	"Grade": {
+		"date":         "String",
+		"code":         "String",
+		"name":         "String",
+		"grade":        "Float",
+		"absence":      "Boolean",
+		"appreciation": "String",
+		"instructors":  "[String]",
+	},
+	"GradeReport": {
+		"average": "Float",
+		"data":    "[Grade]",
+	},
+	"Absence": {
+		"date":       "String",
+		"reason":     "String",
+		"duration":   "String",
+		"schedule":   "String",
+		"course":     "String",
+		"instructor": "String",
+		"subject":    "String",
+	},
+	"AbsenceReport": {
+		"nbAbsences": "Int",
+		"duration":   "Int",
+		"data":       "[Absence]",
+		"warnings":   "[String]",
+	},
+	"Details": {
+		"time":        "String",
+		"room":        "String",
+		"type":        "String",
+		"subject":     "String",
+		"description": "String",
+		"instructors": "[String]",
+		"classGroups": "[String]",
+		"link":        "String",
+	},
+	"Event": {
+		"id":        "String",
+		"start":     "String",
+		"end":       "String",
+		"allDay":    "Boolean",
+		"className": "String",
+		"kind":      "String",
+		"details":   "Details",
+	},
+	"PlanningReport": {
+		"events":   "[Event]",
+		"warnings": "[String]",
+	},
+	"Catalog": {
+		"index":     "Int",
+		"name":      "String",
+		"submenuId": "String",
+		"menuId":    "String",
+	},
+	"CatalogEntry": {
+		"row":        "Int",
+		"company":    "String",
+		"city":       "String",
+		"postalCode": "String",
+		"year":       "String",
+	},
+	"CatalogReport": {
+		"totalEntries": "Int",
+		"entries":      "[CatalogEntry]",
+	},
+	"CatalogDetails": {
+		"title":       "String",
+		"startDate":   "String",
+		"endDate":     "String",
+		"description": "String",
+		"company":     "String",
+		"city":        "String",
+		"postalCode":  "String",
+		"year":        "String",
+		"studentName": "String",
+	},
+}
+
+// rootField is a field of the Query type.
+type rootField struct {
+	typ     typeRef
+	args    [][2]string // name, type
+	resolve func(ex *execution, args arguments) (interface{}, error)
+}
+
+var queryFields = map[string]rootField{
+	"me": {
+		typ: "UserInfo",
+		resolve: func(ex *execution, args arguments) (interface{}, error) {
+			return ex.load("me", func() (interface{}, error) { return ex.source.UserInfo() })
+		},
+	},
+	"grades": {
+		typ:  "GradeReport",
+		args: [][2]string{{"from", "String"}, {"to", "String"}, {"subject", "String"}},
+		resolve: func(ex *execution, args arguments) (interface{}, error) {
+			from, to, err := args.period(ex.source.Location())
+			if err != nil {
+				return nil, err
+			}
+			report, err := ex.load("grades", func() (interface{}, error) { return ex.source.Grades() })
+			if err != nil {
+				return nil, err
+			}
+			grades := report.(*webaurion.GradeReport).Between(from, to)
+			if subject := args.string("subject"); subject != "" {
+				grades = grades.BySubject(subject)
+			}
+			return grades, nil
+		},
+	},
+	"absences": {
+		typ:  "AbsenceReport",
+		args: [][2]string{{"from", "String"}, {"to", "String"}, {"subject", "String"}},
+		resolve: func(ex *execution, args arguments) (interface{}, error) {
+			from, to, err := args.period(ex.source.Location())
+			if err != nil {
+				return nil, err
+			}
+			report, err := ex.load("absences", func() (interface{}, error) { return ex.source.Absences() })
+			if err != nil {
+				return nil, err
+			}
+			absences := report.(*webaurion.AbsenceReport).Between(from, to)
+			if subject := args.string("subject"); subject != "" {
+				absences = absences.BySubject(subject)
+			}
+			return absences, nil
+		},
+	},
+	"planning": {
+		typ:  "PlanningReport",
+		args: [][2]string{{"from", "String"}, {"to", "String"}},
+		resolve: func(ex *execution, args arguments) (interface{}, error) {
+			from, to, err := args.period(ex.source.Location())
+			if err != nil {
+				return nil, err
+			}
+			report, err := ex.load("planning", func() (interface{}, error) { return ex.source.Planning() })
+			if err != nil {
+				return nil, err
+			}
+			return report.(*webaurion.PlanningReport).Between(from, to), nil
+		},
+	},
+	"catalogs": {
+		typ: "[Catalog]",
+		resolve: func(ex *execution, args arguments) (interface{}, error) {
+			catalogs, err := ex.catalogs()
+			if err != nil {
+				return nil, err
+			}
+			values := make([]map[string]interface{}, len(catalogs))
+			for i, c := range catalogs {
+				values[i] = map[string]interface{}{"index": i, "name": c.Name, "submenuId": c.SubmenuID, "menuId": c.MenuID}
+			}
+			return values, nil
+		},
+	},
+	"catalogEntries": {
+		typ:  "CatalogReport",
+		args: [][2]string{{"catalog", "Int!"}},
+		resolve: func(ex *execution, args arguments) (interface{}, error) {
+			report, err := ex.catalogEntries(args.int("catalog"))
+			if err != nil {
+				return nil, err
+			}
+			// RowIndex is hidden from the JSON of the entries
+			entries := make([]map[string]interface{}, len(report.Entries))
+			for i, e := range report.Entries {
+				entries[i] = map[string]interface{}{"row": e.RowIndex, "company": e.Company, "city": e.City, "postalCode": e.PostalCode, "year": e.Year}
+			}
+			return map[string]interface{}{"totalEntries": report.TotalEntries, "entries": entries}, nil
+		},
+	},
+	"catalogEntry": {
+		typ:  "CatalogDetails",
+		args: [][2]string{{"catalog", "Int!"}, {"row", "Int!"}},
+		resolve: func(ex *execution, args arguments) (interface{}, error) {
+			idx, row := args.int("catalog"), args.int("row")
+			if _, err := ex.catalogEntries(idx); err != nil {
+				return nil, err
+			}
+			return ex.load(fmt.Sprintf("catalogs/%d/entries/%d", idx, row), func() (interface{}, error) {
+				return ex.source.CatalogEntryDetails(idx, row)
+			})
+		},
+	},
+}
+
+// Schema returns the schema served by the handler in the GraphQL schema definition language.
+func Schema() string {
+	var sb strings.Builder
+	sb.WriteString("type Query {\n")
+	for _, name := range sortedKeys(queryFields) {
+		field := queryFields[name]
+		sb.WriteString("  " + name)
+		if len(field.args) > 0 {
+			args := make([]string, len(field.args))
+			for i, arg := range field.args {
+				args[i] = arg[0] + ": " + arg[1]
+			}
+			sb.WriteString("(" + strings.Join(args, ", ") + ")")
+		}
+		sb.WriteString(": " + string(field.typ) + "\n")
+	}
+	sb.WriteString("}\n")
+
+	for _, name := range sortedKeys(objectTypes) {
+		sb.WriteString("\ntype " + name + " {\n")
+		fields := objectTypes[name]
+		for _, field := range sortedKeys(fields) {
+			sb.WriteString("  " + field + ": " + string(fields[field]) + "\n")
+		}
+		sb.WriteString("}\n")
+	}
+	return sb.String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// arguments are the coerced arguments of a root field.
+type arguments map[string]interface{}
+
+func (a arguments) string(name string) string {
+	s, _ := a[name].(string)
+	return s
+}
+
+func (a arguments) int(name string) int {
+	n, _ := a[name].(int)
+	return n
+}
+
+// period parses the from/to arguments, RFC 3339 timestamps or YYYY-MM-DD dates of location.
+func (a arguments) period(location *time.Location) (from, to time.Time, err error) {
+	if from, err = parseTime(a.string("from"), location); err != nil {
+		return from, to, fmt.Errorf("invalid from: %v", err)
+	}
+	if to, err = parseTime(a.string("to"), location); err != nil {
+		return from, to, fmt.Errorf("invalid to: %v", err)
+	}
+	return from, to, nil
+}
+
+func parseTime(value string, location *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, location)
+}
+
+// coerceArguments resolves the variables and checks the argument types of a root field.
+func coerceArguments(field *Field, def rootField, variables map[string]interface{}) (arguments, error) {
+	args := make(arguments)
+	for name := range field.Arguments {
+		if !slices.ContainsFunc(def.args, func(arg [2]string) bool { return arg[0] == name }) {
+			return nil, fmt.Errorf("unknown argument %q on field %q", name, field.Name)
+		}
+	}
+
+	for _, arg := range def.args {
+		name, typ := arg[0], typeRef(arg[1])
+		value, ok := field.Arguments[name]
+		if v, isVariable := value.(variable); isVariable {
+			value, ok = variables[string(v)]
+		}
+		if !ok || value == nil {
+			if strings.HasSuffix(string(typ), "!") {
+				return nil, fmt.Errorf("missing argument %q on field %q", name, field.Name)
+			}
+			continue
+		}
+
+		switch typ.named() {
+		case "Int":
+			switch n := value.(type) {
+			case int64:
+				args[name] = int(n)
+			case float64: // JSON variables
+				if n != float64(int(n)) {
+					return nil, fmt.Errorf("argument %q of field %q must be an Int", name, field.Name)
+				}
+				args[name] = int(n)
+			default:
+				return nil, fmt.Errorf("argument %q of field %q must be an Int", name, field.Name)
+			}
+		case "String":
+			s, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("argument %q of field %q must be a String", name, field.Name)
+			}
+			args[name] = s
+		}
+	}
+	return args, nil
+}
+
+// validate checks the selection of a value of type typ.
+func validate(fields []*Field, typ typeRef, path string) error {
+	if typ.isScalar() {
+		if len(fields) > 0 {
+			return fmt.Errorf("field %q of type %s must not have a selection", path, typ)
+		}
+		return nil
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("field %q of type %s must have a selection", path, typ)
+	}
+
+	objectType := objectTypes[typ.named()]
+	for _, field := range fields {
+		if field.Name == "__typename" {
+			continue
+		}
+		fieldType, ok := objectType[field.Name]
+		if !ok {
+			return fmt.Errorf("cannot query field %q on type %s", field.Name, typ.named())
+		}
+		if len(field.Arguments) > 0 {
+			return fmt.Errorf("unknown arguments on field %q", field.Name)
+		}
+		if err := validate(field.Selections, fieldType, path+"."+field.Key()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
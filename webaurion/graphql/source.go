@@ -0,0 +1,106 @@
+package graphql
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/CorentinMre/isengo/webaurion"
+	cat "github.com/CorentinMre/isengo/webaurion/catalog"
+)
+
+// Source fetches the data of the schema. It must be safe for concurrent use.
+type Source interface {
+	UserInfo() (*webaurion.UserInfo, error)
+	Grades() (*webaurion.GradeReport, error)
+	Absences() (*webaurion.AbsenceReport, error)
+	Planning() (*webaurion.PlanningReport, error)
+	Catalogs() ([]cat.Catalog, error)
+	CatalogEntries(idx int) (*cat.CatalogReport, error)
+	CatalogEntryDetails(idx, row int) (*cat.CatalogDetails, error)
+	// Location is the timezone of the dates of the arguments.
+	Location() *time.Location
+}
+
+// ClientSource is a Source over a logged in WebAurion client.
+// WebAurion keeps a JSF ViewState per client, so its fetches run one at a time.
+type ClientSource struct {
+	mu     sync.Mutex
+	client *webaurion.WebAurion
+}
+
+// NewClientSource creates a new instance of ClientSource
+func NewClientSource(w *webaurion.WebAurion) *ClientSource {
+	return &ClientSource{client: w}
+}
+
+func (s *ClientSource) Location() *time.Location {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.client.GetCampus().Location()
+}
+
+func (s *ClientSource) UserInfo() (*webaurion.UserInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.client.UserInfo()
+}
+
+func (s *ClientSource) Grades() (*webaurion.GradeReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.client.GetGrades()
+}
+
+func (s *ClientSource) Absences() (*webaurion.AbsenceReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.client.GetAbsences()
+}
+
+func (s *ClientSource) Planning() (*webaurion.PlanningReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.client.GetPlanning()
+}
+
+func (s *ClientSource) Catalogs() ([]cat.Catalog, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.client.LoadCatalogs(); err != nil {
+		return nil, err
+	}
+	return s.client.ListCatalogs(), nil
+}
+
+func (s *ClientSource) CatalogEntries(idx int) (*cat.CatalogReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.client.Catalogs) == 0 {
+		if err := s.client.LoadCatalogs(); err != nil {
+			return nil, err
+		}
+	}
+	return s.client.GetCatalogEntries(idx)
+}
+
+func (s *ClientSource) CatalogEntryDetails(idx, row int) (*cat.CatalogDetails, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.client.Catalogs) == 0 {
+		if err := s.client.LoadCatalogs(); err != nil {
+			return nil, err
+		}
+	}
+	// the "Consulter" button acts on the last catalog page served to the client
+	report, err := s.client.GetCatalogEntries(idx)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range report.Entries {
+		if entry.RowIndex == row {
+			return s.client.GetCatalogEntryDetails(entry)
+		}
+	}
+	return nil, fmt.Errorf("row %d not found", row)
+}
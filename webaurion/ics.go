@@ -0,0 +1,169 @@
+package webaurion
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ICSEvent is an event of an iCalendar (RFC 5545) file.
+type ICSEvent struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	Categories  []string
+}
+
+// ICSCalendar is an iCalendar file, e.g. to import a planning or meeting proposals
+// in Google Calendar or Thunderbird.
+type ICSCalendar struct {
+	Name   string
+	Events []ICSEvent
+	Stamp  time.Time // DTSTAMP of the events, zero for now
+	// Location is the timezone of the days of the all day events, Europe/Paris when nil.
+	Location *time.Location
+}
+
+// WriteTo writes the calendar to w.
+func (c *ICSCalendar) WriteTo(w io.Writer) (int64, error) {
+	stamp := c.Stamp
+	if stamp.IsZero() {
+		stamp = time.Now()
+	}
+
+	var buf bytes.Buffer
+	line := func(name, value string) {
+		writeICSLine(&buf, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//isengo//webaurion//FR")
+	line("CALSCALE", "GREGORIAN")
+	if c.Name != "" {
+		line("X-WR-CALNAME", escapeICSText(c.Name))
+	}
+	for _, event := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", event.UID)
+		line("DTSTAMP", formatICSTime(stamp))
+		if event.AllDay {
+			line("DTSTART;VALUE=DATE", event.Start.In(orParis(c.Location)).Format("20060102"))
+			line("DTEND;VALUE=DATE", event.End.In(orParis(c.Location)).Format("20060102"))
+		} else {
+			line("DTSTART", formatICSTime(event.Start))
+			line("DTEND", formatICSTime(event.End))
+		}
+		line("SUMMARY", escapeICSText(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION", escapeICSText(event.Description))
+		}
+		if event.Location != "" {
+			line("LOCATION", escapeICSText(event.Location))
+		}
+		if event.URL != "" {
+			line("URL", event.URL)
+		}
+		if len(event.Categories) > 0 {
+			categories := make([]string, len(event.Categories))
+			for i, category := range event.Categories {
+				categories[i] = escapeICSText(category)
+			}
+			line("CATEGORIES", strings.Join(categories, ","))
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// String returns the iCalendar representation of the calendar.
+func (c *ICSCalendar) String() string {
+	var sb strings.Builder
+	c.WriteTo(&sb)
+	return sb.String()
+}
+
+func formatICSTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+var icsTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeICSText(text string) string {
+	return icsTextEscaper.Replace(text)
+}
+
+// writeICSLine writes a content line folded at 75 octets, without splitting a UTF-8 character.
+func writeICSLine(buf *bytes.Buffer, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		buf.WriteString(line[:cut])
+		buf.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74 // the leading space of the continuation line counts
+	}
+	buf.WriteString(line)
+	buf.WriteString("\r\n")
+}
+
+// ICS returns an iCalendar of the planning, e.g. merged with Moodle deadlines by WithDeadlines.
+func (pr *PlanningReport) ICS(name string) *ICSCalendar {
+	calendar := &ICSCalendar{Name: name, Location: pr.Location}
+	for _, event := range pr.Events {
+		kind := event.Kind()
+		summary := strings.Join(nonEmpty(event.Details.Type, event.Details.Subject), " - ")
+		description := event.Details.Description
+		if kind == EventKindDeadline {
+			summary, description = event.Details.Description, event.Details.Subject
+		}
+		if summary == "" {
+			summary = event.ClassName
+		}
+		if len(event.Details.Instructors) > 0 {
+			description = strings.Join(nonEmpty(description, strings.Join(event.Details.Instructors, ", ")), "\n")
+		}
+		calendar.Events = append(calendar.Events, ICSEvent{
+			UID:         icsUID("event", event.ID+"-"+event.Start.UTC().Format("20060102T150405Z")),
+			Start:       event.Start,
+			End:         event.End,
+			AllDay:      event.AllDay,
+			Summary:     summary,
+			Description: description,
+			Location:    event.Details.Room,
+			URL:         event.Details.Link,
+			Categories:  []string{kind.Style().Category},
+		})
+	}
+	return calendar
+}
+
+// nonEmpty returns the values that aren't empty.
+func nonEmpty(values ...string) []string {
+	var kept []string
+	for _, value := range values {
+		if value != "" {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
+
+// icsUID returns a stable UID for an event of the calendar.
+func icsUID(kind, id string) string {
+	return fmt.Sprintf("%s-%s@isengo", kind, id)
+}
@@ -0,0 +1,44 @@
+package webaurion
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportsUseTheirLocation(t *testing.T) {
+	reunion, err := time.LoadLocation("Indian/Reunion") // UTC+4
+	if err != nil {
+		t.Skip("no tz database:", err)
+	}
+
+	// 02:00 on the 7th in Paris is still the 6th in UTC, but already the 7th at La Réunion
+	from := time.Date(2025, 1, 6, 23, 0, 0, 0, time.UTC)
+	grades := NewGradeReport(0, []Grade{{Date: "07/01/2025", Code: "A"}})
+	if n := len(grades.Between(from, time.Time{}).Grades); n != 1 {
+		t.Errorf("Europe/Paris: got %d grades, want 1", n)
+	}
+	grades.Location = reunion
+	filtered := grades.Between(from, time.Time{})
+	if n := len(filtered.Grades); n != 0 {
+		t.Errorf("Indian/Reunion: got %d grades, want 0", n)
+	}
+	if filtered.Location != reunion {
+		t.Error("the filtered report lost its location")
+	}
+
+	start := time.Date(2025, 1, 7, 1, 0, 0, 0, reunion)
+	planning := NewPlanningReport([]Event{{ID: "1", Start: start, End: start.Add(time.Hour)}})
+	planning.Location = reunion
+	if n := len(planning.EventsOn(time.Date(2025, 1, 7, 12, 0, 0, 0, reunion))); n != 1 {
+		t.Errorf("got %d events on the 7th, want 1", n)
+	}
+	if n := len(planning.Only(EventKindOther).EventsOn(time.Date(2025, 1, 6, 12, 0, 0, 0, reunion))); n != 0 {
+		t.Errorf("got %d events on the 6th, want 0", n)
+	}
+
+	allDay := &ICSCalendar{Location: reunion, Stamp: start, Events: []ICSEvent{{UID: "1", Start: start, End: start.AddDate(0, 0, 1), AllDay: true}}}
+	if ics := allDay.String(); !strings.Contains(ics, "DTSTART;VALUE=DATE:20250107") {
+		t.Errorf("the all day event should start on the 7th:\n%s", ics)
+	}
+}
@@ -0,0 +1,196 @@
+// Package menu parses the sidebar of WebAurion: a tree of submenus (loaded on demand by
+// WebAurion) and of pages opened by posting the parameters of their onclick.
+package menu
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Item is a submenu or a page of the sidebar.
+type Item struct {
+	Label     string            `json:"label"`
+	SubmenuID string            `json:"submenuId,omitempty"` // "submenu_123", only for submenus
+	MenuID    string            `json:"menuId,omitempty"`    // form:sidebar_menuid of the page
+	Params    map[string]string `json:"params,omitempty"`    // parameters of the onclick
+	Href      string            `json:"href,omitempty"`      // link of the pages outside of JSF
+	Children  []*Item           `json:"children,omitempty"`  // empty until the submenu is loaded
+}
+
+// IsSubmenu reports whether the item is a submenu.
+func (i *Item) IsSubmenu() bool {
+	return i.SubmenuID != ""
+}
+
+// Child returns the child of the item labeled label, see Menu.Find.
+func (i *Item) Child(label string) *Item {
+	return find(i.Children, label)
+}
+
+// Walk calls fn for the item and its descendants, with their path from the item.
+func (i *Item) Walk(fn func(path []string, item *Item)) {
+	walk([]*Item{i}, nil, fn)
+}
+
+// String returns a string representation of the Item.
+func (i *Item) String() string {
+	return fmt.Sprintf("Item(label='%s', submenuId='%s', menuId='%s', children=%d)", i.Label, i.SubmenuID, i.MenuID, len(i.Children))
+}
+
+// Menu is the sidebar tree.
+type Menu struct {
+	Items []*Item `json:"items"`
+}
+
+// Parse parses the sidebar of a page, or of the partial response of a submenu.
+func Parse(doc *goquery.Document) *Menu {
+	root := doc.Selection
+	if sidebar := doc.Find("[id='form:sidebar']"); sidebar.Length() > 0 {
+		root = sidebar
+	}
+
+	m := &Menu{Items: []*Item{}}
+	root.Find("li.ui-menu-parent, li.ui-menuitem").Each(func(_ int, li *goquery.Selection) {
+		// top level items only, parseItem reads the nested ones
+		if li.ParentsFiltered("li.ui-menu-parent").Length() == 0 {
+			m.Items = append(m.Items, parseItem(li))
+		}
+	})
+	return m
+}
+
+// ParseLinks parses the command links of the main page (the shortcuts and the account menu),
+// outside of the sidebar. They are opened by posting their ID, in Params["id"].
+func ParseLinks(doc *goquery.Document) *Menu {
+	m := &Menu{Items: []*Item{}}
+	doc.Find("a.lien-cliquable, div.menuMonCompte a").Each(func(_ int, a *goquery.Selection) {
+		if id := a.AttrOr("id", ""); id != "" {
+			m.Items = append(m.Items, &Item{Label: text(a), Params: map[string]string{"id": id}})
+		}
+	})
+	return m
+}
+
+// parseItem parses a li of the sidebar and its children.
+func parseItem(li *goquery.Selection) *Item {
+	if !li.HasClass("ui-menu-parent") {
+		link := li.Find("a").First()
+		item := &Item{Label: text(link)}
+		if onclick, ok := link.Attr("onclick"); ok {
+			item.Params = ParseOnclick(onclick)
+			item.MenuID = item.Params["form:sidebar_menuid"]
+		}
+		if href, ok := link.Attr("href"); ok && href != "#" && !strings.HasPrefix(href, "javascript:") {
+			item.Href = href
+		}
+		return item
+	}
+
+	item := &Item{Label: text(li.Find("span.ui-menuitem-text").First())}
+	for _, class := range strings.Fields(li.AttrOr("class", "")) {
+		if strings.HasPrefix(class, "submenu_") {
+			item.SubmenuID = class
+			break
+		}
+	}
+	li.ChildrenFiltered("ul").ChildrenFiltered("li.ui-menu-parent, li.ui-menuitem").Each(func(_ int, child *goquery.Selection) {
+		item.Children = append(item.Children, parseItem(child))
+	})
+	return item
+}
+
+func text(s *goquery.Selection) string {
+	if span := s.Find("span.ui-menuitem-text"); span.Length() > 0 {
+		s = span.First()
+	}
+	return strings.Join(strings.Fields(s.Text()), " ")
+}
+
+// Find returns the item at path, e.g. Find("Divers", "Catalogue des stages"). Labels are
+// compared without case, an exact label wins over a label containing the text. An empty
+// label matches nothing.
+func (m *Menu) Find(path ...string) (*Item, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("empty menu path")
+	}
+	items := m.Items
+	var item *Item
+	for depth, label := range path {
+		if item = find(items, label); item == nil {
+			return nil, fmt.Errorf("menu %q not found", strings.Join(path[:depth+1], " > "))
+		}
+		items = item.Children
+	}
+	return item, nil
+}
+
+// Submenu returns the submenu with the ID submenuID (e.g. "submenu_123").
+func (m *Menu) Submenu(submenuID string) *Item {
+	var found *Item
+	m.Walk(func(_ []string, item *Item) {
+		if found == nil && item.SubmenuID == submenuID {
+			found = item
+		}
+	})
+	return found
+}
+
+// Walk calls fn for every item of the tree, with its path from the top.
+func (m *Menu) Walk(fn func(path []string, item *Item)) {
+	walk(m.Items, nil, fn)
+}
+
+func walk(items []*Item, parent []string, fn func([]string, *Item)) {
+	for _, item := range items {
+		path := append(append([]string{}, parent...), item.Label)
+		fn(path, item)
+		walk(item.Children, path, fn)
+	}
+}
+
+func find(items []*Item, label string) *Item {
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return nil
+	}
+	for _, item := range items {
+		if strings.EqualFold(item.Label, label) {
+			return item
+		}
+	}
+	for _, item := range items {
+		if strings.Contains(strings.ToLower(item.Label), strings.ToLower(label)) {
+			return item
+		}
+	}
+	return nil
+}
+
+// String returns a string representation of the Menu.
+func (m *Menu) String() string {
+	count := 0
+	m.Walk(func([]string, *Item) { count++ })
+	return fmt.Sprintf("Menu(items=%d, total=%d)", len(m.Items), count)
+}
+
+// Get returns the value of a specific key for the Menu.
+func (m *Menu) Get(key string) (interface{}, error) {
+	switch key {
+	case "items":
+		return m.Items, nil
+	default:
+		return nil, fmt.Errorf("invalid key: %s, valid keys are 'items'", key)
+	}
+}
+
+// JSON returns the JSON representation of the Menu.
+func (m *Menu) JSON() string {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("Error marshaling to JSON: %v", err)
+	}
+	return string(data)
+}
@@ -0,0 +1,150 @@
+package menu
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestParseOnclick(t *testing.T) {
+	tests := []struct {
+		name    string
+		onclick string
+		want    map[string]string
+	}{
+		{
+			"addSubmitParam",
+			`PrimeFaces.addSubmitParam('form',{'form:sidebar':'form:sidebar','form:sidebar_menuid':'1_4'}).submit('form');return false;`,
+			map[string]string{"form:sidebar": "form:sidebar", "form:sidebar_menuid": "1_4"},
+		},
+		{
+			"ab",
+			`PrimeFaces.ab({s:"form:j_idt52",f:"form",u:"form:panel",pa:[{name:"id",value:"1"}]});return false;`,
+			map[string]string{"s": "form:j_idt52", "f": "form", "u": "form:panel", "pa": `[{name:"id",value:"1"}]`},
+		},
+		{
+			"escaped quote and nested object",
+			`PrimeFaces.ab({s:'form:l\'id',p:{a:'}',b:[1,2]}})`,
+			map[string]string{"s": "form:l'id", "p": `{a:'}',b:[1,2]}`},
+		},
+		{"no object", `return false;`, map[string]string{}},
+		{"unterminated string", `PrimeFaces.ab({s:"form:j_idt52`, map[string]string{}},
+		{"missing colon", `PrimeFaces.ab({s "x", f:"form"})`, map[string]string{}},
+	}
+	for _, tt := range tests {
+		if got := ParseOnclick(tt.onclick); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+const sidebar = `<html><body><form id="form">
+<div class="header"><ul><li class="ui-menuitem"><a href="#">Outside of the sidebar</a></li></ul></div>
+<div id="form:sidebar"><ul class="ui-menu-list">
+	<li class="ui-menuitem"><a href="#" onclick="PrimeFaces.addSubmitParam('form',{'form:sidebar':'form:sidebar','form:sidebar_menuid':'0'}).submit('form');return false;"><span class="ui-menuitem-text">Accueil</span></a></li>
+	<li class="ui-widget ui-menu-parent submenu_1"><a href="#"><span class="ui-menuitem-text">Scolarité</span></a>
+		<ul class="ui-menu-list">
+			<li class="ui-menuitem"><a href="#" onclick="PrimeFaces.addSubmitParam('form',{'form:sidebar':'form:sidebar','form:sidebar_menuid':'1_0'}).submit('form');return false;"><span class="ui-menuitem-text">Mes notes</span></a></li>
+			<li class="ui-menuitem"><a href="#" onclick="PrimeFaces.addSubmitParam('form',{'form:sidebar':'form:sidebar','form:sidebar_menuid':'1_1'}).submit('form');return false;"><span class="ui-menuitem-text">Mes notes  de   stage</span></a></li>
+			<li class="ui-menu-parent submenu_12"><a href="#"><span class="ui-menuitem-text">Archives</span></a><ul class="ui-menu-list"></ul></li>
+		</ul>
+	</li>
+	<li class="ui-menu-parent submenu_6"><a href="#"><span class="ui-menuitem-text">Divers</span></a></li>
+	<li class="ui-menuitem"><a href="https://moodle.example.fr/"><span class="ui-menuitem-text">Moodle</span></a></li>
+</ul></div>
+<a id="form:j_idt60" class="lien-cliquable" href="#">Mon planning</a>
+<a class="lien-cliquable" href="#">No ID</a>
+<div class="menuMonCompte"><a id="form:j_idt70" href="#"><span>Mes</span> <span>données</span></a></div>
+</form></body></html>`
+
+func parseSidebar(t *testing.T) *Menu {
+	t.Helper()
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(sidebar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Parse(doc)
+}
+
+func TestParse(t *testing.T) {
+	m := parseSidebar(t)
+
+	var lines []string
+	m.Walk(func(path []string, item *Item) {
+		lines = append(lines, strings.Join(path, " > ")+" ["+item.SubmenuID+item.MenuID+item.Href+"]")
+	})
+	want := []string{
+		"Accueil [0]",
+		"Scolarité [submenu_1]",
+		"Scolarité > Mes notes [1_0]",
+		"Scolarité > Mes notes de stage [1_1]",
+		"Scolarité > Archives [submenu_12]",
+		"Divers [submenu_6]",
+		"Moodle [https://moodle.example.fr/]",
+	}
+	if got := strings.Join(lines, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", got, strings.Join(want, "\n"))
+	}
+
+	if item := m.Submenu("submenu_12"); item == nil || item.Label != "Archives" || !item.IsSubmenu() || len(item.Children) != 0 {
+		t.Errorf("unexpected submenu: %v", item)
+	}
+	if m.Submenu("submenu_99") != nil {
+		t.Error("unknown submenus should be nil")
+	}
+	if notes, _ := m.Find("Scolarité", "Mes notes"); notes.Params["form:sidebar"] != "form:sidebar" || notes.IsSubmenu() {
+		t.Errorf("unexpected item: %+v", notes)
+	}
+}
+
+func TestFind(t *testing.T) {
+	m := parseSidebar(t)
+	tests := []struct {
+		path []string
+		want string // menu ID, submenu ID or error
+	}{
+		// an exact label wins over an earlier label containing it
+		{[]string{"scolarité", "mes notes"}, "1_0"},
+		{[]string{"Scolarité", "MES NOTES"}, "1_0"},
+		{[]string{"Scolarité", "stage"}, "1_1"},
+		{[]string{" Scolarité ", "notes"}, "1_0"},
+		{[]string{"divers"}, "submenu_6"},
+		{[]string{"Scolarité", "Archives", "2023"}, `menu "Scolarité > Archives > 2023" not found`},
+		{[]string{"Scolarité", ""}, `menu "Scolarité > " not found`},
+		{[]string{"Absences"}, `menu "Absences" not found`},
+		{nil, "empty menu path"},
+	}
+	for _, tt := range tests {
+		item, err := m.Find(tt.path...)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		} else {
+			got = item.SubmenuID + item.MenuID
+		}
+		if got != tt.want {
+			t.Errorf("Find(%q): got %s, want %s", tt.path, got, tt.want)
+		}
+	}
+
+	scolarite, _ := m.Find("Scolarité")
+	if child := scolarite.Child("Mes notes"); child == nil || child.MenuID != "1_0" {
+		t.Errorf("unexpected child: %v", child)
+	}
+}
+
+func TestParseLinks(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(sidebar))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, item := range ParseLinks(doc).Items {
+		got = append(got, item.Label+"="+item.Params["id"])
+	}
+	if want := "Mon planning=form:j_idt60,Mes données=form:j_idt70"; strings.Join(got, ",") != want {
+		t.Errorf("got %s, want %s", strings.Join(got, ","), want)
+	}
+}
@@ -0,0 +1,97 @@
+package menu
+
+import "strings"
+
+// ParseOnclick returns the parameters of a PrimeFaces onclick, in both formats of
+// WebAurion: PrimeFaces.addSubmitParam('form',{'form:sidebar':'form:sidebar',...}) and
+// PrimeFaces.ab({s:"form:j_idt52",...}). Keys may be quoted or not, nested objects and
+// arrays are kept as raw text.
+func ParseOnclick(onclick string) map[string]string {
+	params := make(map[string]string)
+
+	if i := strings.Index(onclick, "addSubmitParam"); i >= 0 {
+		onclick = onclick[i:]
+	}
+	start := strings.Index(onclick, "{")
+	if start == -1 {
+		return params
+	}
+
+	s := onclick[start+1:]
+	for {
+		s = strings.TrimLeft(s, " \t\r\n,")
+		if s == "" || s[0] == '}' {
+			return params
+		}
+		key, rest, ok := jsToken(s)
+		if !ok {
+			return params
+		}
+		rest = strings.TrimLeft(rest, " \t\r\n")
+		if rest == "" || rest[0] != ':' {
+			return params
+		}
+		value, rest, ok := jsToken(strings.TrimLeft(rest[1:], " \t\r\n"))
+		if !ok {
+			return params
+		}
+		params[key] = value
+		s = rest
+	}
+}
+
+// jsToken reads a quoted string, a nested object or array, or a bare word at the start of s.
+func jsToken(s string) (string, string, bool) {
+	if s == "" {
+		return "", s, false
+	}
+	switch quote := s[0]; quote {
+	case '\'', '"':
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				if i+1 < len(s) {
+					i++
+					b.WriteByte(s[i])
+				}
+			case quote:
+				return b.String(), s[i+1:], true
+			default:
+				b.WriteByte(s[i])
+			}
+		}
+		return "", "", false
+	case '{', '[':
+		depth := 0
+		var inQuote byte
+		for i := 0; i < len(s); i++ {
+			c := s[i]
+			switch {
+			case inQuote != 0:
+				if c == '\\' {
+					i++
+				} else if c == inQuote {
+					inQuote = 0
+				}
+			case c == '\'' || c == '"':
+				inQuote = c
+			case c == '{' || c == '[':
+				depth++
+			case c == '}' || c == ']':
+				if depth--; depth == 0 {
+					return s[:i+1], s[i+1:], true
+				}
+			}
+		}
+		return "", "", false
+	}
+	end := strings.IndexAny(s, ":,} \t\r\n")
+	if end == -1 {
+		end = len(s)
+	}
+	if end == 0 {
+		return "", s, false
+	}
+	return s[:end], s[end:], true
+}
@@ -0,0 +1,396 @@
+package webaurion
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ManifestName is the name of the manifest written in mirror directories.
+const ManifestName = ".isengo-mirror.json"
+
+// MirrorOptions configures MirrorCourse.
+type MirrorOptions struct {
+	Concurrency int // parallel downloads, 4 for <= 0
+}
+
+// MirrorFile is a mirrored file of the manifest.
+type MirrorFile struct {
+	Path         string    `json:"path"` // relative to the mirror directory
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	Target       string    `json:"target,omitempty"` // destination of links
+	SyncedAt     time.Time `json:"syncedAt"`
+}
+
+// MirrorManifest records the mirrored files by source URL, so that an interrupted sync
+// resumes and unchanged files aren't downloaded again.
+type MirrorManifest struct {
+	CourseID int                   `json:"courseId"`
+	Files    map[string]MirrorFile `json:"files"`
+}
+
+// MirrorReport is the result of MirrorCourse.
+type MirrorReport struct {
+	CourseID   int               `json:"courseId"`
+	Dir        string            `json:"dir"`
+	Downloaded []string          `json:"downloaded"` // relative paths
+	Unchanged  []string          `json:"unchanged"`
+	Failed     map[string]string `json:"failed"` // error by source URL
+}
+
+// mirrorItem is a file to sync.
+type mirrorItem struct {
+	URL  string
+	Dir  string // relative to the mirror directory
+	Name string // used when the server doesn't name the file
+	Link bool   // url activity, written as an internet shortcut
+}
+
+// loadManifest reads the manifest of dir, an empty one when there is none.
+func loadManifest(dir string, courseID int) (*MirrorManifest, error) {
+	manifest := &MirrorManifest{CourseID: courseID, Files: map[string]MirrorFile{}}
+	data, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest: %v", err)
+	}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("error parsing manifest: %v", err)
+	}
+	if manifest.Files == nil {
+		manifest.Files = map[string]MirrorFile{}
+	}
+	return manifest, nil
+}
+
+// save writes the manifest in dir.
+func (mm *MirrorManifest) save(dir string) error {
+	data, err := json.MarshalIndent(mm, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding manifest: %v", err)
+	}
+	if err := writeFileAtomic(filepath.Join(dir, ManifestName), strings.NewReader(string(data))); err != nil {
+		return fmt.Errorf("error writing manifest: %v", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes a file through a temporary file, so that an interrupted write
+// doesn't leave a truncated file.
+func writeFileAtomic(name string, content io.Reader) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".isengo-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// safeFileName replaces the characters that aren't allowed in file names.
+func safeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 32 || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(name, " .")
+	if name == "" {
+		return "_"
+	}
+	return name
+}
+
+// MirrorCourse mirrors the resources of a course (files, folders and links) in dir, one
+// directory per section. Files are only downloaded again when their ETag or modification
+// time changed; links are written as .url shortcuts. Files that fail are reported in
+// Failed and retried by the next sync.
+func (m *Moodle) MirrorCourse(courseID int, dir string, options MirrorOptions) (*MirrorReport, error) {
+	content, err := m.GetCourseContent(courseID)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating mirror directory: %v", err)
+	}
+	manifest, err := loadManifest(dir, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &MirrorReport{CourseID: courseID, Dir: dir, Downloaded: []string{}, Unchanged: []string{}, Failed: map[string]string{}}
+	var items []mirrorItem
+	for _, resource := range content.Resources() {
+		section := safeFileName(resource.Section)
+		switch resource.Type {
+		case "folder":
+			files, err := m.GetFolderFiles(resource)
+			if err != nil {
+				report.Failed[resource.URL] = err.Error()
+				continue
+			}
+			for _, file := range files {
+				items = append(items, mirrorItem{URL: file.URL, Dir: filepath.Join(section, safeFileName(resource.Name)), Name: file.Name})
+			}
+		case "url":
+			items = append(items, mirrorItem{URL: resource.URL, Dir: section, Name: resource.Name, Link: true})
+		default:
+			items = append(items, mirrorItem{URL: resource.URL, Dir: section, Name: resource.Name})
+		}
+	}
+
+	concurrency := options.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	var mu sync.Mutex
+	claimed := map[string]string{} // source URL by path, to keep homonyms apart
+	claim := func(path, source string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		ext := filepath.Ext(path)
+		candidate := path
+		for i := 2; claimed[candidate] != "" && claimed[candidate] != source || manifest.owner(candidate, source); i++ {
+			candidate = fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(path, ext), i, ext)
+		}
+		claimed[candidate] = source
+		return candidate
+	}
+
+	var saveErr error // first error saving the manifest, later saves are skipped
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, item := range items {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(item mirrorItem) {
+			defer func() { <-sem; wg.Done() }()
+			mu.Lock()
+			previous, known := manifest.Files[item.URL]
+			mu.Unlock()
+			if known && !fileExists(filepath.Join(dir, previous.Path)) {
+				previous = MirrorFile{Path: previous.Path}
+			}
+
+			var file MirrorFile
+			var changed bool
+			var err error
+			if item.Link {
+				file, changed, err = m.mirrorLink(dir, item, previous, claim)
+			} else {
+				file, changed, err = m.mirrorFile(dir, item, previous, claim)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				report.Failed[item.URL] = err.Error()
+				return
+			}
+			manifest.Files[item.URL] = file
+			if changed {
+				report.Downloaded = append(report.Downloaded, file.Path)
+			} else {
+				report.Unchanged = append(report.Unchanged, file.Path)
+			}
+			// saved after every file to resume an interrupted sync
+			if saveErr == nil {
+				saveErr = manifest.save(dir)
+			}
+		}(item)
+	}
+	wg.Wait()
+
+	slices.Sort(report.Downloaded)
+	slices.Sort(report.Unchanged)
+	if err := manifest.save(dir); err != nil {
+		if saveErr != nil {
+			err = saveErr
+		}
+		return report, err
+	}
+	return report, nil
+}
+
+// MirrorCourses mirrors every enrolled course in a directory of dir named after its short name.
+func (m *Moodle) MirrorCourses(dir string, options MirrorOptions) ([]*MirrorReport, error) {
+	courses, err := m.GetCourses()
+	if err != nil {
+		return nil, err
+	}
+	reports := []*MirrorReport{}
+	for _, course := range courses.Courses {
+		name := course.ShortName
+		if name == "" {
+			name = course.FullName
+		}
+		report, err := m.MirrorCourse(course.ID, filepath.Join(dir, safeFileName(name)), options)
+		if err != nil {
+			return reports, fmt.Errorf("error mirroring %s: %v", name, err)
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// owner reports whether path belongs to another source than source in the manifest.
+func (mm *MirrorManifest) owner(path, source string) bool {
+	for url, file := range mm.Files {
+		if file.Path == path && url != source {
+			return true
+		}
+	}
+	return false
+}
+
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
+
+// mirrorFile syncs a file with a conditional request.
+func (m *Moodle) mirrorFile(dir string, item mirrorItem, previous MirrorFile, claim func(path, source string) string) (MirrorFile, bool, error) {
+	header := http.Header{}
+	if previous.ETag != "" {
+		header.Set("If-None-Match", previous.ETag)
+	}
+	if previous.LastModified != "" {
+		header.Set("If-Modified-Since", previous.LastModified)
+	}
+	resp, err := m.openFile(item.URL, header)
+	if err != nil {
+		return MirrorFile{}, false, err
+	}
+	defer resp.Body.Close()
+
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	unchanged := resp.StatusCode == http.StatusNotModified ||
+		etag != "" && etag == previous.ETag ||
+		etag == "" && lastModified != "" && lastModified == previous.LastModified
+	if unchanged && !previous.SyncedAt.IsZero() {
+		previous.SyncedAt = time.Now()
+		return previous, false, nil
+	}
+
+	name := responseFileName(resp)
+	if name == "" || name == "." || name == "/" || strings.HasSuffix(name, ".php") {
+		name = item.Name
+	}
+	path := filepath.Join(item.Dir, safeFileName(name))
+	if previous.Path != path {
+		path = claim(path, item.URL)
+	}
+	target := filepath.Join(dir, path)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return MirrorFile{}, false, fmt.Errorf("error creating %s: %v", filepath.Dir(path), err)
+	}
+	if err := writeFileAtomic(target, resp.Body); err != nil {
+		return MirrorFile{}, false, fmt.Errorf("error writing %s: %v", path, err)
+	}
+	if modified, err := http.ParseTime(lastModified); err == nil {
+		os.Chtimes(target, modified, modified)
+	}
+	if previous.Path != "" && previous.Path != path {
+		os.Remove(filepath.Join(dir, previous.Path))
+	}
+	return MirrorFile{Path: path, ETag: etag, LastModified: lastModified, SyncedAt: time.Now()}, true, nil
+}
+
+// mirrorLink writes an url activity as an internet shortcut.
+func (m *Moodle) mirrorLink(dir string, item mirrorItem, previous MirrorFile, claim func(path, source string) string) (MirrorFile, bool, error) {
+	destination, err := m.linkTarget(item.URL)
+	if err != nil {
+		return MirrorFile{}, false, err
+	}
+	if destination == previous.Target && !previous.SyncedAt.IsZero() {
+		previous.SyncedAt = time.Now()
+		return previous, false, nil
+	}
+
+	path := filepath.Join(item.Dir, safeFileName(item.Name)+".url")
+	if previous.Path != path {
+		path = claim(path, item.URL)
+	}
+	target := filepath.Join(dir, path)
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return MirrorFile{}, false, fmt.Errorf("error creating %s: %v", filepath.Dir(path), err)
+	}
+	shortcut := "[InternetShortcut]\r\nURL=" + destination + "\r\n"
+	if err := writeFileAtomic(target, strings.NewReader(shortcut)); err != nil {
+		return MirrorFile{}, false, fmt.Errorf("error writing %s: %v", path, err)
+	}
+	return MirrorFile{Path: path, Target: destination, SyncedAt: time.Now()}, true, nil
+}
+
+// linkTarget returns the destination of an url activity without requesting it.
+func (m *Moodle) linkTarget(linkURL string) (string, error) {
+	client := *m.Client
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := client.Get(linkURL + "&redirect=1")
+	if err != nil {
+		return "", fmt.Errorf("error getting %s: %v", linkURL, err)
+	}
+	defer resp.Body.Close()
+
+	if location, err := resp.Location(); err == nil {
+		if strings.HasPrefix(location.Path, "/login/") && sameHost(location, m.BaseURL) || sameHost(location, m.CASURL) {
+			return "", fmt.Errorf("Moodle session expired, log in again")
+		}
+		return location.String(), nil
+	}
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error parsing %s: %v", linkURL, err)
+	}
+	if href, ok := doc.Find(".urlworkaround a").Attr("href"); ok {
+		return href, nil
+	}
+	return "", fmt.Errorf("no link found on %s", linkURL)
+}
+
+// String returns a string representation of the MirrorReport.
+func (mr *MirrorReport) String() string {
+	return fmt.Sprintf("MirrorReport(courseId=%d, dir='%s', downloaded=%d, unchanged=%d, failed=%d)", mr.CourseID, mr.Dir, len(mr.Downloaded), len(mr.Unchanged), len(mr.Failed))
+}
+
+// Get returns the value of a specific key for the MirrorReport.
+func (mr *MirrorReport) Get(key string) (interface{}, error) {
+	switch key {
+	case "downloaded":
+		return mr.Downloaded, nil
+	case "unchanged":
+		return mr.Unchanged, nil
+	case "failed":
+		return mr.Failed, nil
+	default:
+		return nil, fmt.Errorf("invalid key: %s, valid keys are 'downloaded', 'unchanged' and 'failed'", key)
+	}
+}
+
+// JSON returns the JSON representation of the MirrorReport.
+func (mr *MirrorReport) JSON() string {
+	data, err := json.MarshalIndent(mr, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("Error marshaling to JSON: %v", err)
+	}
+	return string(data)
+}
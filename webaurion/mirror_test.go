@@ -0,0 +1,114 @@
+package webaurion
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func mirror(t *testing.T, m *Moodle, dir string) *MirrorReport {
+	t.Helper()
+	// one download at a time, so that homonyms are numbered in the course order
+	report, err := m.MirrorCourse(12, dir, MirrorOptions{Concurrency: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Failed) > 0 {
+		t.Fatalf("failed files: %v", report.Failed)
+	}
+	return report
+}
+
+func TestMirrorCourse(t *testing.T) {
+	m := fakeMoodle(t)
+	if _, err := m.Login("user", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	section := "Semaine 1"
+
+	// first sync, the two cours.pdf get distinct paths
+	report := mirror(t, m, dir)
+	want := strings.Join([]string{
+		filepath.Join(section, "Site.url"),
+		filepath.Join(section, "TD", "td1.pdf"),
+		filepath.Join(section, "cours (2).pdf"),
+		filepath.Join(section, "cours.pdf"),
+	}, ",")
+	if got := strings.Join(report.Downloaded, ","); got != want {
+		t.Fatalf("got downloaded %s, want %s", got, want)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, section, "TD", "td1.pdf")); err != nil || string(data) != "%PDF-1.4" {
+		t.Errorf("got %q, %v", data, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, section, "Site.url")); err != nil || !strings.Contains(string(data), "URL=https://example.com/td") {
+		t.Errorf("got shortcut %q, %v", data, err)
+	}
+	manifest, err := loadManifest(dir, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file := manifest.Files[m.BaseURL+"/mod/resource/view.php?id=6"]; file.Path != filepath.Join(section, "cours (2).pdf") || file.ETag != `"v1"` {
+		t.Errorf("unexpected manifest entry: %+v", file)
+	}
+
+	// second sync, the server answers 304 and the link didn't change
+	report = mirror(t, m, dir)
+	if len(report.Downloaded) != 0 || strings.Join(report.Unchanged, ",") != want {
+		t.Errorf("second sync: got downloaded %v, unchanged %v", report.Downloaded, report.Unchanged)
+	}
+
+	// a file deleted locally is downloaded again at the same path
+	deleted := filepath.Join(section, "cours (2).pdf")
+	if err := os.Remove(filepath.Join(dir, deleted)); err != nil {
+		t.Fatal(err)
+	}
+	report = mirror(t, m, dir)
+	if strings.Join(report.Downloaded, ",") != deleted || len(report.Unchanged) != 3 {
+		t.Errorf("after a deletion: got downloaded %v, unchanged %v", report.Downloaded, report.Unchanged)
+	}
+	if !fileExists(filepath.Join(dir, deleted)) {
+		t.Errorf("%s wasn't downloaded again", deleted)
+	}
+}
+
+func TestMirrorCourseResume(t *testing.T) {
+	m := fakeMoodle(t)
+	if _, err := m.Login("user", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	mirror(t, m, dir)
+
+	// an interrupted sync only recorded the first file
+	manifest, err := loadManifest(dir, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := m.BaseURL + "/mod/resource/view.php?id=5"
+	for source, file := range manifest.Files {
+		if source != first {
+			delete(manifest.Files, source)
+			os.Remove(filepath.Join(dir, file.Path))
+		}
+	}
+	if err := manifest.save(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	report := mirror(t, m, dir)
+	if got := strings.Join(report.Unchanged, ","); got != filepath.Join("Semaine 1", "cours.pdf") {
+		t.Errorf("got unchanged %s", got)
+	}
+	if len(report.Downloaded) != 3 {
+		t.Errorf("got downloaded %v", report.Downloaded)
+	}
+	manifest, err = loadManifest(dir, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Files) != 4 {
+		t.Errorf("got %d files in the manifest, want 4", len(manifest.Files))
+	}
+}
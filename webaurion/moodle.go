@@ -1 +1,978 @@
 package webaurion
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"github.com/CorentinMre/isengo/webaurion/campus"
+)
+
+// Moodle is a client of the Moodle of ISEN, logged in through the CAS shared with WebAurion.
+// BaseURL and CASURL can be changed, e.g. to test against a local fake server.
+type Moodle struct {
+	BaseURL  string
+	CASURL   string
+	Client   *http.Client
+	SessKey  string // session key of the AJAX web services, set by Login
+	LoggedIn bool
+	Location *time.Location // timezone of the dates, Europe/Paris when nil
+}
+
+// NewMoodle creates a Moodle client for ISEN-Ouest.
+func NewMoodle() *Moodle {
+	return NewMoodleForCampus(campus.ISENOuest())
+}
+
+// NewMoodleForCampus creates a Moodle client for the Moodle and the CAS of a campus.
+func NewMoodleForCampus(c *campus.Campus) *Moodle {
+	jar, _ := cookiejar.New(nil)
+	return &Moodle{
+		BaseURL:  c.MoodleURL,
+		CASURL:   c.CASURL,
+		Client:   &http.Client{Jar: jar, Timeout: 30 * time.Second},
+		Location: c.Location(),
+	}
+}
+
+var sessKeyRegex = regexp.MustCompile(`"sesskey":"([^"]+)"`)
+
+// Login logs in to Moodle with the CAS credentials.
+func (m *Moodle) Login(username, password string) (bool, error) {
+	if _, err := casLogin(m.Client, m.CASURL, m.BaseURL+"/login/index.php?authCAS=CAS", username, password); err != nil {
+		return false, err
+	}
+
+	resp, err := m.fetch("/my/", nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("error parsing dashboard: %v", err)
+	}
+	m.SessKey = sessKey(doc)
+	if m.SessKey == "" {
+		return false, fmt.Errorf("session key not found, the login failed")
+	}
+	m.LoggedIn = true
+	return true, nil
+}
+
+// sessKey finds the session key in the M.cfg script or in a form of a page.
+func sessKey(doc *goquery.Document) string {
+	if match := sessKeyRegex.FindStringSubmatch(doc.Text()); match != nil {
+		return match[1]
+	}
+	if key, ok := doc.Find("input[name='sesskey']").Attr("value"); ok {
+		return key
+	}
+	return ""
+}
+
+// get sends a GET request to a path of Moodle or to an absolute URL.
+func (m *Moodle) get(target string) (*http.Response, error) {
+	if !m.LoggedIn {
+		return nil, fmt.Errorf("not logged in to Moodle")
+	}
+	return m.fetch(target, nil)
+}
+
+// fetch is get without the login check, with extra headers (conditional requests...).
+// Responses are 200 OK or, for conditional requests, 304 Not Modified.
+func (m *Moodle) fetch(target string, header http.Header) (*http.Response, error) {
+	if strings.HasPrefix(target, "/") {
+		target = m.BaseURL + target
+	}
+	req, err := http.NewRequest("GET", target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request for %s: %v", target, err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	resp, err := m.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error getting %s: %v", target, err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotModified {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s returned status %d", target, resp.StatusCode)
+	}
+	// an expired session is redirected to the login page
+	if strings.HasPrefix(resp.Request.URL.Path, "/login/") || !sameHost(resp.Request.URL, m.BaseURL) && sameHost(resp.Request.URL, m.CASURL) {
+		resp.Body.Close()
+		return nil, fmt.Errorf("Moodle session expired, log in again")
+	}
+	return resp, nil
+}
+
+// getPage returns the document of a page of Moodle.
+func (m *Moodle) getPage(target string) (*goquery.Document, error) {
+	resp, err := m.get(target)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s: %v", target, err)
+	}
+	return doc, nil
+}
+
+// call calls a function of the AJAX web services of Moodle and decodes its data in result.
+func (m *Moodle) call(method string, args interface{}, result interface{}) error {
+	if !m.LoggedIn {
+		return fmt.Errorf("not logged in to Moodle")
+	}
+	body, err := json.Marshal([]map[string]interface{}{{"index": 0, "methodname": method, "args": args}})
+	if err != nil {
+		return fmt.Errorf("error encoding %s arguments: %v", method, err)
+	}
+	target := m.BaseURL + "/lib/ajax/service.php?sesskey=" + url.QueryEscape(m.SessKey) + "&info=" + url.QueryEscape(method)
+	resp, err := m.Client.Post(target, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error calling %s: %v", method, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading %s response: %v", method, err)
+	}
+
+	// errors of the whole request (expired session...) are an object, not a list
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '{' {
+		var failure struct {
+			Error     string `json:"error"`
+			ErrorCode string `json:"errorcode"`
+		}
+		json.Unmarshal(data, &failure)
+		return fmt.Errorf("%s failed: %s (%s)", method, failure.Error, failure.ErrorCode)
+	}
+	var responses []struct {
+		Error     bool            `json:"error"`
+		Data      json.RawMessage `json:"data"`
+		Exception struct {
+			Message   string `json:"message"`
+			ErrorCode string `json:"errorcode"`
+		} `json:"exception"`
+	}
+	if err := json.Unmarshal(data, &responses); err != nil {
+		return fmt.Errorf("error decoding %s response: %v", method, err)
+	}
+	if len(responses) == 0 {
+		return fmt.Errorf("empty response for %s", method)
+	}
+	if responses[0].Error {
+		return fmt.Errorf("%s failed: %s (%s)", method, responses[0].Exception.Message, responses[0].Exception.ErrorCode)
+	}
+	if err := json.Unmarshal(responses[0].Data, result); err != nil {
+		return fmt.Errorf("error decoding %s data: %v", method, err)
+	}
+	return nil
+}
+
+// MoodleCourse is a course the user is enrolled in.
+type MoodleCourse struct {
+	ID        int       `json:"id"`
+	FullName  string    `json:"fullName"`
+	ShortName string    `json:"shortName"`
+	Category  string    `json:"category"`
+	URL       string    `json:"url"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Progress  float64   `json:"progress"` // percentage of completed activities
+}
+
+// MoodleCourseReport holds the enrolled courses.
+type MoodleCourseReport struct {
+	Courses []MoodleCourse `json:"courses"`
+}
+
+// unixTime returns the time of a Moodle timestamp in location, zero for 0.
+func unixTime(timestamp int64, location *time.Location) time.Time {
+	if timestamp == 0 {
+		return time.Time{}
+	}
+	return time.Unix(timestamp, 0).In(location)
+}
+
+// GetCourses returns the courses the user is enrolled in.
+func (m *Moodle) GetCourses() (*MoodleCourseReport, error) {
+	var data struct {
+		Courses []struct {
+			ID             int      `json:"id"`
+			FullName       string   `json:"fullname"`
+			ShortName      string   `json:"shortname"`
+			CourseCategory string   `json:"coursecategory"`
+			ViewURL        string   `json:"viewurl"`
+			StartDate      int64    `json:"startdate"`
+			EndDate        int64    `json:"enddate"`
+			Progress       *float64 `json:"progress"`
+		} `json:"courses"`
+	}
+	args := map[string]interface{}{"offset": 0, "limit": 0, "classification": "all", "sort": "fullname"}
+	if err := m.call("core_course_get_enrolled_courses_by_timeline_classification", args, &data); err != nil {
+		return nil, err
+	}
+
+	report := &MoodleCourseReport{Courses: []MoodleCourse{}}
+	for _, c := range data.Courses {
+		course := MoodleCourse{
+			ID:        c.ID,
+			FullName:  c.FullName,
+			ShortName: c.ShortName,
+			Category:  c.CourseCategory,
+			URL:       c.ViewURL,
+			Start:     unixTime(c.StartDate, orParis(m.Location)),
+			End:       unixTime(c.EndDate, orParis(m.Location)),
+		}
+		if c.Progress != nil {
+			course.Progress = *c.Progress
+		}
+		report.Courses = append(report.Courses, course)
+	}
+	return report, nil
+}
+
+// Find returns the first course whose full or short name contains name, case insensitive.
+func (cr *MoodleCourseReport) Find(name string) *MoodleCourse {
+	for i, course := range cr.Courses {
+		if containsFold(course.FullName, name) || containsFold(course.ShortName, name) {
+			return &cr.Courses[i]
+		}
+	}
+	return nil
+}
+
+// String returns a string representation of the MoodleCourseReport.
+func (cr *MoodleCourseReport) String() string {
+	return fmt.Sprintf("MoodleCourseReport(courses=%d)", len(cr.Courses))
+}
+
+// Get returns the value of a specific key for the MoodleCourseReport.
+func (cr *MoodleCourseReport) Get(key string) (interface{}, error) {
+	switch key {
+	case "courses":
+		return cr.Courses, nil
+	case "count":
+		return len(cr.Courses), nil
+	default:
+		return nil, fmt.Errorf("invalid key: %s, valid keys are 'courses' and 'count'", key)
+	}
+}
+
+// JSON returns the JSON representation of the MoodleCourseReport.
+func (cr *MoodleCourseReport) JSON() string {
+	data, err := json.MarshalIndent(cr, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("Error marshaling to JSON: %v", err)
+	}
+	return string(data)
+}
+
+// MoodleActivity is an activity or a resource of a course (assign, forum, resource, folder, url, quiz...).
+type MoodleActivity struct {
+	ID      int    `json:"id"` // course module ID, the "id" of its view.php
+	Name    string `json:"name"`
+	Type    string `json:"type"` // Moodle module name
+	URL     string `json:"url"`
+	Section string `json:"section"`
+}
+
+// MoodleSection is a section (topic or week) of a course.
+type MoodleSection struct {
+	Number     int              `json:"number"`
+	Name       string           `json:"name"`
+	Activities []MoodleActivity `json:"activities"`
+}
+
+// MoodleCourseContent holds the sections of a course.
+type MoodleCourseContent struct {
+	CourseID int             `json:"courseId"`
+	Title    string          `json:"title"`
+	Sections []MoodleSection `json:"sections"`
+}
+
+var moduleIDRegex = regexp.MustCompile(`(\d+)$`)
+
+// GetCourseContent returns the sections and activities of a course.
+func (m *Moodle) GetCourseContent(courseID int) (*MoodleCourseContent, error) {
+	doc, err := m.getPage(fmt.Sprintf("/course/view.php?id=%d", courseID))
+	if err != nil {
+		return nil, err
+	}
+	return parseCourseContent(doc, courseID), nil
+}
+
+// parseCourseContent parses the page of a course (Moodle 3 and 4 themes).
+func parseCourseContent(doc *goquery.Document, courseID int) *MoodleCourseContent {
+	content := &MoodleCourseContent{
+		CourseID: courseID,
+		Title:    strings.TrimSpace(doc.Find(".page-header-headings h1, h1").First().Text()),
+		Sections: []MoodleSection{},
+	}
+	doc.Find("li.section").Each(func(i int, s *goquery.Selection) {
+		section := MoodleSection{Number: i, Activities: []MoodleActivity{}}
+		if number, err := strconv.Atoi(s.AttrOr("data-number", "")); err == nil {
+			section.Number = number
+		} else if match := moduleIDRegex.FindString(s.AttrOr("id", "")); match != "" {
+			section.Number, _ = strconv.Atoi(match)
+		}
+		section.Name = strings.TrimSpace(s.Find(".sectionname").First().Text())
+		if section.Name == "" {
+			section.Name = strings.TrimSpace(s.AttrOr("aria-label", ""))
+		}
+
+		s.Find("li.activity").Each(func(j int, a *goquery.Selection) {
+			activity := MoodleActivity{Section: section.Name}
+			id := a.AttrOr("data-id", moduleIDRegex.FindString(a.AttrOr("id", "")))
+			activity.ID, _ = strconv.Atoi(id)
+			for _, class := range strings.Fields(a.AttrOr("class", "")) {
+				if strings.HasPrefix(class, "modtype_") {
+					activity.Type = strings.TrimPrefix(class, "modtype_")
+				}
+			}
+
+			name := a.Find(".instancename").First().Clone()
+			name.Find(".accesshide").Remove()
+			activity.Name = strings.TrimSpace(name.Text())
+			if activity.Name == "" {
+				activity.Name = strings.TrimSpace(a.AttrOr("data-activityname", a.Text()))
+			}
+
+			link := a.Find("a.aalink").First()
+			if link.Length() == 0 {
+				link = a.Find("a[href*='/mod/']").First()
+			}
+			activity.URL = link.AttrOr("href", "")
+			section.Activities = append(section.Activities, activity)
+		})
+		content.Sections = append(content.Sections, section)
+	})
+	return content
+}
+
+// Activities returns the activities of every section of the given types (all for none).
+func (cc *MoodleCourseContent) Activities(types ...string) []MoodleActivity {
+	activities := []MoodleActivity{}
+	for _, section := range cc.Sections {
+		for _, activity := range section.Activities {
+			if len(types) == 0 || slices.Contains(types, activity.Type) {
+				activities = append(activities, activity)
+			}
+		}
+	}
+	return activities
+}
+
+// Resources returns the downloadable resources of the course: files, folders and links.
+func (cc *MoodleCourseContent) Resources() []MoodleActivity {
+	return cc.Activities("resource", "folder", "url")
+}
+
+// String returns a string representation of the MoodleCourseContent.
+func (cc *MoodleCourseContent) String() string {
+	return fmt.Sprintf("MoodleCourseContent(courseId=%d, title='%s', sections=%d)", cc.CourseID, cc.Title, len(cc.Sections))
+}
+
+// Get returns the value of a specific key for the MoodleCourseContent.
+func (cc *MoodleCourseContent) Get(key string) (interface{}, error) {
+	switch key {
+	case "title":
+		return cc.Title, nil
+	case "sections":
+		return cc.Sections, nil
+	case "activities":
+		return cc.Activities(), nil
+	case "resources":
+		return cc.Resources(), nil
+	default:
+		return nil, fmt.Errorf("invalid key: %s, valid keys are 'title', 'sections', 'activities' and 'resources'", key)
+	}
+}
+
+// JSON returns the JSON representation of the MoodleCourseContent.
+func (cc *MoodleCourseContent) JSON() string {
+	data, err := json.MarshalIndent(cc, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("Error marshaling to JSON: %v", err)
+	}
+	return string(data)
+}
+
+// MoodleAssignment is an assignment of a course.
+type MoodleAssignment struct {
+	ID         int        `json:"id"` // course module ID
+	CourseID   int        `json:"courseId"`
+	Name       string     `json:"name"`
+	Section    string     `json:"section"`
+	URL        string     `json:"url"`
+	Due        *time.Time `json:"due"` // nil when there is no due date
+	Submission string     `json:"submission"`
+	Grade      string     `json:"grade"`
+}
+
+// MoodleAssignmentReport holds assignments, sorted by due date.
+type MoodleAssignmentReport struct {
+	Assignments []MoodleAssignment `json:"assignments"`
+}
+
+// GetAssignments returns the assignments of the courses, of every enrolled course for none.
+func (m *Moodle) GetAssignments(courseIDs ...int) (*MoodleAssignmentReport, error) {
+	courseIDs, err := m.courseIDs(courseIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &MoodleAssignmentReport{Assignments: []MoodleAssignment{}}
+	for _, courseID := range courseIDs {
+		doc, err := m.getPage(fmt.Sprintf("/mod/assign/index.php?id=%d", courseID))
+		if err != nil {
+			return nil, err
+		}
+		report.Assignments = append(report.Assignments, parseAssignments(doc, courseID, orParis(m.Location))...)
+	}
+	slices.SortStableFunc(report.Assignments, func(a, b MoodleAssignment) int { return compareDue(a.Due, b.Due) })
+	return report, nil
+}
+
+// indexRow is a row of the activity index of a course (/mod/<type>/index.php).
+type indexRow struct {
+	ID      int
+	Name    string
+	Section string
+	URL     string
+	Cells   *goquery.Selection
+}
+
+// parseIndex parses the activity index of a course, whose first columns are the section and
+// the activity.
+func parseIndex(doc *goquery.Document) []indexRow {
+	rows := []indexRow{}
+	section := ""
+	doc.Find("table.generaltable tbody tr").Each(func(i int, tr *goquery.Selection) {
+		cells := tr.Find("td")
+		link := cells.Eq(1).Find("a").First()
+		if cells.Length() < 2 || link.Length() == 0 {
+			return
+		}
+		// the section is only written on its first row
+		if name := strings.TrimSpace(cells.Eq(0).Text()); name != "" {
+			section = name
+		}
+		row := indexRow{Name: strings.TrimSpace(link.Text()), Section: section, URL: link.AttrOr("href", ""), Cells: cells}
+		if u, err := url.Parse(row.URL); err == nil {
+			row.ID, _ = strconv.Atoi(u.Query().Get("id"))
+		}
+		rows = append(rows, row)
+	})
+	return rows
+}
+
+// dateCell returns the date of a cell in location, nil when there is none.
+func dateCell(cell *goquery.Selection, location *time.Location) *time.Time {
+	date, err := parseMoodleDate(cell.Text(), location)
+	if err != nil {
+		return nil
+	}
+	return &date
+}
+
+// compareDue sorts by due date, without due date last.
+func compareDue(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return a.Compare(*b)
+}
+
+// parseAssignments parses the assignment index of a course, whose columns are the section,
+// the assignment, the due date (in location), the submission and the grade.
+func parseAssignments(doc *goquery.Document, courseID int, location *time.Location) []MoodleAssignment {
+	assignments := []MoodleAssignment{}
+	for _, row := range parseIndex(doc) {
+		assignments = append(assignments, MoodleAssignment{
+			ID:         row.ID,
+			CourseID:   courseID,
+			Name:       row.Name,
+			Section:    row.Section,
+			URL:        row.URL,
+			Due:        dateCell(row.Cells.Eq(2), location),
+			Submission: strings.TrimSpace(row.Cells.Eq(3).Text()),
+			Grade:      strings.TrimSpace(row.Cells.Eq(4).Text()),
+		})
+	}
+	return assignments
+}
+
+var moodleDateRegex = regexp.MustCompile(`(\d{1,2})\s+([^\s\d,]+)\s+(\d{4}),?\s+(\d{1,2}):(\d{2})\s*([AaPp][Mm])?`)
+
+var moodleMonths = map[string]time.Month{
+	"janvier": time.January, "fevrier": time.February, "mars": time.March, "avril": time.April,
+	"mai": time.May, "juin": time.June, "juillet": time.July, "aout": time.August,
+	"septembre": time.September, "octobre": time.October, "novembre": time.November, "decembre": time.December,
+	"january": time.January, "february": time.February, "march": time.March, "april": time.April,
+	"may": time.May, "june": time.June, "july": time.July, "august": time.August,
+	"september": time.September, "october": time.October, "november": time.November, "december": time.December,
+}
+
+// parseMoodleDate parses the dates written by Moodle in French or English,
+// e.g. "vendredi 12 janvier 2024, 23:59" or "Friday, 12 January 2024, 11:59 PM", in location.
+func parseMoodleDate(text string, location *time.Location) (time.Time, error) {
+	match := moodleDateRegex.FindStringSubmatch(text)
+	if match == nil {
+		return time.Time{}, fmt.Errorf("invalid Moodle date: %q", strings.TrimSpace(text))
+	}
+	month, ok := moodleMonths[strings.ToLower(removeAccents(match[2]))]
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid month in Moodle date: %q", match[2])
+	}
+	day, _ := strconv.Atoi(match[1])
+	year, _ := strconv.Atoi(match[3])
+	hour, _ := strconv.Atoi(match[4])
+	minute, _ := strconv.Atoi(match[5])
+	switch strings.ToLower(match[6]) {
+	case "am":
+		if hour == 12 {
+			hour = 0
+		}
+	case "pm":
+		if hour < 12 {
+			hour += 12
+		}
+	}
+	return time.Date(year, month, day, hour, minute, 0, 0, location), nil
+}
+
+// Upcoming returns the assignments due after now.
+func (ar *MoodleAssignmentReport) Upcoming(now time.Time) []MoodleAssignment {
+	assignments := []MoodleAssignment{}
+	for _, assignment := range ar.Assignments {
+		if assignment.Due != nil && assignment.Due.After(now) {
+			assignments = append(assignments, assignment)
+		}
+	}
+	return assignments
+}
+
+// String returns a string representation of the MoodleAssignmentReport.
+func (ar *MoodleAssignmentReport) String() string {
+	return fmt.Sprintf("MoodleAssignmentReport(assignments=%d)", len(ar.Assignments))
+}
+
+// Get returns the value of a specific key for the MoodleAssignmentReport.
+func (ar *MoodleAssignmentReport) Get(key string) (interface{}, error) {
+	switch key {
+	case "assignments":
+		return ar.Assignments, nil
+	case "upcoming":
+		return ar.Upcoming(time.Now()), nil
+	default:
+		return nil, fmt.Errorf("invalid key: %s, valid keys are 'assignments' and 'upcoming'", key)
+	}
+}
+
+// JSON returns the JSON representation of the MoodleAssignmentReport.
+func (ar *MoodleAssignmentReport) JSON() string {
+	data, err := json.MarshalIndent(ar, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("Error marshaling to JSON: %v", err)
+	}
+	return string(data)
+}
+
+// MoodleForumPost is a post of a forum discussion.
+type MoodleForumPost struct {
+	ID      int       `json:"id"`
+	Subject string    `json:"subject"`
+	Author  string    `json:"author"`
+	Date    time.Time `json:"date"`
+	Message string    `json:"message"`
+}
+
+// MoodleDiscussion is a discussion of a forum with its posts.
+type MoodleDiscussion struct {
+	ID      int               `json:"id"`
+	Subject string            `json:"subject"`
+	URL     string            `json:"url"`
+	Posts   []MoodleForumPost `json:"posts"`
+}
+
+// MoodleForumReport holds the discussions of a forum.
+type MoodleForumReport struct {
+	ForumID     int                `json:"forumId"` // course module ID
+	Discussions []MoodleDiscussion `json:"discussions"`
+}
+
+// GetForumPosts returns the discussions of a forum (course module ID) with their posts,
+// the maxDiscussions most recent ones (all for <= 0).
+func (m *Moodle) GetForumPosts(forumID int, maxDiscussions int) (*MoodleForumReport, error) {
+	doc, err := m.getPage(fmt.Sprintf("/mod/forum/view.php?id=%d", forumID))
+	if err != nil {
+		return nil, err
+	}
+
+	report := &MoodleForumReport{ForumID: forumID, Discussions: []MoodleDiscussion{}}
+	seen := map[int]bool{}
+	doc.Find("a[href*='discuss.php?d=']").Each(func(i int, a *goquery.Selection) {
+		href := a.AttrOr("href", "")
+		u, err := url.Parse(href)
+		if err != nil {
+			return
+		}
+		id, err := strconv.Atoi(u.Query().Get("d"))
+		if err != nil || seen[id] || maxDiscussions > 0 && len(report.Discussions) >= maxDiscussions {
+			return
+		}
+		seen[id] = true
+		report.Discussions = append(report.Discussions, MoodleDiscussion{ID: id, Subject: strings.TrimSpace(a.Text()), URL: href})
+	})
+
+	for i, discussion := range report.Discussions {
+		doc, err := m.getPage(fmt.Sprintf("/mod/forum/discuss.php?d=%d", discussion.ID))
+		if err != nil {
+			return nil, err
+		}
+		report.Discussions[i].Posts = parseForumPosts(doc, orParis(m.Location))
+	}
+	return report, nil
+}
+
+// parseForumPosts parses the posts of a discussion page (Moodle 3.8+ and older themes), the
+// dates without offset are in location.
+func parseForumPosts(doc *goquery.Document, location *time.Location) []MoodleForumPost {
+	posts := []MoodleForumPost{}
+	doc.Find("[data-region='post'], div.forumpost").Each(func(i int, s *goquery.Selection) {
+		post := MoodleForumPost{
+			Subject: firstText(s, "[data-region-content='forum-post-core-subject']", ".subject"),
+			Author:  firstText(s, "header a[href*='/user/view.php']", ".author a", "a[href*='/user/view.php']"),
+			Message: firstText(s, ".post-content-container", ".posting"),
+		}
+		post.ID, _ = strconv.Atoi(s.AttrOr("data-post-id", moduleIDRegex.FindString(s.AttrOr("id", ""))))
+
+		date := s.Find("time").First()
+		if value, ok := date.Attr("datetime"); ok {
+			post.Date, _ = time.Parse(time.RFC3339, value)
+		} else if parsed, err := parseMoodleDate(firstText(s, "time", ".author"), location); err == nil {
+			post.Date = parsed
+		}
+		posts = append(posts, post)
+	})
+	return posts
+}
+
+// firstText returns the text of the first selector found in s.
+func firstText(s *goquery.Selection, selectors ...string) string {
+	for _, selector := range selectors {
+		if found := s.Find(selector).First(); found.Length() > 0 {
+			return strings.TrimSpace(found.Text())
+		}
+	}
+	return ""
+}
+
+// Posts returns the posts of every discussion.
+func (fr *MoodleForumReport) Posts() []MoodleForumPost {
+	posts := []MoodleForumPost{}
+	for _, discussion := range fr.Discussions {
+		posts = append(posts, discussion.Posts...)
+	}
+	return posts
+}
+
+// String returns a string representation of the MoodleForumReport.
+func (fr *MoodleForumReport) String() string {
+	return fmt.Sprintf("MoodleForumReport(forumId=%d, discussions=%d, posts=%d)", fr.ForumID, len(fr.Discussions), len(fr.Posts()))
+}
+
+// Get returns the value of a specific key for the MoodleForumReport.
+func (fr *MoodleForumReport) Get(key string) (interface{}, error) {
+	switch key {
+	case "discussions":
+		return fr.Discussions, nil
+	case "posts":
+		return fr.Posts(), nil
+	default:
+		return nil, fmt.Errorf("invalid key: %s, valid keys are 'discussions' and 'posts'", key)
+	}
+}
+
+// JSON returns the JSON representation of the MoodleForumReport.
+func (fr *MoodleForumReport) JSON() string {
+	data, err := json.MarshalIndent(fr, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("Error marshaling to JSON: %v", err)
+	}
+	return string(data)
+}
+
+// MoodleFile is a file of a resource or of a folder.
+type MoodleFile struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// GetFolderFiles returns the files of a folder activity.
+func (m *Moodle) GetFolderFiles(folder MoodleActivity) ([]MoodleFile, error) {
+	doc, err := m.getPage(folder.URL)
+	if err != nil {
+		return nil, err
+	}
+	files := []MoodleFile{}
+	seen := map[string]bool{}
+	doc.Find("a[href*='pluginfile.php']").Each(func(i int, a *goquery.Selection) {
+		href := a.AttrOr("href", "")
+		// the folder can be downloaded as a zip, the files have a "?forcedownload" variant
+		if seen[href] || strings.Contains(href, "download_folder") {
+			return
+		}
+		seen[href] = true
+		name := strings.TrimSpace(a.Find(".fp-filename").Text())
+		if name == "" {
+			name = fileNameOf(href)
+		}
+		files = append(files, MoodleFile{Name: name, URL: href})
+	})
+	return files, nil
+}
+
+// fileNameOf returns the unescaped last element of the path of a file URL.
+func fileNameOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return path.Base(rawURL)
+	}
+	return path.Base(u.Path)
+}
+
+// maxFilePages is the number of HTML pages openFile follows to reach a file.
+const maxFilePages = 3
+
+// openFile requests a file URL or a resource activity with extra headers. Resources
+// displayed in a page (embedded or in a frame) are followed to their file, through at most
+// maxFilePages pages.
+func (m *Moodle) openFile(fileURL string, header http.Header) (*http.Response, error) {
+	if !m.LoggedIn {
+		return nil, fmt.Errorf("not logged in to Moodle")
+	}
+	target := fileURL
+	for pages := 0; ; pages++ {
+		resp, err := m.fetch(target, header)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") || strings.Contains(resp.Request.URL.Path, "pluginfile.php") {
+			return resp, nil
+		}
+		if pages == maxFilePages {
+			resp.Body.Close()
+			return nil, fmt.Errorf("no file found on %s after %d pages", fileURL, maxFilePages)
+		}
+
+		doc, err := goquery.NewDocumentFromReader(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %v", target, err)
+		}
+		file := doc.Find("object[data*='pluginfile.php'], iframe[src*='pluginfile.php'], a[href*='pluginfile.php']").First()
+		link := file.AttrOr("data", file.AttrOr("src", file.AttrOr("href", "")))
+		if link == "" {
+			return nil, fmt.Errorf("no file found on %s", target)
+		}
+		next, err := resp.Request.URL.Parse(link)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file link %q on %s: %v", link, target, err)
+		}
+		target = next.String()
+	}
+}
+
+// responseFileName returns the name of the file of a response.
+func responseFileName(resp *http.Response) string {
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		return params["filename"]
+	}
+	return fileNameOf(resp.Request.URL.String())
+}
+
+// Download returns the content and the name of a file URL or of a resource activity.
+func (m *Moodle) Download(fileURL string) ([]byte, string, error) {
+	resp, err := m.openFile(fileURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("error downloading %s: %v", fileURL, err)
+	}
+	return data, responseFileName(resp), nil
+}
+
+// courseIDs returns ids, or the IDs of every enrolled course for none.
+func (m *Moodle) courseIDs(ids []int) ([]int, error) {
+	if len(ids) > 0 {
+		return ids, nil
+	}
+	courses, err := m.GetCourses()
+	if err != nil {
+		return nil, err
+	}
+	for _, course := range courses.Courses {
+		ids = append(ids, course.ID)
+	}
+	return ids, nil
+}
+
+// MoodleQuiz is a quiz of a course.
+type MoodleQuiz struct {
+	ID       int        `json:"id"` // course module ID
+	CourseID int        `json:"courseId"`
+	Name     string     `json:"name"`
+	Section  string     `json:"section"`
+	URL      string     `json:"url"`
+	Closes   *time.Time `json:"closes"` // nil when the quiz doesn't close
+}
+
+// GetQuizzes returns the quizzes of the courses, of every enrolled course for none,
+// sorted by closing date.
+func (m *Moodle) GetQuizzes(courseIDs ...int) ([]MoodleQuiz, error) {
+	courseIDs, err := m.courseIDs(courseIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	quizzes := []MoodleQuiz{}
+	for _, courseID := range courseIDs {
+		doc, err := m.getPage(fmt.Sprintf("/mod/quiz/index.php?id=%d", courseID))
+		if err != nil {
+			return nil, err
+		}
+		// the closing date column is only there when a quiz closes
+		for _, row := range parseIndex(doc) {
+			quizzes = append(quizzes, MoodleQuiz{
+				ID:       row.ID,
+				CourseID: courseID,
+				Name:     row.Name,
+				Section:  row.Section,
+				URL:      row.URL,
+				Closes:   dateCell(row.Cells.Eq(2), orParis(m.Location)),
+			})
+		}
+	}
+	slices.SortStableFunc(quizzes, func(a, b MoodleQuiz) int { return compareDue(a.Closes, b.Closes) })
+	return quizzes, nil
+}
+
+// MoodleDeadline is the due date of an assignment or the closing date of a quiz.
+type MoodleDeadline struct {
+	ID        int       `json:"id"`   // course module ID
+	Type      string    `json:"type"` // "assign" or "quiz"
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Course    string    `json:"course"`
+	CourseURL string    `json:"courseUrl"`
+	Due       time.Time `json:"due"`
+}
+
+// GetDeadlines returns the deadlines of the assignments and quizzes of the courses,
+// of every enrolled course for none, sorted by date.
+func (m *Moodle) GetDeadlines(courseIDs ...int) ([]MoodleDeadline, error) {
+	courseIDs, err := m.courseIDs(courseIDs)
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := m.GetAssignments(courseIDs...)
+	if err != nil {
+		return nil, err
+	}
+	quizzes, err := m.GetQuizzes(courseIDs...)
+	if err != nil {
+		return nil, err
+	}
+
+	deadlines := []MoodleDeadline{}
+	courseOf := []int{} // course ID of each deadline
+	for _, a := range assignments.Assignments {
+		if a.Due != nil {
+			courseOf = append(courseOf, a.CourseID)
+			deadlines = append(deadlines, MoodleDeadline{ID: a.ID, Type: "assign", Name: a.Name, URL: a.URL, Due: *a.Due})
+		}
+	}
+	for _, q := range quizzes {
+		if q.Closes != nil {
+			courseOf = append(courseOf, q.CourseID)
+			deadlines = append(deadlines, MoodleDeadline{ID: q.ID, Type: "quiz", Name: q.Name, URL: q.URL, Due: *q.Closes})
+		}
+	}
+
+	// the courses are only needed for their names
+	if len(deadlines) > 0 {
+		enrolled, err := m.GetCourses()
+		if err != nil {
+			return nil, err
+		}
+		for i, id := range courseOf {
+			deadlines[i].CourseURL = fmt.Sprintf("%s/course/view.php?id=%d", m.BaseURL, id)
+			if j := slices.IndexFunc(enrolled.Courses, func(c MoodleCourse) bool { return c.ID == id }); j >= 0 {
+				deadlines[i].Course, deadlines[i].CourseURL = enrolled.Courses[j].FullName, enrolled.Courses[j].URL
+			}
+		}
+	}
+	slices.SortStableFunc(deadlines, func(a, b MoodleDeadline) int { return a.Due.Compare(b.Due) })
+	return deadlines, nil
+}
+
+// Event returns the deadline as a planning event of kind EventKindDeadline, starting and
+// ending at the due date. The subject is the course, the description the assignment or quiz.
+func (d MoodleDeadline) Event() Event {
+	return Event{
+		ID:        fmt.Sprintf("moodle-%s-%d", d.Type, d.ID),
+		Start:     d.Due,
+		End:       d.Due,
+		ClassName: "moodle-deadline",
+		Details: Details{
+			Time:        d.Due.Format("15:04"),
+			Type:        "Deadline",
+			Subject:     d.Course,
+			Description: d.Name,
+			Link:        d.CourseURL,
+		},
+	}
+}
+
+// WithDeadlines returns the planning merged with Moodle deadlines in a single timeline,
+// sorted by start.
+func (pr *PlanningReport) WithDeadlines(deadlines []MoodleDeadline) *PlanningReport {
+	events := append([]Event{}, pr.Events...)
+	for _, deadline := range deadlines {
+		events = append(events, deadline.Event())
+	}
+	slices.SortStableFunc(events, func(a, b Event) int { return a.Start.Compare(b.Start) })
+	report := NewPlanningReport(events)
+	report.Warnings = pr.Warnings
+	report.Location = pr.Location
+	return report
+}
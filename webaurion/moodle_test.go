@@ -0,0 +1,260 @@
+package webaurion
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeMoodle starts a CAS server and a Moodle server behind it, with the account
+// "user"/"secret", and returns a client of them.
+func fakeMoodle(t *testing.T) *Moodle {
+	t.Helper()
+	cas := http.NewServeMux()
+	casServer := httptest.NewServer(cas)
+	t.Cleanup(casServer.Close)
+	moodle := http.NewServeMux()
+	moodleServer := httptest.NewServer(moodle)
+	t.Cleanup(moodleServer.Close)
+
+	loginForm := `<html><body><form method="post" action="/cas/login?service=%s">
+		<input type="hidden" name="execution" value="e1s1">
+		<input type="text" name="username"><input type="password" name="password">
+		</form></body></html>`
+	cas.HandleFunc("/cas/login", func(w http.ResponseWriter, r *http.Request) {
+		service := r.URL.Query().Get("service")
+		if r.Method == "POST" && r.FormValue("username") == "user" && r.FormValue("password") == "secret" && r.FormValue("execution") == "e1s1" {
+			http.Redirect(w, r, service+"&ticket=ST-1", http.StatusFound)
+			return
+		}
+		fmt.Fprintf(w, loginForm, url.QueryEscape(service))
+	})
+
+	loggedIn := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if cookie, err := r.Cookie("MoodleSession"); err != nil || cookie.Value != "s1" {
+				http.Redirect(w, r, "/login/index.php", http.StatusSeeOther)
+				return
+			}
+			next(w, r)
+		}
+	}
+	moodle.HandleFunc("/login/index.php", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("ticket") != "ST-1" {
+			http.Redirect(w, r, casServer.URL+"/cas/login?service="+url.QueryEscape(moodleServer.URL+r.URL.RequestURI()), http.StatusFound)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "MoodleSession", Value: "s1", Path: "/"})
+		http.Redirect(w, r, "/my/", http.StatusSeeOther)
+	})
+	moodle.HandleFunc("/my/", loggedIn(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><script>M.cfg = {"wwwroot":"x","sesskey":"key1"};</script><body>Dashboard</body></html>`)
+	}))
+	moodle.HandleFunc("/lib/ajax/service.php", loggedIn(func(w http.ResponseWriter, r *http.Request) {
+		var calls []struct {
+			MethodName string `json:"methodname"`
+		}
+		json.NewDecoder(r.Body).Decode(&calls)
+		if r.URL.Query().Get("sesskey") != "key1" || len(calls) != 1 || calls[0].MethodName != "core_course_get_enrolled_courses_by_timeline_classification" {
+			fmt.Fprint(w, `{"error":"Invalid session key","errorcode":"invalidsesskey"}`)
+			return
+		}
+		fmt.Fprintf(w, `[{"error":false,"data":{"courses":[
+			{"id":12,"fullname":"Mathématiques","shortname":"MATH","viewurl":"%[1]s/course/view.php?id=12","startdate":1704067200,"progress":50},
+			{"id":34,"fullname":"Physique","shortname":"PHY","viewurl":"%[1]s/course/view.php?id=34","startdate":0,"progress":null}]}}]`, moodleServer.URL)
+	}))
+	moodle.HandleFunc("/mod/assign/index.php", loggedIn(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("id") {
+		case "12":
+			fmt.Fprint(w, `<table class="generaltable"><tbody>
+				<tr><td>Semaine 1</td><td><a href="/mod/assign/view.php?id=101">Devoir 1</a></td><td>vendredi 12 janvier 2024, 23:59</td><td>Remis</td><td>15</td></tr>
+				<tr><td></td><td><a href="/mod/assign/view.php?id=102">Devoir 2</a></td><td>-</td><td></td><td></td></tr>
+			</tbody></table>`)
+		case "34":
+			fmt.Fprint(w, `<table class="generaltable"><tbody>
+				<tr><td>TP</td><td><a href="/mod/assign/view.php?id=201">Rapport</a></td><td>Friday, 5 January 2024, 11:59 PM</td><td></td><td></td></tr>
+			</tbody></table>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	moodle.HandleFunc("/mod/quiz/index.php", loggedIn(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("id") == "12" {
+			fmt.Fprint(w, `<table class="generaltable"><tbody>
+				<tr><td>Semaine 2</td><td><a href="/mod/quiz/view.php?id=301">QCM</a></td><td>lundi 8 janvier 2024, 08:00</td></tr>
+			</tbody></table>`)
+			return
+		}
+		fmt.Fprint(w, `<table class="generaltable"><tbody></tbody></table>`)
+	}))
+	moodle.HandleFunc("/mod/resource/view.php", loggedIn(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body><object data="/pluginfile.php/1/mod_resource/content/1/cours.pdf" type="application/pdf"></object></body></html>`)
+	}))
+	moodle.HandleFunc("/mod/page/view.php", loggedIn(func(w http.ResponseWriter, r *http.Request) {
+		// a page whose file link leads to itself
+		fmt.Fprint(w, `<html><body><a href="/mod/page/view.php?id=7&pluginfile.php">file</a></body></html>`)
+	}))
+	moodle.HandleFunc("/pluginfile.php/", loggedIn(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, path.Base(r.URL.Path)))
+		fmt.Fprint(w, "%PDF-1.4")
+	}))
+	// the course 12 has two files named cours.pdf, a folder and a link
+	moodle.HandleFunc("/course/view.php", loggedIn(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><body><ul><li class="section" data-number="1"><h3 class="sectionname">Semaine 1</h3><ul>
+			<li class="activity modtype_resource" data-id="5"><a class="aalink" href="%[1]s/mod/resource/view.php?id=5"><span class="instancename">Cours</span></a></li>
+			<li class="activity modtype_resource" data-id="6"><a class="aalink" href="%[1]s/mod/resource/view.php?id=6"><span class="instancename">Cours (corrigé)</span></a></li>
+			<li class="activity modtype_folder" data-id="8"><a class="aalink" href="%[1]s/mod/folder/view.php?id=8"><span class="instancename">TD</span></a></li>
+			<li class="activity modtype_url" data-id="9"><a class="aalink" href="%[1]s/mod/url/view.php?id=9"><span class="instancename">Site</span></a></li>
+			<li class="activity modtype_forum" data-id="10"><a class="aalink" href="%[1]s/mod/forum/view.php?id=10"><span class="instancename">Annonces</span></a></li>
+		</ul></li></ul></body></html>`, moodleServer.URL)
+	}))
+	moodle.HandleFunc("/mod/folder/view.php", loggedIn(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<html><body>
+			<a href="/pluginfile.php/2/mod_folder/content/0/td1.pdf?forcedownload=1"><span class="fp-filename">td1.pdf</span></a>
+			<a href="/pluginfile.php/2/mod_folder/download_folder.php">Télécharger le dossier</a>
+		</body></html>`)
+	}))
+	moodle.HandleFunc("/mod/url/view.php", loggedIn(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://example.com/td", http.StatusSeeOther)
+	}))
+
+	jar, _ := cookiejar.New(nil)
+	return &Moodle{BaseURL: moodleServer.URL, CASURL: casServer.URL + "/cas", Client: &http.Client{Jar: jar, Timeout: 5 * time.Second}}
+}
+
+func TestMoodleLogin(t *testing.T) {
+	m := fakeMoodle(t)
+	if _, err := m.Login("user", "wrong"); err == nil || !strings.Contains(err.Error(), "incorrect") {
+		t.Errorf("wrong password: got %v", err)
+	}
+	if m.LoggedIn {
+		t.Error("a failed login shouldn't log in")
+	}
+	if _, err := m.GetCourses(); err == nil {
+		t.Error("GetCourses should fail before the login")
+	}
+
+	if ok, err := m.Login("user", "secret"); !ok || err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if m.SessKey != "key1" {
+		t.Errorf("got session key %q", m.SessKey)
+	}
+}
+
+func TestMoodleCourses(t *testing.T) {
+	m := fakeMoodle(t)
+	if _, err := m.Login("user", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	courses, err := m.GetCourses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(courses.Courses) != 2 {
+		t.Fatalf("got %d courses", len(courses.Courses))
+	}
+	maths := courses.Find("mathematiques")
+	if maths == nil || maths.ID != 12 || maths.Progress != 50 || maths.Start.Unix() != 1704067200 {
+		t.Errorf("unexpected course: %+v", maths)
+	}
+	if physics := courses.Courses[1]; !physics.Start.IsZero() || physics.Progress != 0 {
+		t.Errorf("unexpected course: %+v", physics)
+	}
+
+	m.SessKey = "expired"
+	if _, err := m.GetCourses(); err == nil || !strings.Contains(err.Error(), "invalidsesskey") {
+		t.Errorf("invalid session key: got %v", err)
+	}
+}
+
+func TestMoodleAssignments(t *testing.T) {
+	m := fakeMoodle(t)
+	if _, err := m.Login("user", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	report, err := m.GetAssignments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, a := range report.Assignments {
+		names = append(names, a.Name)
+	}
+	// sorted by due date, without due date last
+	if got := strings.Join(names, ","); got != "Rapport,Devoir 1,Devoir 2" {
+		t.Fatalf("got assignments %s", got)
+	}
+	first := report.Assignments[1]
+	due := time.Date(2024, time.January, 12, 23, 59, 0, 0, ParisLocation)
+	if first.ID != 101 || first.CourseID != 12 || first.Section != "Semaine 1" || first.Submission != "Remis" || first.Grade != "15" || !first.Due.Equal(due) {
+		t.Errorf("unexpected assignment: %+v", first)
+	}
+	if second := report.Assignments[2]; second.Section != "Semaine 1" || second.Due != nil {
+		t.Errorf("unexpected assignment: %+v", second)
+	}
+}
+
+func TestMoodleDownload(t *testing.T) {
+	m := fakeMoodle(t)
+	if _, _, err := m.Download(m.BaseURL + "/mod/resource/view.php?id=5"); err == nil {
+		t.Error("Download should fail before the login")
+	}
+	if _, err := m.Login("user", "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, name, err := m.Download(m.BaseURL + "/mod/resource/view.php?id=5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "%PDF-1.4" || name != "cours.pdf" {
+		t.Errorf("got %q named %q", data, name)
+	}
+
+	if _, _, err := m.Download(m.BaseURL + "/mod/page/view.php?id=7"); err == nil || !strings.Contains(err.Error(), "pages") {
+		t.Errorf("looping pages: got %v", err)
+	}
+}
+
+func TestMoodleDeadlines(t *testing.T) {
+	m := fakeMoodle(t)
+	if _, err := m.Login("user", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	deadlines, err := m.GetDeadlines(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deadlines) != 2 {
+		t.Fatalf("got %d deadlines", len(deadlines))
+	}
+	quiz := deadlines[0]
+	if quiz.Type != "quiz" || quiz.Name != "QCM" || quiz.Course != "Mathématiques" || quiz.CourseURL != m.BaseURL+"/course/view.php?id=12" {
+		t.Errorf("unexpected deadline: %+v", quiz)
+	}
+	if deadlines[1].Type != "assign" || deadlines[1].Name != "Devoir 1" {
+		t.Errorf("unexpected deadline: %+v", deadlines[1])
+	}
+
+	all, err := m.GetDeadlines()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 || all[0].Name != "Rapport" || all[0].Course != "Physique" {
+		t.Errorf("unexpected deadlines: %+v", all)
+	}
+}
@@ -0,0 +1,128 @@
+// Package notify sends notifications about WebAurion changes (new grades,
+// absences, planning updates) to webhooks, Discord, email or a terminal.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/CorentinMre/isengo/webaurion"
+)
+
+// Notification is a message about something that changed on WebAurion.
+type Notification struct {
+	Kind    string      `json:"kind"` // "grades", "absences" or "planning"
+	Title   string      `json:"title"`
+	Message string      `json:"message"`
+	At      time.Time   `json:"at"`
+	Data    interface{} `json:"data,omitempty"` // the typed change set
+}
+
+// Notifier delivers notifications.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+// multiNotifier forwards notifications to several notifiers.
+type multiNotifier []Notifier
+
+// Multi returns a Notifier that forwards every notification to all the notifiers.
+// Every notifier is called even if one fails, the errors are joined.
+func Multi(notifiers ...Notifier) Notifier {
+	return multiNotifier(notifiers)
+}
+
+func (m multiNotifier) Notify(ctx context.Context, n Notification) error {
+	var errs []error
+	for _, notifier := range m {
+		if err := notifier.Notify(ctx, n); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GradeNotifications returns the notifications for a grade change set.
+func GradeNotifications(changes *webaurion.GradeChanges, at time.Time) []Notification {
+	var notifications []Notification
+	for _, grade := range changes.Added {
+		notifications = append(notifications, Notification{
+			Kind:    "grades",
+			Title:   "New grade: " + grade.Name,
+			Message: fmt.Sprintf("%s (%s): %s", grade.Name, grade.Code, formatGrade(grade)),
+			At:      at,
+			Data:    grade,
+		})
+	}
+	for _, change := range changes.Changed {
+		notifications = append(notifications, Notification{
+			Kind:    "grades",
+			Title:   "Grade updated: " + change.New.Name,
+			Message: fmt.Sprintf("%s (%s): %s -> %s", change.New.Name, change.New.Code, formatGrade(change.Old), formatGrade(change.New)),
+			At:      at,
+			Data:    change,
+		})
+	}
+	return notifications
+}
+
+func formatGrade(grade webaurion.Grade) string {
+	if grade.Absence {
+		return "absent"
+	}
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", grade.Grade), "0"), ".")
+}
+
+// AbsenceNotifications returns the notifications for an absence change set.
+func AbsenceNotifications(changes *webaurion.AbsenceChanges, at time.Time) []Notification {
+	var notifications []Notification
+	for _, absence := range changes.Added {
+		notifications = append(notifications, Notification{
+			Kind:    "absences",
+			Title:   "New absence: " + absence.Subject,
+			Message: fmt.Sprintf("%s %s (%s): %s, %s", absence.Date, absence.Schedule, absence.Duration, absence.Subject, absence.Reason),
+			At:      at,
+			Data:    absence,
+		})
+	}
+	return notifications
+}
+
+// PlanningNotifications returns one notification summing up a planning change set.
+func PlanningNotifications(changes *webaurion.PlanningChanges, at time.Time) []Notification {
+	if changes.IsEmpty() {
+		return nil
+	}
+
+	var lines []string
+	for _, event := range changes.Added {
+		lines = append(lines, "+ "+describeEvent(event))
+	}
+	for _, event := range changes.Removed {
+		lines = append(lines, "- "+describeEvent(event))
+	}
+	for _, change := range changes.Moved {
+		lines = append(lines, fmt.Sprintf("~ %s moved to %s", describeEvent(change.Old), change.New.Start.Format("02/01 15:04")))
+	}
+	for _, change := range changes.RoomChanged {
+		lines = append(lines, fmt.Sprintf("~ %s now in %s", describeEvent(change.Old), change.New.Details.Room))
+	}
+	for _, change := range changes.Changed {
+		lines = append(lines, fmt.Sprintf("~ %s changed (%s)", describeEvent(change.New), strings.Join(change.Fields, ", ")))
+	}
+
+	return []Notification{{
+		Kind:    "planning",
+		Title:   "Planning updated",
+		Message: strings.Join(lines, "\n"),
+		At:      at,
+		Data:    changes,
+	}}
+}
+
+func describeEvent(event webaurion.Event) string {
+	return fmt.Sprintf("%s %s %s", event.Start.Format("02/01 15:04"), event.Details.Type, event.Details.Subject)
+}
@@ -0,0 +1,208 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// postJSON sends v as a JSON POST request and checks the response status.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("error encoding payload: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending notification: %v", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification rejected with status: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// WebhookNotifier posts every notification as JSON to a URL.
+type WebhookNotifier struct {
+	URL     string
+	Headers map[string]string
+	Client  *http.Client
+}
+
+// NewWebhookNotifier creates a new instance of WebhookNotifier.
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		URL:    url,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (wn *WebhookNotifier) Notify(ctx context.Context, n Notification) error {
+	return postJSON(ctx, wn.Client, wn.URL, wn.Headers, n)
+}
+
+// DiscordNotifier posts notifications to a Discord (or compatible) webhook as embeds.
+type DiscordNotifier struct {
+	WebhookURL string
+	Username   string
+	Client     *http.Client
+}
+
+// NewDiscordNotifier creates a new instance of DiscordNotifier.
+func NewDiscordNotifier(webhookURL string) *DiscordNotifier {
+	return &DiscordNotifier{
+		WebhookURL: webhookURL,
+		Username:   "isengo",
+		Client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// discord limits
+const (
+	discordTitleLimit       = 256
+	discordDescriptionLimit = 4096
+)
+
+var discordColors = map[string]int{
+	"grades":   0x2ecc71,
+	"absences": 0xe74c3c,
+	"planning": 0x3498db,
+}
+
+type discordEmbed struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Timestamp   string `json:"timestamp,omitempty"`
+	Color       int    `json:"color,omitempty"`
+}
+
+type discordPayload struct {
+	Username string         `json:"username,omitempty"`
+	Embeds   []discordEmbed `json:"embeds"`
+}
+
+func (dn *DiscordNotifier) Notify(ctx context.Context, n Notification) error {
+	payload := discordPayload{
+		Username: dn.Username,
+		Embeds: []discordEmbed{{
+			Title:       truncate(n.Title, discordTitleLimit),
+			Description: truncate(n.Message, discordDescriptionLimit),
+			Color:       discordColors[n.Kind],
+		}},
+	}
+	if !n.At.IsZero() {
+		payload.Embeds[0].Timestamp = n.At.Format(time.RFC3339)
+	}
+	return postJSON(ctx, dn.Client, dn.WebhookURL, nil, payload)
+}
+
+func truncate(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+// SMTPNotifier sends every notification as a plain text email.
+type SMTPNotifier struct {
+	Addr string // host:port of the SMTP server
+	From string
+	To   []string
+	Auth smtp.Auth // optional
+
+	// SendMail defaults to smtp.SendMail, it can be replaced to send through another transport.
+	SendMail func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPNotifier creates a new instance of SMTPNotifier.
+// Username may be empty for servers that don't require authentication.
+func NewSMTPNotifier(addr, username, password, from string, to []string) *SMTPNotifier {
+	var auth smtp.Auth
+	if username != "" {
+		host := addr
+		if i := strings.LastIndex(addr, ":"); i >= 0 {
+			host = addr[:i]
+		}
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPNotifier{
+		Addr:     addr,
+		From:     from,
+		To:       to,
+		Auth:     auth,
+		SendMail: smtp.SendMail,
+	}
+}
+
+func (sn *SMTPNotifier) Notify(ctx context.Context, n Notification) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", sn.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(sn.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", sanitizeHeader("[isengo] "+n.Title)))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(n.Message, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	sendMail := sn.SendMail
+	if sendMail == nil {
+		sendMail = smtp.SendMail
+	}
+	if err := sendMail(sn.Addr, sn.Auth, sn.From, sn.To, msg.Bytes()); err != nil {
+		return fmt.Errorf("error sending email: %v", err)
+	}
+	return nil
+}
+
+func sanitizeHeader(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+// StdoutNotifier writes every notification on a writer (os.Stdout by default).
+type StdoutNotifier struct {
+	Writer io.Writer
+	JSON   bool // one JSON object per line instead of text
+}
+
+func (sn *StdoutNotifier) Notify(ctx context.Context, n Notification) error {
+	writer := sn.Writer
+	if writer == nil {
+		writer = os.Stdout
+	}
+
+	if sn.JSON {
+		return json.NewEncoder(writer).Encode(n)
+	}
+	_, err := fmt.Fprintf(writer, "[%s] %s\n%s\n\n", n.At.Format("2006-01-02 15:04"), n.Title, n.Message)
+	return err
+}
@@ -0,0 +1,221 @@
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNotification = Notification{
+	Kind:    "grades",
+	Title:   "New grade: Maths",
+	Message: "Maths: 14.00\nPhysics: 12.50",
+	At:      time.Date(2025, 1, 6, 8, 0, 0, 0, time.UTC),
+}
+
+// recordServer is an HTTP stand-in answering status and recording the requests.
+func recordServer(t *testing.T, status int) (*httptest.Server, chan *http.Request, chan []byte) {
+	t.Helper()
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- r
+		bodies <- body
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, requests, bodies
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	server, requests, bodies := recordServer(t, http.StatusNoContent)
+	notifier := NewWebhookNotifier(server.URL)
+	notifier.Headers = map[string]string{"Authorization": "Bearer token"}
+
+	if err := notifier.Notify(context.Background(), testNotification); err != nil {
+		t.Fatal(err)
+	}
+	req := <-requests
+	if req.Method != "POST" || req.Header.Get("Content-Type") != "application/json" || req.Header.Get("Authorization") != "Bearer token" {
+		t.Errorf("unexpected request: %s %v", req.Method, req.Header)
+	}
+	var got Notification
+	if err := json.Unmarshal(<-bodies, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Kind != testNotification.Kind || got.Title != testNotification.Title || got.Message != testNotification.Message || !got.At.Equal(testNotification.At) {
+		t.Errorf("got %+v, want %+v", got, testNotification)
+	}
+}
+
+func TestWebhookNotifierRejected(t *testing.T) {
+	server, _, _ := recordServer(t, http.StatusInternalServerError)
+	err := NewWebhookNotifier(server.URL).Notify(context.Background(), testNotification)
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("want a status error, got %v", err)
+	}
+}
+
+func TestDiscordNotifier(t *testing.T) {
+	server, _, bodies := recordServer(t, http.StatusOK)
+	notification := testNotification
+	notification.Message = strings.Repeat("é", discordDescriptionLimit+10)
+
+	if err := NewDiscordNotifier(server.URL).Notify(context.Background(), notification); err != nil {
+		t.Fatal(err)
+	}
+	var payload discordPayload
+	if err := json.Unmarshal(<-bodies, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Username != "isengo" || len(payload.Embeds) != 1 {
+		t.Fatalf("unexpected payload: %+v", payload)
+	}
+	embed := payload.Embeds[0]
+	if embed.Title != notification.Title || embed.Color != discordColors["grades"] || embed.Timestamp != "2025-01-06T08:00:00Z" {
+		t.Errorf("unexpected embed: %+v", embed)
+	}
+	if n := len([]rune(embed.Description)); n != discordDescriptionLimit || !strings.HasSuffix(embed.Description, "…") {
+		t.Errorf("description should be truncated to %d runes, got %d", discordDescriptionLimit, n)
+	}
+}
+
+// smtpServer is a minimal in-process SMTP server accepting one message. The received
+// message and the AUTH PLAIN credentials are sent on the returned channel.
+type smtpMessage struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+func smtpServer(t *testing.T) (string, chan smtpMessage) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	messages := make(chan smtpMessage, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+		var message smtpMessage
+
+		reply("220 localhost ESMTP stand-in")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+			switch {
+			case command == "EHLO" || command == "HELO":
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			case strings.HasPrefix(strings.ToUpper(line), "AUTH PLAIN "):
+				decoded, _ := base64.StdEncoding.DecodeString(line[len("AUTH PLAIN "):])
+				message.auth = string(decoded)
+				reply("235 2.7.0 Authentication successful")
+			case strings.HasPrefix(strings.ToUpper(line), "MAIL FROM:"):
+				message.from = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+				reply("250 OK")
+			case strings.HasPrefix(strings.ToUpper(line), "RCPT TO:"):
+				message.to = append(message.to, strings.Trim(line[len("RCPT TO:"):], "<> "))
+				reply("250 OK")
+			case command == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data bytes.Buffer
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				message.data = data.String()
+				reply("250 OK")
+			case command == "QUIT":
+				reply("221 Bye")
+				messages <- message
+				return
+			default:
+				reply("502 Command not implemented")
+			}
+		}
+	}()
+	return listener.Addr().String(), messages
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	addr, messages := smtpServer(t)
+	notifier := NewSMTPNotifier(addr, "user", "secret", "isengo@example.fr", []string{"a@example.fr", "b@example.fr"})
+
+	notification := testNotification
+	notification.Title = "Planning changed\r\nBcc: evil@example.fr"
+	if err := notifier.Notify(context.Background(), notification); err != nil {
+		t.Fatal(err)
+	}
+
+	message := <-messages
+	if message.auth != "\x00user\x00secret" {
+		t.Errorf("unexpected AUTH PLAIN credentials: %q", message.auth)
+	}
+	if message.from != "isengo@example.fr" || strings.Join(message.to, ",") != "a@example.fr,b@example.fr" {
+		t.Errorf("unexpected envelope: %s -> %v", message.from, message.to)
+	}
+	headers, body, _ := strings.Cut(message.data, "\r\n\r\n")
+	if !strings.Contains(headers, "To: a@example.fr, b@example.fr\r\n") || !strings.Contains(headers, "Content-Type: text/plain; charset=utf-8") {
+		t.Errorf("unexpected headers:\n%s", headers)
+	}
+	if strings.Contains(headers, "\r\nBcc:") || !strings.Contains(headers, "Subject: [isengo] Planning changed  Bcc: evil@example.fr\r\n") {
+		t.Errorf("the subject should be sanitized:\n%s", headers)
+	}
+	if body != "Maths: 14.00\r\nPhysics: 12.50\r\n" {
+		t.Errorf("unexpected body: %q", body)
+	}
+}
+
+func TestSMTPNotifierCanceled(t *testing.T) {
+	notifier := NewSMTPNotifier("127.0.0.1:1", "", "", "isengo@example.fr", []string{"a@example.fr"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := notifier.Notify(ctx, testNotification); !errors.Is(err, context.Canceled) {
+		t.Errorf("want context.Canceled, got %v", err)
+	}
+}
+
+func TestMultiJoinsErrors(t *testing.T) {
+	var out bytes.Buffer
+	failing, _, _ := recordServer(t, http.StatusBadRequest)
+	err := Multi(NewWebhookNotifier(failing.URL), &StdoutNotifier{Writer: &out, JSON: true}).Notify(context.Background(), testNotification)
+	if err == nil {
+		t.Error("the webhook error should be returned")
+	}
+	if !strings.Contains(out.String(), `"title":"New grade: Maths"`) {
+		t.Errorf("the other notifiers should still be called, got %q", out.String())
+	}
+}
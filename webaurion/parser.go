@@ -5,13 +5,19 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 	
 	"github.com/PuerkitoBio/goquery"
+	"github.com/CorentinMre/isengo/webaurion/partial"
 )
 
 type BeautifulGrade struct{}
 type BeautifulAbsences struct{}
-type BeautifulPlanning struct{}
+// BeautifulPlanning parses the planning, the zero value parses the one of ISEN-Ouest.
+type BeautifulPlanning struct {
+	ScheduleID string         // ID of the schedule component, "form:j_idt118" when empty
+	Location   *time.Location // timezone of the dates without offset, Europe/Paris when nil
+}
 
 func (b *BeautifulGrade) ParseGrades(html []byte) (*GradeReport, error) {
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(html)))
@@ -25,8 +31,6 @@ func (b *BeautifulGrade) ParseGrades(html []byte) (*GradeReport, error) {
 	}
 
 	var grades []Grade
-	var totalGrade float64
-	var gradeCount int
 
 	doc.Find("tr").Each(func(i int, s *goquery.Selection) {
 		tds := s.Find("td").Map(func(_ int, s *goquery.Selection) string {
@@ -58,20 +62,10 @@ func (b *BeautifulGrade) ParseGrades(html []byte) (*GradeReport, error) {
 		}
 
 		grades = append(grades, grade)
-
-		if !absence {
-			totalGrade += gradeValue
-			gradeCount++
-		}
 	})
 
-	average := 0.0
-	if gradeCount > 0 {
-		average = totalGrade / float64(gradeCount)
-	}
-
 	gradeReport := &GradeReport{
-		Average: average,
+		Average: gradesAverage(grades),
 		Grades:  grades,
 	}
 
@@ -117,75 +111,95 @@ func (b *BeautifulAbsences) ParseAbsences(html []byte) (*AbsenceReport, error) {
 		absences = append(absences, absence)
 	})
 
-	// all periods of absence
-	duration := 0
-	for _, absence := range absences {
-		// split duration into hours and minutes
-		durationParts := strings.Split(absence.Duration, ":")
-		if len(durationParts) != 2 {
-			continue
-		}
+	absenceReport := &AbsenceReport{
+		NbAbsences: len(absences),
+		Duration:       absencesDuration(absences),
+		Data:       absences,
+		Warnings:   absenceWarnings(absences),
+	}
 
-		hours, err := strconv.Atoi(durationParts[0])
-		if err != nil {
-			continue
-		}
+	return absenceReport, nil
+}
 
-		minutes, err := strconv.Atoi(durationParts[1])
-		if err != nil {
-			continue
+// gradesAverage returns the average of the grades, absences excluded.
+func gradesAverage(grades []Grade) float64 {
+	var total float64
+	var count int
+	for _, grade := range grades {
+		if !grade.Absence {
+			total += grade.Grade
+			count++
 		}
-
-		duration += hours*60 + minutes
 	}
 
-	absenceReport := &AbsenceReport{
-		NbAbsences: len(absences),
-		Duration:       duration,
-		Data:       absences,
+	if count == 0 {
+		return 0
 	}
+	return total / float64(count)
+}
 
-	return absenceReport, nil
+// absencesDuration returns the total duration of the absences in minutes.
+// Durations that can't be parsed are skipped (see absenceWarnings).
+func absencesDuration(absences []Absence) int {
+	var duration time.Duration
+	for _, absence := range absences {
+		d, err := absence.ParsedDuration()
+		if err != nil {
+			continue
+		}
+		duration += d
+	}
+	return int(duration / time.Minute)
 }
 
 func (b *BeautifulPlanning) ParsePlanning(html []byte) (*PlanningReport, error) {
-	// delete CDATA tags
-	htmlString := strings.ReplaceAll(string(html), `<![CDATA[`, "")
-	htmlString = strings.ReplaceAll(htmlString, `]]>`, "")
-	
-	// laod the HTML
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlString))
+	if !partial.IsPartial(html) {
+		return nil, fmt.Errorf("no JSON data found, you may not be connected to WebAurion")
+	}
+	response, err := partial.Parse(html)
+	if err == nil {
+		err = response.Err()
+	}
 	if err != nil {
-		return nil, fmt.Errorf("error parsing HTML: %v", err)
+		return nil, fmt.Errorf("error reading planning response: %v", err)
 	}
+	return b.parseResponse(response)
+}
 
-	// extract the JSON data
-	jsonData := doc.Find("#form\\:j_idt118").Text()
-	if jsonData == "" {
+// parseResponse parses the events of the partial response of the schedule.
+func (b *BeautifulPlanning) parseResponse(response *partial.Response) (*PlanningReport, error) {
+	// extract the JSON data, the update of the schedule in the partial response
+	scheduleID := b.ScheduleID
+	if scheduleID == "" {
+		scheduleID = "form:j_idt118"
+	}
+	jsonData, ok := response.Update(scheduleID)
+	if !ok {
+		return nil, fmt.Errorf("error reading planning response: update of %s not found in partial response", scheduleID)
+	}
+	if strings.TrimSpace(jsonData) == "" {
 		return nil, fmt.Errorf("no JSON data found, you may not be connected to WebAurion")
 	}
 
 	// parse the JSON data
 	var rawData struct {
-		Events []map[string]interface{} `json:"events"`
+		Events []json.RawMessage `json:"events"`
 	}
 
-	// fmt.Println(jsonData)
-
 	if err := json.Unmarshal([]byte(jsonData), &rawData); err != nil {
 		return nil, fmt.Errorf("error parsing planning JSON: %v", err)
 	}
 
 	// for all evenements, create an Event object
-	var events []Event
-	for _, rawEvent := range rawData.Events {
-		event, err := NewEvent(rawEvent)
-		if err != nil {
-			return nil, fmt.Errorf("error creating event: %v", err)
-		}
-		events = append(events, *event)
+	location := b.Location
+	if location == nil {
+		location = ParisLocation
 	}
+	events, warnings := parseEvents(rawData.Events, location)
 
 	//return the PlanningReport
-	return NewPlanningReport(events), nil
+	report := NewPlanningReport(events)
+	report.Warnings = warnings
+	report.Location = location
+	return report, nil
 }
\ No newline at end of file
@@ -0,0 +1,156 @@
+// Package partial decodes the JSF partial responses of WebAurion, the XML answers of its
+// AJAX requests (Faces-Request: partial/ajax).
+package partial
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Error is the <error> of a partial response, an exception on the server.
+type Error struct {
+	Name    string `xml:"error-name"`
+	Message string `xml:"error-message"`
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("server error: %s", e.Name)
+	}
+	return fmt.Sprintf("server error: %s: %s", e.Name, strings.TrimSpace(e.Message))
+}
+
+// Extension is an <extension> of a partial response, e.g. the callback parameters of
+// PrimeFaces (ln="primefaces" type="args").
+type Extension struct {
+	Attrs   map[string]string
+	Content string
+}
+
+// Response is a decoded partial response.
+type Response struct {
+	Updates    map[string]string // content of the <update> by component ID
+	Order      []string          // IDs of the updates, in the order of the response
+	ViewState  string            // new ViewState, empty when the response doesn't change it
+	Redirect   string            // URL of the <redirect>, e.g. when the session has expired
+	Error      *Error            // <error>, nil when there is none
+	Evals      []string          // scripts of the <eval>
+	Extensions []Extension
+}
+
+// IsPartial reports whether data is a partial response and not an HTML page.
+func IsPartial(data []byte) bool {
+	return bytes.Contains(data[:min(len(data), 512)], []byte("<partial-response"))
+}
+
+// xml structure of the partial responses
+type xmlResponse struct {
+	XMLName  xml.Name    `xml:"partial-response"`
+	Changes  *xmlChanges `xml:"changes"`
+	Redirect *struct {
+		URL string `xml:"url,attr"`
+	} `xml:"redirect"`
+	Error *Error `xml:"error"`
+}
+
+type xmlChanges struct {
+	Items []xmlChange `xml:",any"`
+}
+
+type xmlChange struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Content string     `xml:",chardata"`
+}
+
+func (c xmlChange) attr(name string) string {
+	for _, attr := range c.Attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// Parse decodes a partial response. It fails when data isn't one, e.g. the login page
+// served instead after the session expired.
+func Parse(data []byte) (*Response, error) {
+	if !IsPartial(data) {
+		return nil, fmt.Errorf("not a partial response, the session may have expired")
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	var raw xmlResponse
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("error decoding partial response: %v", err)
+	}
+
+	response := &Response{Updates: map[string]string{}, Error: raw.Error}
+	if raw.Redirect != nil {
+		response.Redirect = raw.Redirect.URL
+	}
+	if raw.Changes == nil {
+		return response, nil
+	}
+	for _, change := range raw.Changes.Items {
+		switch change.XMLName.Local {
+		case "update":
+			id := change.attr("id")
+			response.Updates[id] = change.Content
+			response.Order = append(response.Order, id)
+			if strings.Contains(id, "javax.faces.ViewState") {
+				response.ViewState = strings.TrimSpace(change.Content)
+			}
+		case "eval":
+			response.Evals = append(response.Evals, change.Content)
+		case "extension":
+			extension := Extension{Attrs: map[string]string{}, Content: change.Content}
+			for _, attr := range change.Attrs {
+				extension.Attrs[attr.Name.Local] = attr.Value
+			}
+			response.Extensions = append(response.Extensions, extension)
+		}
+	}
+	return response, nil
+}
+
+// Update returns the content of the update of the component id.
+func (r *Response) Update(id string) (string, bool) {
+	content, ok := r.Updates[id]
+	return content, ok
+}
+
+// Err returns the server error of the response, or an error for a redirect (the session has
+// expired or the page has changed), nil otherwise.
+func (r *Response) Err() error {
+	if r.Error != nil {
+		return r.Error
+	}
+	if r.Redirect != "" {
+		return fmt.Errorf("redirected to %s, the session may have expired", r.Redirect)
+	}
+	return nil
+}
+
+// Content decodes data and returns the content of the update of the component id, with the
+// error of the response if any.
+func Content(data []byte, id string) (string, *Response, error) {
+	response, err := Parse(data)
+	if err != nil {
+		return "", nil, err
+	}
+	if err := response.Err(); err != nil {
+		return "", response, err
+	}
+	content, ok := response.Update(id)
+	if !ok {
+		return "", response, fmt.Errorf("update of %s not found in partial response", id)
+	}
+	return content, response, nil
+}
@@ -0,0 +1,82 @@
+package partial
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	data := `<?xml version="1.0" encoding="UTF-8"?>
+<partial-response id="j_id1"><changes>
+<update id="form:table"><![CDATA[<tr data-ri="0"><td>a]]]]><![CDATA[>b</td></tr>]]></update>
+<update id="j_id1:javax.faces.ViewState:0"><![CDATA[-123:456]]></update>
+<eval><![CDATA[PF('dialog').show();]]></eval>
+<extension ln="primefaces" type="args">{"totalRecords":12}</extension>
+</changes></partial-response>`
+
+	response, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a CDATA can't hold "]]>", JSF splits it in two sections
+	if got, _ := response.Update("form:table"); got != `<tr data-ri="0"><td>a]]>b</td></tr>` {
+		t.Errorf("got update %q", got)
+	}
+	if response.ViewState != "-123:456" {
+		t.Errorf("got ViewState %q", response.ViewState)
+	}
+	if strings.Join(response.Order, ",") != "form:table,j_id1:javax.faces.ViewState:0" {
+		t.Errorf("got order %v", response.Order)
+	}
+	if len(response.Evals) != 1 || response.Evals[0] != "PF('dialog').show();" {
+		t.Errorf("got evals %v", response.Evals)
+	}
+	if len(response.Extensions) != 1 || response.Extensions[0].Attrs["type"] != "args" || response.Extensions[0].Content != `{"totalRecords":12}` {
+		t.Errorf("got extensions %+v", response.Extensions)
+	}
+	if err := response.Err(); err != nil {
+		t.Errorf("got error %v", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{
+			"server error",
+			`<partial-response><error><error-name>javax.faces.application.ViewExpiredException</error-name><error-message><![CDATA[View could not be restored]]></error-message></error></partial-response>`,
+			"server error: javax.faces.application.ViewExpiredException: View could not be restored",
+		},
+		{
+			"redirect",
+			`<partial-response><redirect url="/webAurion/login"></redirect></partial-response>`,
+			"redirected to /webAurion/login",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := Parse([]byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := response.Err(); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got %v, want %q", err, tt.err)
+			}
+			if _, _, err := Content([]byte(tt.data), "form:table"); err == nil {
+				t.Error("Content should return the error of the response")
+			}
+		})
+	}
+}
+
+func TestNotPartial(t *testing.T) {
+	if _, err := Parse([]byte(`<html><body><form action="/webAurion/login"></form></body></html>`)); err == nil {
+		t.Error("Parse should fail for an HTML page")
+	}
+	if _, _, err := Content([]byte(`<partial-response><changes></changes></partial-response>`), "form:table"); err == nil {
+		t.Error("Content should fail without the update")
+	}
+}
@@ -0,0 +1,231 @@
+package webaurion
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// TimeSlot is a time interval [Start, End).
+type TimeSlot struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Duration returns the duration of the slot.
+func (s TimeSlot) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// Overlaps reports whether two slots share some time.
+func (s TimeSlot) Overlaps(other TimeSlot) bool {
+	return s.Start.Before(other.End) && other.Start.Before(s.End)
+}
+
+// Contains reports whether t is in the slot.
+func (s TimeSlot) Contains(t time.Time) bool {
+	return !t.Before(s.Start) && t.Before(s.End)
+}
+
+// String returns a string representation of the TimeSlot.
+func (s TimeSlot) String() string {
+	return fmt.Sprintf("TimeSlot(start='%s', end='%s')", s.Start.Format(time.RFC3339), s.End.Format(time.RFC3339))
+}
+
+// Slot returns the time slot of the event.
+func (e *Event) Slot() TimeSlot {
+	return TimeSlot{Start: e.Start, End: e.End}
+}
+
+// Workday is the part of a day where free slots are looked for, as offsets from midnight.
+type Workday struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// DefaultWorkday is the workday used by FreeSlots, from 8:00 to 19:00.
+var DefaultWorkday = Workday{Start: 8 * time.Hour, End: 19 * time.Hour}
+
+// On returns the workday of the calendar day of date, in the location of date.
+func (w Workday) On(date time.Time) TimeSlot {
+	day := startOfDay(date, date.Location())
+	return TimeSlot{Start: addClock(day, w.Start), End: addClock(day, w.End)}
+}
+
+// startOfDay returns the midnight of the calendar day of t in location.
+func startOfDay(t time.Time, location *time.Location) time.Time {
+	t = t.In(location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
+}
+
+// addClock returns the wall clock time offset after the midnight of day, so that
+// 8:00 stays 8:00 on daylight saving days.
+func addClock(day time.Time, offset time.Duration) time.Time {
+	hours, minutes := int(offset/time.Hour), int(offset%time.Hour/time.Minute)
+	return time.Date(day.Year(), day.Month(), day.Day(), hours, minutes, 0, 0, day.Location())
+}
+
+// isoWeek returns the ISO week of t, e.g. "2024-W07".
+func isoWeek(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// busySlots returns the merged slots of the events overlapping window, sorted by start.
+// All day events (holidays, closures) don't block any time.
+func busySlots(events []Event, window TimeSlot) []TimeSlot {
+	var busy []TimeSlot
+	for _, event := range events {
+		if event.AllDay || !event.Slot().Overlaps(window) {
+			continue
+		}
+		busy = append(busy, event.Slot())
+	}
+	slices.SortFunc(busy, func(a, b TimeSlot) int { return a.Start.Compare(b.Start) })
+
+	var merged []TimeSlot
+	for _, slot := range busy {
+		if n := len(merged); n > 0 && !slot.Start.After(merged[n-1].End) {
+			if slot.End.After(merged[n-1].End) {
+				merged[n-1].End = slot.End
+			}
+			continue
+		}
+		merged = append(merged, slot)
+	}
+	return merged
+}
+
+// freeSlots returns the gaps of at least minDuration between the busy slots inside window.
+func freeSlots(busy []TimeSlot, window TimeSlot, minDuration time.Duration) []TimeSlot {
+	free := []TimeSlot{}
+	cursor := window.Start
+	for _, slot := range busy {
+		if slot.Start.After(cursor) {
+			gap := TimeSlot{Start: cursor, End: minTime(slot.Start, window.End)}
+			if gap.Duration() > 0 && gap.Duration() >= minDuration {
+				free = append(free, gap)
+			}
+		}
+		if slot.End.After(cursor) {
+			cursor = slot.End
+		}
+	}
+	if gap := (TimeSlot{Start: cursor, End: window.End}); gap.Duration() > 0 && gap.Duration() >= minDuration {
+		free = append(free, gap)
+	}
+	return free
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// FreeSlots returns the gaps of at least minDuration between the events of the day of date,
+// within DefaultWorkday.
+func (pr *PlanningReport) FreeSlots(date time.Time, minDuration time.Duration) []TimeSlot {
+	window := DefaultWorkday.On(date.In(orParis(pr.Location)))
+	return freeSlots(busySlots(pr.Events, window), window, minDuration)
+}
+
+// EventsOn returns the events of the calendar day of date (in the Location of the report),
+// sorted by start.
+func (pr *PlanningReport) EventsOn(date time.Time) []Event {
+	day := startOfDay(date, orParis(pr.Location))
+	window := TimeSlot{Start: day, End: day.AddDate(0, 0, 1)}
+
+	events := []Event{}
+	for _, event := range pr.Events {
+		if event.Slot().Overlaps(window) || (event.Start.Equal(event.End) && window.Contains(event.Start)) {
+			events = append(events, event)
+		}
+	}
+	slices.SortStableFunc(events, func(a, b Event) int { return a.Start.Compare(b.Start) })
+	return events
+}
+
+// NextEvent returns the first event starting at or after now, nil if there is none.
+func (pr *PlanningReport) NextEvent(now time.Time) *Event {
+	var next *Event
+	for i, event := range pr.Events {
+		if event.Start.Before(now) {
+			continue
+		}
+		if next == nil || event.Start.Before(next.Start) {
+			next = &pr.Events[i]
+		}
+	}
+	return next
+}
+
+// WorkloadBy returns the time spent in events by 'week' (ISO week, e.g. "2024-W07"),
+// 'subject', 'type' (as written by WebAurion: CM, TD, TP, DS...) or 'kind' (EventKind name).
+// All day events are not counted.
+func (pr *PlanningReport) WorkloadBy(by string) (map[string]time.Duration, error) {
+	var key func(Event) string
+	switch by {
+	case "week":
+		key = func(e Event) string { return isoWeek(e.Start.In(orParis(pr.Location))) }
+	case "subject":
+		key = func(e Event) string { return e.Details.Subject }
+	case "type":
+		key = func(e Event) string { return e.Details.Type }
+	case "kind":
+		key = func(e Event) string { return e.Kind().String() }
+	default:
+		return nil, fmt.Errorf("invalid workload key: %s, valid keys are 'week', 'subject', 'type' and 'kind'", by)
+	}
+
+	workload := make(map[string]time.Duration)
+	for _, event := range pr.Events {
+		if event.AllDay {
+			continue
+		}
+		workload[key(event)] += event.End.Sub(event.Start)
+	}
+	return workload, nil
+}
+
+// RoomsInUse returns the events taking place at a given time, by room.
+func (pr *PlanningReport) RoomsInUse(at time.Time) map[string]Event {
+	rooms := make(map[string]Event)
+	for _, event := range pr.Events {
+		if !event.Slot().Contains(at) {
+			continue
+		}
+		for _, room := range splitList(event.Details.Room) {
+			rooms[room] = event
+		}
+	}
+	return rooms
+}
+
+// Rooms returns every room of the planning, sorted.
+func (pr *PlanningReport) Rooms() []string {
+	var rooms []string
+	for _, event := range pr.Events {
+		for _, room := range splitList(event.Details.Room) {
+			if !slices.Contains(rooms, room) {
+				rooms = append(rooms, room)
+			}
+		}
+	}
+	slices.Sort(rooms)
+	return rooms
+}
+
+// FreeRooms returns the rooms of the planning that are not in use at a given time.
+// Only the rooms appearing in the planning are known.
+func (pr *PlanningReport) FreeRooms(at time.Time) []string {
+	inUse := pr.RoomsInUse(at)
+	free := []string{}
+	for _, room := range pr.Rooms() {
+		if _, ok := inUse[room]; !ok {
+			free = append(free, room)
+		}
+	}
+	return free
+}
@@ -0,0 +1,177 @@
+package webaurion
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// at returns the time of day of 14/10/2024 in Paris, e.g. at(8, 30).
+func at(hour, minute int) time.Time {
+	return time.Date(2024, time.October, 14, hour, minute, 0, 0, ParisLocation)
+}
+
+// slotEvent returns an event of 14/10/2024 from start to end.
+func slotEvent(id string, start, end time.Time, room, eventType, subject string) Event {
+	return Event{ID: id, Start: start, End: end, Details: Details{Room: room, Type: eventType, Subject: subject}}
+}
+
+func formatSlots(slots []TimeSlot) string {
+	var parts []string
+	for _, slot := range slots {
+		parts = append(parts, slot.Start.Format("15:04")+"-"+slot.End.Format("15:04"))
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestFreeSlots(t *testing.T) {
+	tests := []struct {
+		name        string
+		events      []Event
+		minDuration time.Duration
+		want        string
+	}{
+		{"empty day", nil, 0, "08:00-19:00"},
+		{
+			"overlapping events",
+			[]Event{slotEvent("1", at(9, 0), at(11, 0), "A1", "CM", "Maths"), slotEvent("2", at(10, 0), at(12, 0), "A2", "TD", "Maths")},
+			0, "08:00-09:00,12:00-19:00",
+		},
+		{
+			"adjacent events",
+			[]Event{slotEvent("1", at(8, 0), at(10, 0), "A1", "CM", "Maths"), slotEvent("2", at(10, 0), at(12, 0), "A2", "TD", "Maths")},
+			0, "12:00-19:00",
+		},
+		{
+			"event inside another",
+			[]Event{slotEvent("1", at(13, 0), at(17, 0), "A1", "TP", "Java"), slotEvent("2", at(14, 0), at(15, 0), "A2", "TD", "Maths")},
+			0, "08:00-13:00,17:00-19:00",
+		},
+		{
+			"events crossing the workday",
+			[]Event{slotEvent("1", at(7, 0), at(9, 0), "A1", "CM", "Maths"), slotEvent("2", at(18, 0), at(21, 0), "A2", "TP", "Java")},
+			0, "09:00-18:00",
+		},
+		{
+			"events outside the workday",
+			[]Event{slotEvent("1", at(6, 0), at(7, 30), "A1", "CM", "Maths"), slotEvent("2", at(19, 0), at(21, 0), "A2", "TP", "Java")},
+			0, "08:00-19:00",
+		},
+		{
+			"all day event",
+			[]Event{{ID: "1", Start: at(0, 0), End: at(0, 0).AddDate(0, 0, 1), AllDay: true}},
+			0, "08:00-19:00",
+		},
+		{
+			"min duration",
+			[]Event{slotEvent("1", at(8, 30), at(10, 0), "A1", "CM", "Maths"), slotEvent("2", at(11, 0), at(18, 0), "A2", "TP", "Java")},
+			time.Hour, "10:00-11:00,18:00-19:00",
+		},
+		{
+			"min duration too long",
+			[]Event{slotEvent("1", at(8, 30), at(10, 0), "A1", "CM", "Maths"), slotEvent("2", at(11, 0), at(18, 0), "A2", "TP", "Java")},
+			2 * time.Hour, "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewPlanningReport(tt.events)
+			if got := formatSlots(report.FreeSlots(at(12, 0), tt.minDuration)); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFreeSlotsOnDaylightSavingDay(t *testing.T) {
+	// the clocks go back on 27/10/2024, the workday still starts at 8:00
+	date := time.Date(2024, time.October, 27, 12, 0, 0, 0, ParisLocation)
+	slots := NewPlanningReport(nil).FreeSlots(date, 0)
+	if got := formatSlots(slots); got != "08:00-19:00" || slots[0].Duration() != 11*time.Hour {
+		t.Errorf("got %s (%v)", got, slots[0].Duration())
+	}
+}
+
+func TestNextEvent(t *testing.T) {
+	report := NewPlanningReport([]Event{
+		slotEvent("late", at(14, 0), at(16, 0), "A1", "CM", "Maths"),
+		slotEvent("past", at(8, 0), at(10, 0), "A1", "CM", "Maths"),
+		slotEvent("next", at(10, 0), at(12, 0), "A1", "CM", "Maths"),
+	})
+	if next := report.NextEvent(at(9, 0)); next == nil || next.ID != "next" {
+		t.Errorf("got %v, want next", next)
+	}
+	if next := report.NextEvent(at(10, 0)); next == nil || next.ID != "next" {
+		t.Errorf("an event starting now is the next one, got %v", next)
+	}
+	if next := report.NextEvent(at(15, 0)); next != nil {
+		t.Errorf("got %v, want none", next)
+	}
+}
+
+func TestWorkloadBy(t *testing.T) {
+	report := NewPlanningReport([]Event{
+		slotEvent("1", at(8, 0), at(10, 0), "A1", "CM", "Maths"),
+		slotEvent("2", at(10, 0), at(11, 30), "A1", "TD", "Maths"),
+		slotEvent("3", at(13, 0), at(17, 0), "A1", "TP", "Java"),
+		slotEvent("4", at(8, 0).AddDate(0, 0, 7), at(10, 0).AddDate(0, 0, 7), "A1", "CM", "Java"),
+		{ID: "5", Start: at(0, 0), End: at(0, 0).AddDate(0, 0, 1), AllDay: true, Details: Details{Subject: "Férié"}},
+	})
+
+	tests := []struct {
+		by   string
+		want map[string]time.Duration
+	}{
+		{"week", map[string]time.Duration{"2024-W42": 7*time.Hour + 30*time.Minute, "2024-W43": 2 * time.Hour}},
+		{"subject", map[string]time.Duration{"Maths": 3*time.Hour + 30*time.Minute, "Java": 6 * time.Hour}},
+		{"type", map[string]time.Duration{"CM": 4 * time.Hour, "TD": 90 * time.Minute, "TP": 4 * time.Hour}},
+	}
+	for _, tt := range tests {
+		got, err := report.WorkloadBy(tt.by)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.by, got, tt.want)
+		}
+		for key, duration := range tt.want {
+			if got[key] != duration {
+				t.Errorf("%s: got %v, want %v", tt.by, got, tt.want)
+			}
+		}
+	}
+
+	if _, err := report.WorkloadBy("month"); err == nil || !strings.Contains(err.Error(), "invalid workload key") {
+		t.Errorf("got %v, want an invalid key error", err)
+	}
+}
+
+func TestFreeRooms(t *testing.T) {
+	report := NewPlanningReport([]Event{
+		slotEvent("1", at(8, 0), at(10, 0), "A101", "CM", "Maths"),
+		slotEvent("2", at(9, 0), at(11, 0), "B201 / B202", "TP", "Java"),
+		slotEvent("3", at(10, 0), at(12, 0), "A101", "TD", "Maths"),
+		slotEvent("4", at(14, 0), at(16, 0), "C301", "TD", "Anglais"),
+	})
+	if got := strings.Join(report.Rooms(), ","); got != "A101,B201,B202,C301" {
+		t.Errorf("got rooms %s", got)
+	}
+
+	tests := []struct {
+		at   time.Time
+		want string
+	}{
+		{at(9, 30), "C301"},
+		{at(10, 0), "C301"}, // the first event ends at 10:00, the third starts
+		{at(11, 0), "B201,B202,C301"},
+		{at(13, 0), "A101,B201,B202,C301"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(report.FreeRooms(tt.at), ","); got != tt.want {
+			t.Errorf("FreeRooms(%s) = %s, want %s", tt.at.Format("15:04"), got, tt.want)
+		}
+	}
+	if inUse := report.RoomsInUse(at(9, 30)); inUse["B202"].ID != "2" || inUse["A101"].ID != "1" {
+		t.Errorf("got rooms in use %v", inUse)
+	}
+}
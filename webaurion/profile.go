@@ -0,0 +1,173 @@
+package webaurion
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// UserInfo returns the identity of the student from the personal data page ("Mon compte").
+// When the page isn't available, the names are guessed from the displayed name and the email
+// is built on the campus domain.
+func (w *WebAurion) UserInfo() (*UserInfo, error) {
+	if w.UserInfoLink != "" {
+		data, err := w.DoRequest(w.GetUserInfoPayload())
+		if err == nil {
+			if info, err := parseProfile(data, w.absoluteURL); err == nil {
+				w.completeUserInfo(info)
+				w.LastRequetTime = time.Now()
+				return info, nil
+			}
+		}
+	}
+
+	firstName, lastName := splitName(w.Name)
+	info := NewUserInfo(firstName, lastName, strings.TrimSpace(w.Name), "")
+	info.Source = "name"
+	w.completeUserInfo(info)
+	return info, nil
+}
+
+// completeUserInfo fills what the profile page didn't give.
+func (w *WebAurion) completeUserInfo(info *UserInfo) {
+	if info.Name == "" {
+		info.Name = strings.TrimSpace(w.Name)
+	}
+	if info.FirstName == "" && info.LastName == "" {
+		info.FirstName, info.LastName = splitName(info.Name)
+	}
+	if info.Email == "" && (info.FirstName != "" || info.LastName != "") {
+		info.Email = w.campus().Email(removeAccents(info.FirstName), removeAccents(info.LastName))
+	}
+	if info.PhotoURL == "" {
+		info.PhotoURL = w.PhotoURL
+	}
+}
+
+// absoluteURL resolves a link of a WebAurion page.
+func (w *WebAurion) absoluteURL(link string) string {
+	base, err := url.Parse(w.BaseURL + "/webAurion/faces/MainMenuPage.xhtml")
+	if err != nil {
+		return link
+	}
+	resolved, err := base.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return link
+	}
+	return resolved.String()
+}
+
+// parseProfile parses the personal data page: its "label / value" lines and the photo.
+func parseProfile(html []byte, resolve func(string) string) (*UserInfo, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing profile page: %v", err)
+	}
+
+	info := &UserInfo{Source: "profile"}
+	found := false
+	set := func(label, value string) {
+		value = strings.Join(strings.Fields(value), " ")
+		if value == "" {
+			return
+		}
+		if profileField(info, label, value) {
+			found = true
+		}
+	}
+
+	// form layout of WebAurion, the same as the catalog details
+	doc.Find("div.ligne").Each(func(i int, line *goquery.Selection) {
+		label := line.Find("label").First().Text()
+		value := line.Find("div.colonne2").First()
+		if input := value.Find("input"); input.Length() > 0 {
+			text, _ := input.First().Attr("value")
+			set(label, text)
+			return
+		}
+		set(label, value.Text())
+	})
+	// tables of "label | value"
+	doc.Find("tr").Each(func(i int, row *goquery.Selection) {
+		cells := row.Children()
+		if cells.Length() == 2 {
+			set(cells.Eq(0).Text(), cells.Eq(1).Text())
+		}
+	})
+
+	for _, selector := range []string{"img.photo", "img[src*='photo']", "img[src*='Photo']", "div.menuMonCompte img"} {
+		if src, ok := doc.Find(selector).First().Attr("src"); ok && src != "" {
+			info.PhotoURL = resolve(src)
+			break
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no personal data found in profile page")
+	}
+	return info, nil
+}
+
+// profileField sets the field of info named by a label of the profile page, e.g.
+// "N° étudiant" or "Adresse e-mail", and reports whether the label is known.
+func profileField(info *UserInfo, label, value string) bool {
+	key := strings.Join(normalizedWords(label), " ")
+	switch {
+	case key == "":
+		return false
+	case strings.Contains(key, "mail"):
+		// the official address, not the personal one
+		if info.Email == "" && strings.Contains(value, "@") && !strings.Contains(key, "perso") {
+			info.Email = strings.ToLower(value)
+		}
+	case strings.Contains(key, "matricule"), key == "ine",
+		(strings.Contains(key, "etudiant") || strings.Contains(key, "apprenant")) && (strings.Contains(key, "numero") || strings.HasPrefix(key, "n ") || strings.HasPrefix(key, "no ")):
+		if info.StudentNumber == "" {
+			info.StudentNumber = value
+		}
+	case strings.Contains(key, "promotion"), strings.Contains(key, "promo"):
+		info.Promotion = value
+	case strings.Contains(key, "groupe"), strings.Contains(key, "classe"):
+		info.Group = value
+	case key == "nom prenom" || key == "identite":
+		info.Name = value
+	case strings.Contains(key, "prenom"):
+		info.FirstName = value
+	case key == "nom" || strings.HasPrefix(key, "nom d"):
+		info.LastName = value
+	default:
+		return false
+	}
+	return true
+}
+
+// name particles, part of the last name even when they aren't capitalized
+var nameParticles = map[string]bool{
+	"de": true, "du": true, "des": true, "d": true, "la": true, "le": true,
+	"van": true, "von": true, "der": true, "den": true, "di": true, "da": true, "del": true,
+}
+
+// splitName guesses the first and the last name from a name displayed as "Jean-Pierre
+// DE LA FONTAINE", "DUPONT Élodie" or "Jean de la Fontaine": capitalized words are the
+// last name, and so is everything from a particle following the first name.
+func splitName(name string) (string, string) {
+	var firstName, lastName []string
+	inParticle := false
+	for _, word := range strings.Fields(name) {
+		letters := strings.Trim(word, "-'’.")
+		upper := letters == strings.ToUpper(letters) && letters != strings.ToLower(letters) && len([]rune(letters)) > 1
+		if nameParticles[strings.ToLower(letters)] && len(firstName) > 0 && len(lastName) == 0 {
+			inParticle = true
+		}
+		if upper || inParticle {
+			lastName = append(lastName, word)
+		} else {
+			firstName = append(firstName, word)
+		}
+	}
+	return strings.Join(firstName, " "), strings.Join(lastName, " ")
+}
@@ -0,0 +1,214 @@
+package webaurion
+
+import (
+	"cmp"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// inRange reports whether date is in [from, to]. A zero bound is open.
+func inRange(date, from, to time.Time) bool {
+	if !from.IsZero() && date.Before(from) {
+		return false
+	}
+	if !to.IsZero() && date.After(to) {
+		return false
+	}
+	return true
+}
+
+// containsFold reports whether substr is in s, ignoring case and accents.
+func containsFold(s, substr string) bool {
+	s = strings.ToLower(removeAccents(s))
+	substr = strings.ToLower(removeAccents(substr))
+	return strings.Contains(s, strings.TrimSpace(substr))
+}
+
+// compareDates orders two raw WebAurion dates of location, unparsable dates last.
+func compareDates(a, b string, location *time.Location) int {
+	dateA, errA := ParseFrenchDate(a, location)
+	dateB, errB := ParseFrenchDate(b, location)
+	switch {
+	case errA != nil && errB != nil:
+		return strings.Compare(a, b)
+	case errA != nil:
+		return 1
+	case errB != nil:
+		return -1
+	}
+	return dateA.Compare(dateB)
+}
+
+// filterGrades returns a new GradeReport with the grades matching keep.
+func (gr *GradeReport) filterGrades(keep func(Grade) bool) *GradeReport {
+	grades := []Grade{}
+	for _, grade := range gr.Grades {
+		if keep(grade) {
+			grades = append(grades, grade)
+		}
+	}
+	report := NewGradeReport(gradesAverage(grades), grades)
+	report.Location = gr.Location
+	return report
+}
+
+// Between returns the grades dated between from and to (inclusive).
+// A zero bound is open. Grades with an unparsable date are dropped, unless both bounds
+// are open and the report is returned unfiltered.
+func (gr *GradeReport) Between(from, to time.Time) *GradeReport {
+	return gr.filterGrades(func(grade Grade) bool {
+		if from.IsZero() && to.IsZero() {
+			return true
+		}
+		date, err := ParseFrenchDate(grade.Date, orParis(gr.Location))
+		return err == nil && inRange(date, from, to)
+	})
+}
+
+// BySubject returns the grades whose name or code contains subject (case and accent insensitive).
+func (gr *GradeReport) BySubject(subject string) *GradeReport {
+	return gr.filterGrades(func(grade Grade) bool {
+		return containsFold(grade.Name, subject) || containsFold(grade.Code, subject)
+	})
+}
+
+// ByInstructor returns the grades given by an instructor (case and accent insensitive).
+func (gr *GradeReport) ByInstructor(instructor string) *GradeReport {
+	return gr.filterGrades(func(grade Grade) bool {
+		for _, name := range grade.Instructors {
+			if containsFold(name, instructor) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// SortBy returns a copy of the report sorted by key ('date', 'code', 'name' or 'grade').
+// Prefix the key with '-' for a descending order.
+func (gr *GradeReport) SortBy(key string) (*GradeReport, error) {
+	desc := strings.HasPrefix(key, "-")
+	key = strings.TrimPrefix(key, "-")
+
+	var compare func(a, b Grade) int
+	switch key {
+	case "date":
+		compare = func(a, b Grade) int { return compareDates(a.Date, b.Date, orParis(gr.Location)) }
+	case "code":
+		compare = func(a, b Grade) int { return strings.Compare(a.Code, b.Code) }
+	case "name":
+		compare = func(a, b Grade) int { return strings.Compare(a.Name, b.Name) }
+	case "grade":
+		compare = func(a, b Grade) int {
+			switch {
+			case a.Grade < b.Grade:
+				return -1
+			case a.Grade > b.Grade:
+				return 1
+			}
+			return 0
+		}
+	default:
+		return nil, fmt.Errorf("invalid sort key: %s, valid keys are 'date', 'code', 'name' and 'grade'", key)
+	}
+
+	grades := append([]Grade{}, gr.Grades...)
+	sort.SliceStable(grades, func(i, j int) bool {
+		if desc {
+			return compare(grades[j], grades[i]) < 0
+		}
+		return compare(grades[i], grades[j]) < 0
+	})
+	report := NewGradeReport(gr.Average, grades)
+	report.Location = gr.Location
+	return report, nil
+}
+
+// filterAbsences returns a new AbsenceReport with the absences matching keep.
+func (ar *AbsenceReport) filterAbsences(keep func(Absence) bool) *AbsenceReport {
+	absences := []Absence{}
+	for _, absence := range ar.Data {
+		if keep(absence) {
+			absences = append(absences, absence)
+		}
+	}
+	report := NewAbsenceReport(len(absences), absencesDuration(absences), absences)
+	report.Warnings = absenceWarnings(absences)
+	report.Location = ar.Location
+	return report
+}
+
+// Between returns the absences dated between from and to (inclusive).
+// A zero bound is open. Absences with an unparsable date are dropped, unless both bounds
+// are open and the report is returned unfiltered.
+func (ar *AbsenceReport) Between(from, to time.Time) *AbsenceReport {
+	return ar.filterAbsences(func(absence Absence) bool {
+		if from.IsZero() && to.IsZero() {
+			return true
+		}
+		date, err := ParseFrenchDate(absence.Date, orParis(ar.Location))
+		return err == nil && inRange(date, from, to)
+	})
+}
+
+// BySubject returns the absences whose subject contains subject (case and accent insensitive).
+func (ar *AbsenceReport) BySubject(subject string) *AbsenceReport {
+	return ar.filterAbsences(func(absence Absence) bool {
+		return containsFold(absence.Subject, subject)
+	})
+}
+
+// ByInstructor returns the absences recorded by an instructor (case and accent insensitive).
+func (ar *AbsenceReport) ByInstructor(instructor string) *AbsenceReport {
+	return ar.filterAbsences(func(absence Absence) bool {
+		return containsFold(absence.Instructor, instructor)
+	})
+}
+
+// SortBy returns a copy of the report sorted by key ('date', 'duration', 'reason', 'course', 'instructor' or 'subject').
+// Prefix the key with '-' for a descending order.
+func (ar *AbsenceReport) SortBy(key string) (*AbsenceReport, error) {
+	desc := strings.HasPrefix(key, "-")
+	key = strings.TrimPrefix(key, "-")
+
+	var compare func(a, b Absence) int
+	switch key {
+	case "date":
+		compare = func(a, b Absence) int { return compareDates(a.Date, b.Date, orParis(ar.Location)) }
+	case "duration":
+		compare = func(a, b Absence) int {
+			durationA, _ := a.ParsedDuration()
+			durationB, _ := b.ParsedDuration()
+			return cmp.Compare(durationA, durationB)
+		}
+	case "reason", "course", "instructor", "subject":
+		compare = func(a, b Absence) int {
+			valueA, _ := a.Get(key)
+			valueB, _ := b.Get(key)
+			return strings.Compare(valueA, valueB)
+		}
+	default:
+		return nil, fmt.Errorf("invalid sort key: %s, valid keys are 'date', 'duration', 'reason', 'course', 'instructor' and 'subject'", key)
+	}
+
+	absences := append([]Absence{}, ar.Data...)
+	sort.SliceStable(absences, func(i, j int) bool {
+		if desc {
+			return compare(absences[j], absences[i]) < 0
+		}
+		return compare(absences[i], absences[j]) < 0
+	})
+	report := NewAbsenceReport(ar.NbAbsences, ar.Duration, absences)
+	report.Warnings = ar.Warnings
+	report.Location = ar.Location
+	return report, nil
+}
+
+// Between returns the events overlapping [from, to]. A zero bound is open.
+func (pr *PlanningReport) Between(from, to time.Time) *PlanningReport {
+	return pr.filterEvents(func(event Event) bool {
+		return (from.IsZero() || !event.End.Before(from)) && (to.IsZero() || !event.Start.After(to))
+	})
+}
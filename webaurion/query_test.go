@@ -0,0 +1,33 @@
+package webaurion
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilteredAbsencesKeepWarnings(t *testing.T) {
+	absences := []Absence{
+		{Date: "15/01/2024", Duration: "02:00", Schedule: "08:00 - 10:00", Subject: "Mathématiques", Instructor: "Martin"},
+		{Date: "16/01/2024", Duration: "deux heures", Schedule: "10:00 - 12:00", Subject: "Maths appliquées", Instructor: "Durand"},
+		{Date: "20/02/2024", Duration: "01:00", Schedule: "14:00 - 15:00", Subject: "Physique", Instructor: "Martin"},
+	}
+	report := NewAbsenceReport(len(absences), 0, absences)
+
+	tests := []struct {
+		name     string
+		report   *AbsenceReport
+		count    int
+		warnings int
+	}{
+		{"Between", report.Between(time.Date(2024, time.January, 1, 0, 0, 0, 0, ParisLocation), time.Date(2024, time.January, 31, 0, 0, 0, 0, ParisLocation)), 2, 1},
+		{"BySubject", report.BySubject("math"), 2, 1},
+		{"ByInstructor", report.ByInstructor("martin"), 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if len(tt.report.Data) != tt.count || len(tt.report.Warnings) != tt.warnings {
+				t.Errorf("got %d absences and warnings %v, want %d and %d warnings", len(tt.report.Data), tt.report.Warnings, tt.count, tt.warnings)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/CorentinMre/isengo/webaurion"
+	cat "github.com/CorentinMre/isengo/webaurion/catalog"
+	"github.com/CorentinMre/isengo/webaurion/graphql"
+)
+
+// sessionSource is the GraphQL source of a session, sharing the cache of the REST endpoints.
+type sessionSource struct {
+	server  *Server
+	session *Session
+}
+
+func (src *sessionSource) Location() *time.Location {
+	return src.session.Cache.Location()
+}
+
+func (src *sessionSource) UserInfo() (*webaurion.UserInfo, error) {
+	return src.server.userInfo(src.session)
+}
+
+func (src *sessionSource) Grades() (*webaurion.GradeReport, error) {
+	return src.server.grades(src.session)
+}
+
+func (src *sessionSource) Absences() (*webaurion.AbsenceReport, error) {
+	return src.server.absences(src.session)
+}
+
+func (src *sessionSource) Planning() (*webaurion.PlanningReport, error) {
+	return src.server.planning(src.session)
+}
+
+func (src *sessionSource) Catalogs() ([]cat.Catalog, error) {
+	return src.server.catalogs(src.session)
+}
+
+func (src *sessionSource) CatalogEntries(idx int) (*cat.CatalogReport, error) {
+	return src.server.catalogEntries(src.session, idx)
+}
+
+func (src *sessionSource) CatalogEntryDetails(idx, row int) (*cat.CatalogDetails, error) {
+	return src.server.catalogEntryDetails(src.session, idx, row)
+}
+
+// graphQLSource resolves the bearer token of a GraphQL request.
+func (s *Server) graphQLSource(r *http.Request) (graphql.Source, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return nil, errorf(http.StatusUnauthorized, "missing bearer token")
+	}
+	session, err := s.Sessions.Get(token)
+	if err != nil {
+		return nil, err
+	}
+	return &sessionSource{server: s, session: session}, nil
+}
+
+func (s *Server) handleGraphQLSchema(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(graphql.Schema()))
+}
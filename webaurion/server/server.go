@@ -0,0 +1,353 @@
+// Package server exposes the WebAurion client as a REST/JSON API.
+//
+// Clients open a session with POST /v1/sessions and send the returned token
+// as "Authorization: Bearer <token>" on the other endpoints.
+package server
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/CorentinMre/isengo/webaurion"
+	"github.com/CorentinMre/isengo/webaurion/cache"
+	cat "github.com/CorentinMre/isengo/webaurion/catalog"
+	"github.com/CorentinMre/isengo/webaurion/graphql"
+)
+
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// Config holds the cache TTLs of every resource. A TTL <= 0 disables the cache of the resource.
+type Config struct {
+	GradesTTL            time.Duration
+	AbsencesTTL          time.Duration
+	PlanningTTL          time.Duration
+	CatalogTTL           time.Duration
+	StaleWhileRevalidate time.Duration // expired values are served for this long while they are refreshed
+	SessionIdle          time.Duration // sessions unused for longer are dropped
+	MaxBodyBytes         int64         // size limit of the request bodies, DefaultMaxBodyBytes when <= 0
+}
+
+// DefaultMaxBodyBytes is the default size limit of the request bodies.
+const DefaultMaxBodyBytes = 1 << 20
+
+// cacheConfig returns the cache settings of the sessions. The user info isn't cached
+// and the last snapshot of a resource is served when WebAurion fails.
+func (c Config) cacheConfig() cache.Config {
+	return cache.Config{
+		TTL: map[cache.Resource]time.Duration{
+			cache.ResourceGrades:   c.GradesTTL,
+			cache.ResourceAbsences: c.AbsencesTTL,
+			cache.ResourcePlanning: c.PlanningTTL,
+			cache.ResourceCatalogs: c.CatalogTTL,
+		},
+		StaleWhileRevalidate: c.StaleWhileRevalidate,
+		ServeStaleOnError:    true,
+	}
+}
+
+// DefaultConfig returns the default TTLs.
+func DefaultConfig() Config {
+	return Config{
+		GradesTTL:    15 * time.Minute,
+		AbsencesTTL:  15 * time.Minute,
+		PlanningTTL:  15 * time.Minute,
+		CatalogTTL:   time.Hour,
+		SessionIdle:  30 * time.Minute,
+		MaxBodyBytes: DefaultMaxBodyBytes,
+	}
+}
+
+// Server serves the REST API.
+type Server struct {
+	Config   Config
+	Sessions *SessionPool
+	mux      *http.ServeMux
+}
+
+// New creates a new instance of Server. A nil login uses DefaultLogin.
+func New(config Config, login LoginFunc) *Server {
+	s := &Server{
+		Config:   config,
+		Sessions: NewSessionPool(login, config.SessionIdle),
+		mux:      http.NewServeMux(),
+	}
+	s.Sessions.CacheConfig = config.cacheConfig()
+
+	s.mux.HandleFunc("GET /v1/openapi.json", s.handleOpenAPI)
+	s.mux.HandleFunc("POST /v1/sessions", s.handleLogin)
+	s.mux.HandleFunc("DELETE /v1/sessions", s.withSession(s.handleLogout))
+	s.mux.HandleFunc("GET /v1/me", s.withSession(s.handleMe))
+	s.mux.HandleFunc("GET /v1/grades", s.withSession(s.handleGrades))
+	s.mux.HandleFunc("GET /v1/absences", s.withSession(s.handleAbsences))
+	s.mux.HandleFunc("GET /v1/planning", s.withSession(s.handlePlanning))
+	s.mux.HandleFunc("GET /v1/catalogs", s.withSession(s.handleCatalogs))
+	s.mux.HandleFunc("GET /v1/catalogs/{idx}/entries", s.withSession(s.handleCatalogEntries))
+	s.mux.HandleFunc("GET /v1/catalogs/{idx}/entries/{row}", s.withSession(s.handleCatalogEntry))
+	s.mux.Handle("/v1/graphql", graphql.NewHandler(s.graphQLSource))
+	s.mux.HandleFunc("GET /v1/graphql/schema", s.handleGraphQLSchema)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	limit := s.Config.MaxBodyBytes
+	if limit <= 0 {
+		limit = DefaultMaxBodyBytes
+	}
+	if r.Body != nil {
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// Handle registers an extra handler on the server mux (e.g. a GraphQL endpoint).
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
+// apiError is an error with an HTTP status.
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+func errorf(status int, format string, args ...interface{}) error {
+	return &apiError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway // WebAurion failed
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		status = apiErr.status
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+type sessionHandler func(w http.ResponseWriter, r *http.Request, token string, session *Session) error
+
+// withSession resolves the bearer token of the request.
+func (s *Server) withSession(h sessionHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			writeError(w, errorf(http.StatusUnauthorized, "missing bearer token"))
+			return
+		}
+
+		session, err := s.Sessions.Get(token)
+		if err != nil {
+			writeError(w, errorf(http.StatusUnauthorized, "%v", err))
+			return
+		}
+
+		if err := h(w, r, token, session); err != nil {
+			writeError(w, err)
+		}
+	}
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPIDocument)
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	var credentials struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil || credentials.Username == "" || credentials.Password == "" {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, errorf(http.StatusRequestEntityTooLarge, "request body larger than %d bytes", tooLarge.Limit))
+			return
+		}
+		writeError(w, errorf(http.StatusBadRequest, "expected a JSON body with username and password"))
+		return
+	}
+
+	token, _, err := s.Sessions.Open(credentials.Username, credentials.Password)
+	if err != nil {
+		writeError(w, errorf(http.StatusUnauthorized, "%v", err))
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]string{"token": token})
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request, token string, session *Session) error {
+	s.Sessions.Close(token)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (s *Server) handleMe(w http.ResponseWriter, r *http.Request, token string, session *Session) error {
+	info, err := s.userInfo(session)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, info)
+	return nil
+}
+
+func (s *Server) userInfo(session *Session) (*webaurion.UserInfo, error) {
+	return session.Cache.UserInfo()
+}
+
+func (s *Server) grades(session *Session) (*webaurion.GradeReport, error) {
+	return session.Cache.GetGrades()
+}
+
+func (s *Server) handleGrades(w http.ResponseWriter, r *http.Request, token string, session *Session) error {
+	grades, err := s.grades(session)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, grades)
+	return nil
+}
+
+func (s *Server) absences(session *Session) (*webaurion.AbsenceReport, error) {
+	return session.Cache.GetAbsences()
+}
+
+func (s *Server) handleAbsences(w http.ResponseWriter, r *http.Request, token string, session *Session) error {
+	absences, err := s.absences(session)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, absences)
+	return nil
+}
+
+// parseTime accepts RFC 3339 timestamps and YYYY-MM-DD dates of location.
+func parseTime(value string, location *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, location)
+}
+
+func (s *Server) planning(session *Session) (*webaurion.PlanningReport, error) {
+	return session.Cache.GetPlanning()
+}
+
+func (s *Server) handlePlanning(w http.ResponseWriter, r *http.Request, token string, session *Session) error {
+	from, err := parseTime(r.URL.Query().Get("from"), session.Cache.Location())
+	if err != nil {
+		return errorf(http.StatusBadRequest, "invalid from: %v", err)
+	}
+	to, err := parseTime(r.URL.Query().Get("to"), session.Cache.Location())
+	if err != nil {
+		return errorf(http.StatusBadRequest, "invalid to: %v", err)
+	}
+
+	planning, err := s.planning(session)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, planning.Between(from, to))
+	return nil
+}
+
+func (s *Server) catalogs(session *Session) ([]cat.Catalog, error) {
+	return session.Cache.ListCatalogs()
+}
+
+func (s *Server) handleCatalogs(w http.ResponseWriter, r *http.Request, token string, session *Session) error {
+	catalogs, err := s.catalogs(session)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, catalogs)
+	return nil
+}
+
+// catalogIndex parses the {idx} path value.
+func catalogIndex(r *http.Request) (int, error) {
+	idx, err := strconv.Atoi(r.PathValue("idx"))
+	if err != nil {
+		return 0, errorf(http.StatusBadRequest, "invalid catalog index: %s", r.PathValue("idx"))
+	}
+	return idx, nil
+}
+
+// catalogEntries returns the entries of a catalog.
+func (s *Server) catalogEntries(session *Session, idx int) (*cat.CatalogReport, error) {
+	// the catalogs must be loaded on the client before reading entries
+	catalogs, err := s.catalogs(session)
+	if err != nil {
+		return nil, err
+	}
+	if idx < 0 || idx >= len(catalogs) {
+		return nil, errorf(http.StatusNotFound, "catalog %d not found", idx)
+	}
+
+	return session.Cache.GetCatalogEntries(idx)
+}
+
+// catalogEntryDetails returns the details of a row of a catalog.
+func (s *Server) catalogEntryDetails(session *Session, idx, row int) (*cat.CatalogDetails, error) {
+	report, err := s.catalogEntries(session, idx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range report.Entries {
+		if entry.RowIndex != row {
+			continue
+		}
+		return session.Cache.GetCatalogEntryDetails(idx, entry)
+	}
+	return nil, errorf(http.StatusNotFound, "row %d not found", row)
+}
+
+func (s *Server) handleCatalogEntries(w http.ResponseWriter, r *http.Request, token string, session *Session) error {
+	idx, err := catalogIndex(r)
+	if err != nil {
+		return err
+	}
+	report, err := s.catalogEntries(session, idx)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, report)
+	return nil
+}
+
+func (s *Server) handleCatalogEntry(w http.ResponseWriter, r *http.Request, token string, session *Session) error {
+	idx, err := catalogIndex(r)
+	if err != nil {
+		return err
+	}
+	row, err := strconv.Atoi(r.PathValue("row"))
+	if err != nil {
+		return errorf(http.StatusBadRequest, "invalid row: %s", r.PathValue("row"))
+	}
+
+	details, err := s.catalogEntryDetails(session, idx, row)
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, details)
+	return nil
+}
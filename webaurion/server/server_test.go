@@ -0,0 +1,90 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/CorentinMre/isengo/webaurion"
+	"github.com/CorentinMre/isengo/webaurion/campus"
+)
+
+func testServer(maxBody int64) *Server {
+	config := DefaultConfig()
+	config.MaxBodyBytes = maxBody
+	return New(config, func(username, password string) (*webaurion.WebAurion, error) {
+		return webaurion.NewWebAurion(), nil
+	})
+}
+
+func TestLoginBody(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{"valid", `{"username":"user","password":"secret"}`, http.StatusCreated},
+		{"missing password", `{"username":"user"}`, http.StatusBadRequest},
+		{"too large", `{"username":"` + strings.Repeat("a", 2048) + `","password":"secret"}`, http.StatusRequestEntityTooLarge},
+	}
+	s := testServer(1024)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, httptest.NewRequest("POST", "/v1/sessions", strings.NewReader(tt.body)))
+			if rec.Code != tt.status {
+				t.Errorf("got status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+		})
+	}
+}
+
+func TestNewLogin(t *testing.T) {
+	// a campus whose WebAurion refuses the session cookie
+	var cookie string
+	webAurion := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("JSESSIONID"); err == nil {
+			cookie = c.Value
+		}
+		w.Write([]byte("<html><body>Session expired</body></html>"))
+	}))
+	defer webAurion.Close()
+	profile := campus.ISENOuest()
+	profile.BaseURL = webAurion.URL
+	data, err := json.Marshal(profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "campus.json")
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = NewLogin(file, webaurion.CookieLogin{Cookie: "JSESSIONID=abc"})("", "")
+	if err == nil || !strings.Contains(err.Error(), "cookie") {
+		t.Errorf("got error %v, want an invalid cookie", err)
+	}
+	if cookie != "abc" {
+		t.Errorf("the campus of the profile got cookie %q", cookie)
+	}
+
+	if _, err := NewLogin("unknown", nil)("user", "secret"); err == nil || !strings.Contains(err.Error(), "unknown campus") {
+		t.Errorf("unknown campus: got %v", err)
+	}
+}
+
+func TestGraphQLBodyLimit(t *testing.T) {
+	s := testServer(64)
+	rec := httptest.NewRecorder()
+	body := `{"query":"{ grades { average } }","variables":{"padding":"` + strings.Repeat("a", 128) + `"}}`
+	req := httptest.NewRequest("POST", "/v1/graphql", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	s.ServeHTTP(rec, req)
+	if rec.Code == http.StatusOK {
+		t.Errorf("an oversized GraphQL body should be refused: %s", rec.Body)
+	}
+}
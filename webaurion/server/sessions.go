@@ -0,0 +1,149 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/CorentinMre/isengo/webaurion"
+	"github.com/CorentinMre/isengo/webaurion/cache"
+	"github.com/CorentinMre/isengo/webaurion/campus"
+)
+
+// ErrUnknownSession is returned for a token that doesn't match a live session.
+var ErrUnknownSession = errors.New("unknown or expired session")
+
+// LoginFunc opens a WebAurion session.
+type LoginFunc func(username, password string) (*webaurion.WebAurion, error)
+
+// DefaultLogin logs in to ISEN-Ouest with the login form.
+func DefaultLogin(username, password string) (*webaurion.WebAurion, error) {
+	return NewLogin(campus.Default, nil)(username, password)
+}
+
+// NewLogin returns a LoginFunc logging in to a campus (a key or a JSON profile file, see
+// campus.Load) with an authenticator, the login form of the campus when nil.
+func NewLogin(campusKey string, authenticator webaurion.Authenticator) LoginFunc {
+	_, loadErr := campus.Load(campusKey)
+	return func(username, password string) (*webaurion.WebAurion, error) {
+		if loadErr != nil {
+			return nil, loadErr
+		}
+		w, err := webaurion.NewWebAurionForCampus(campusKey)
+		if err != nil {
+			return nil, err
+		}
+		w.Authenticator = authenticator
+		if _, err := w.Login(username, password); err != nil {
+			return nil, err
+		}
+		return w, nil
+	}
+}
+
+// Session is a logged in WebAurion client shared by the requests of one token.
+// Its Cache runs the WebAurion requests one at a time, since the client keeps a JSF ViewState.
+type Session struct {
+	Cache     *cache.Cache
+	Username  string
+	CreatedAt time.Time
+	lastUsed  time.Time
+}
+
+// Do runs f with the WebAurion client of the session, one call at a time.
+func (s *Session) Do(f func(w *webaurion.WebAurion) error) error {
+	return s.Cache.Do(f)
+}
+
+// SessionPool maps session tokens to WebAurion sessions.
+type SessionPool struct {
+	mu          sync.Mutex
+	sessions    map[string]*Session
+	login       LoginFunc
+	IdleTimeout time.Duration
+	CacheConfig cache.Config // cache settings of the new sessions
+}
+
+// NewSessionPool creates a new instance of SessionPool. A nil login uses DefaultLogin.
+func NewSessionPool(login LoginFunc, idleTimeout time.Duration) *SessionPool {
+	if login == nil {
+		login = DefaultLogin
+	}
+	return &SessionPool{
+		sessions:    make(map[string]*Session),
+		login:       login,
+		IdleTimeout: idleTimeout,
+		CacheConfig: cache.DefaultConfig(),
+	}
+}
+
+// Open logs in and returns the token of the new session.
+func (p *SessionPool) Open(username, password string) (string, *Session, error) {
+	client, err := p.login(username, password)
+	if err != nil {
+		return "", nil, err
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", nil, err
+	}
+	token := hex.EncodeToString(raw)
+
+	now := time.Now()
+	session := &Session{
+		Cache:     cache.New(client, p.CacheConfig),
+		Username:  username,
+		CreatedAt: now,
+		lastUsed:  now,
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.expire(now)
+	p.sessions[token] = session
+	return token, session, nil
+}
+
+// Get returns the session of a token.
+func (p *SessionPool) Get(token string) (*Session, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	p.expire(now)
+	session, ok := p.sessions[token]
+	if !ok {
+		return nil, ErrUnknownSession
+	}
+	session.lastUsed = now
+	return session, nil
+}
+
+// Close forgets the session of a token.
+func (p *SessionPool) Close(token string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.sessions, token)
+}
+
+// Len returns the number of live sessions.
+func (p *SessionPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.sessions)
+}
+
+// expire drops the sessions idle for longer than IdleTimeout. The caller must hold p.mu.
+func (p *SessionPool) expire(now time.Time) {
+	if p.IdleTimeout <= 0 {
+		return
+	}
+	for token, session := range p.sessions {
+		if now.Sub(session.lastUsed) > p.IdleTimeout {
+			delete(p.sessions, token)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package webaurion
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/CorentinMre/isengo/webaurion/menu"
+	"github.com/PuerkitoBio/goquery"
+)
+
+// sidebarPage loads the main page and parses its sidebar, the submenus aren't loaded yet.
+// The ViewState of the page becomes the one of the session for the submenu requests.
+func (w *WebAurion) sidebarPage() (*menu.Menu, error) {
+	req, err := http.NewRequest("GET", w.BaseURL+"/webAurion/", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	w.setRequestHeaders(req)
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error loading page: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading body: %v", err)
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing HTML: %v", err)
+	}
+	viewState, err := w.getViewState(bytes.NewReader(body), false)
+	if err != nil {
+		return nil, fmt.Errorf("error getting ViewState: %v", err)
+	}
+	w.setViewState(viewState)
+	return menu.Parse(doc), nil
+}
+
+// expandSubmenu loads the children of a submenu (WebAurion renders them on demand).
+func (w *WebAurion) expandSubmenu(item *menu.Item) error {
+	profile := w.campus()
+	button, sidebarSelect := profile.Components.SidebarButton, profile.Components.SidebarSelect
+
+	// like the browser, the whole form of the main page is posted: the state of its
+	// components (page of a datatable, view of the schedule...) goes with the AJAX fields
+	payload := w.pageForm()
+	payload.Set("javax.faces.partial.ajax", "true")
+	payload.Set("javax.faces.source", button)
+	payload.Set("javax.faces.partial.execute", button)
+	payload.Set("javax.faces.partial.render", "form:sidebar")
+	payload.Set(button, button)
+	payload.Set("webscolaapp.Sidebar.ID_SUBMENU", item.SubmenuID)
+	payload.Set("form", "form")
+	payload.Set("form:largeurDivCenter", w.PayloadValue("form:largeurDivCenter"))
+	payload.Set("form:idInit", w.PayloadValue("form:idInit"))
+	payload.Set("form:sauvegarde", "")
+	payload.Set(sidebarSelect+"_focus", "")
+	payload.Set(sidebarSelect+"_input", w.PayloadValue(profile.Components.RoleSelect+"_input"))
+
+	response, err := w.PostPartial("/webAurion/faces/MainMenuPage.xhtml", payload)
+	if err != nil {
+		return fmt.Errorf("error loading submenu: %v", err)
+	}
+	// the response renders the sidebar again, with the submenu open
+	content, ok := response.Update("form:sidebar")
+	if !ok {
+		return fmt.Errorf("sidebar not found in submenu response")
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("error parsing submenu HTML: %v", err)
+	}
+	loaded := menu.Parse(doc).Submenu(item.SubmenuID)
+	if loaded == nil {
+		return fmt.Errorf("submenu %s not found in response", item.SubmenuID)
+	}
+	item.Children = loaded.Children
+	return nil
+}
+
+// Menu returns the whole sidebar, with every submenu loaded.
+func (w *WebAurion) Menu() (*menu.Menu, error) {
+	sidebar, err := w.sidebarPage()
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := map[string]bool{}
+	pending := append([]*menu.Item{}, sidebar.Items...)
+	for len(pending) > 0 {
+		item := pending[0]
+		pending = pending[1:]
+		if item.IsSubmenu() && len(item.Children) == 0 && !loaded[item.SubmenuID] {
+			loaded[item.SubmenuID] = true
+			if err := w.expandSubmenu(item); err != nil {
+				return nil, fmt.Errorf("error loading menu %q: %v", item.Label, err)
+			}
+		}
+		pending = append(pending, item.Children...)
+	}
+
+	w.LastRequetTime = time.Now()
+	return sidebar, nil
+}
+
+// MenuItem returns the item of the sidebar at path (labels, e.g. "Divers", "Catalogue des
+// stages"), only the submenus on the path are loaded.
+func (w *WebAurion) MenuItem(path ...string) (*menu.Item, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("empty menu path")
+	}
+	sidebar, err := w.sidebarPage()
+	if err != nil {
+		return nil, err
+	}
+
+	items := sidebar.Items
+	var item *menu.Item
+	for depth, label := range path {
+		current := &menu.Menu{Items: items}
+		if item, err = current.Find(label); err != nil {
+			return nil, fmt.Errorf("menu %q not found", strings.Join(path[:depth+1], " > "))
+		}
+		if item.IsSubmenu() && len(item.Children) == 0 {
+			if err := w.expandSubmenu(item); err != nil {
+				return nil, fmt.Errorf("error loading menu %q: %v", item.Label, err)
+			}
+		}
+		items = item.Children
+	}
+
+	w.LastRequetTime = time.Now()
+	return item, nil
+}
+
+// Navigate opens the page of the sidebar at path and returns its document, e.g.
+// Navigate("Scolarité", "Mes inscriptions") for a page isengo doesn't wrap.
+func (w *WebAurion) Navigate(path ...string) (*goquery.Document, error) {
+	item, err := w.MenuItem(path...)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	switch {
+	case len(item.Params) > 0 || item.MenuID != "":
+		data, err = w.DoRequest(itemPayload(w.Payload, item))
+	case item.Href != "":
+		data, err = w.get(w.absoluteURL(item.Href))
+	case item.IsSubmenu():
+		return nil, fmt.Errorf("%q is a submenu, not a page", item.Label)
+	default:
+		return nil, fmt.Errorf("menu %q has no target", item.Label)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening %q: %v", item.Label, err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %q: %v", item.Label, err)
+	}
+	w.LastRequetTime = time.Now()
+	return doc, nil
+}
+
+// itemPayload returns the payload opening a page of the sidebar: the parameters of its
+// onclick, or the menuid of the sidebar when it has none.
+func itemPayload(payload string, item *menu.Item) string {
+	params := url.Values{}
+	for name, value := range item.Params {
+		params.Set(name, value)
+	}
+	if len(params) == 0 {
+		params.Set("form:sidebar", "form:sidebar")
+		params.Set("form:sidebar_menuid", item.MenuID)
+	}
+	return payload + "&" + params.Encode()
+}
+
+// get loads a page of WebAurion with the session.
+func (w *WebAurion) get(target string) ([]byte, error) {
+	req, err := http.NewRequest("GET", target, nil)
+	if err != nil {
+		return nil, err
+	}
+	w.setRequestHeaders(req)
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
@@ -0,0 +1,109 @@
+package webaurion
+
+import "testing"
+
+func TestMenuItemPostsPageState(t *testing.T) {
+	f := newFakeWebAurion(t)
+	w := f.client(CookieLogin{Cookie: "s1"})
+	if _, err := w.Login("", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := w.MenuItem("Divers", "Catalogue des stages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.MenuID != "6_0" {
+		t.Errorf("got menu ID %q", item.MenuID)
+	}
+
+	if len(f.posts) != 1 {
+		t.Fatalf("got %d posts, want 1", len(f.posts))
+	}
+	post := f.posts[0]
+	want := map[string]string{
+		"webscolaapp.Sidebar.ID_SUBMENU": "submenu_6",
+		"form:largeurDivCenter":          "1200",
+		"form:idInit":                    "init-1",
+		"form:j_idt774:j_idt776_page":    "0",
+		"form:j_idt822:j_idt825_view":    "basicDay",
+		"form:j_idt837_input":            "275805",
+		"javax.faces.ViewState":          "vs-1",
+		"javax.faces.partial.ajax":       "true",
+		"javax.faces.partial.render":     "form:sidebar",
+	}
+	for name, value := range want {
+		if got := post.Get(name); got != value {
+			t.Errorf("%s: got %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestLinksSkipEmptyLabels(t *testing.T) {
+	f := newFakeWebAurion(t)
+	w := f.client(CookieLogin{Cookie: "s1"})
+	w.Campus.Menu.Profile = "" // Validate refuses it, but it mustn't match every link
+	w.Campus.Menu.Grades = "Mes notes"
+	if _, err := w.Login("", ""); err != nil {
+		t.Fatal(err)
+	}
+	if w.UserInfoLink != "" || w.GradeLink != "form:grades" || w.AbsenceLink != "form:absences" || w.PlanningLink != "form:planning" {
+		t.Errorf("unexpected links: profile=%q grades=%q absences=%q planning=%q", w.UserInfoLink, w.GradeLink, w.AbsenceLink, w.PlanningLink)
+	}
+}
+
+func TestLinksExactLabelWins(t *testing.T) {
+	f := newFakeWebAurion(t)
+	w := f.client(CookieLogin{Cookie: "s1"})
+	w.Campus.Menu.Grades = "mes notes"
+	if _, err := w.Login("", ""); err != nil {
+		t.Fatal(err)
+	}
+	if w.GradeLink != "form:grades" {
+		t.Errorf("got grade link %q, want form:grades", w.GradeLink)
+	}
+}
+
+func TestNavigatePostsItemParams(t *testing.T) {
+	f := newFakeWebAurion(t)
+	w := f.client(CookieLogin{Cookie: "s1"})
+	if _, err := w.Login("", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	doc, err := w.Navigate("Divers", "Catalogue des stages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title := doc.Find("h1").Text(); title != "Catalogue des stages" {
+		t.Errorf("got page %q", title)
+	}
+
+	post := f.posts[len(f.posts)-1]
+	want := map[string]string{
+		"form:sidebar":          "form:sidebar",
+		"form:sidebar_menuid":   "6_0",
+		"form:idInit":           "init-1",
+		"javax.faces.ViewState": "vs-2", // of the submenu response
+	}
+	for name, value := range want {
+		if got := post.Get(name); got != value {
+			t.Errorf("%s: got %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestPayloadValue(t *testing.T) {
+	w := NewWebAurion()
+	w.Payload = "form=form&form%3AidInit=a%2Bb&form:largeurDivCenter=1200&form:idInit=other"
+	tests := map[string]string{
+		"form:idInit":           "a+b",
+		"form:largeurDivCenter": "1200",
+		"form:missing":          "",
+	}
+	for name, want := range tests {
+		if got := w.PayloadValue(name); got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package webaurion
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MaxGrade is the highest grade WebAurion can give (grades are out of 20).
+const MaxGrade = 20.0
+
+// CoefficientTable maps an evaluation code (or a code prefix, e.g. a module code)
+// to its weight. Codes that are not in the table weigh 1.
+type CoefficientTable map[string]float64
+
+// Coefficient returns the weight of an evaluation code.
+// An exact match wins, otherwise the longest matching prefix is used.
+func (ct CoefficientTable) Coefficient(code string) float64 {
+	if coef, ok := ct[code]; ok {
+		return coef
+	}
+
+	coef, longest := 1.0, 0
+	for prefix, value := range ct {
+		if len(prefix) > longest && strings.HasPrefix(code, prefix) {
+			coef, longest = value, len(prefix)
+		}
+	}
+	return coef
+}
+
+// ModuleCode returns the module an evaluation belongs to, i.e. its code
+// without the last "_" separated part ("..._MATH_DS1" -> "..._MATH").
+func ModuleCode(code string) string {
+	if i := strings.LastIndex(code, "_"); i > 0 {
+		return code[:i]
+	}
+	return code
+}
+
+// GradeSimulator computes "what-if" averages on top of a GradeReport.
+type GradeSimulator struct {
+	Report       *GradeReport
+	Coefficients CoefficientTable
+}
+
+// NewGradeSimulator creates a new instance of GradeSimulator.
+// A nil coefficient table gives every evaluation the same weight.
+func NewGradeSimulator(report *GradeReport, coefficients CoefficientTable) *GradeSimulator {
+	if report == nil {
+		report = NewGradeReport(0, nil)
+	}
+	if coefficients == nil {
+		coefficients = CoefficientTable{}
+	}
+	return &GradeSimulator{
+		Report:       report,
+		Coefficients: coefficients,
+	}
+}
+
+// SimulationResult represents the averages obtained with hypothetical grades.
+type SimulationResult struct {
+	Average float64            `json:"average"`
+	Modules map[string]float64 `json:"modules"`
+	Grades  []Grade            `json:"data"`
+}
+
+// String returns a string representation of the SimulationResult.
+func (sr *SimulationResult) String() string {
+	return fmt.Sprintf("SimulationResult(average=%f, modules=%v, data=%v)", sr.Average, sr.Modules, sr.Grades)
+}
+
+// Get gets the value of a specific key for the SimulationResult.
+func (sr *SimulationResult) Get(key string) (interface{}, error) {
+	switch key {
+	case "average":
+		return sr.Average, nil
+	case "modules":
+		return sr.Modules, nil
+	case "data":
+		return sr.Grades, nil
+	default:
+		return nil, fmt.Errorf("invalid key: %s, valid keys are 'average', 'modules' and 'data'", key)
+	}
+}
+
+func (sr *SimulationResult) JSON() string {
+	data, err := json.MarshalIndent(sr, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("Error marshaling to JSON: %v", err)
+	}
+	return string(data)
+}
+
+// Simulate returns the averages obtained if the hypothetical grades (by evaluation code)
+// were added to the report. A code already present in the report replaces its grade.
+func (s *GradeSimulator) Simulate(hypothetical map[string]float64) (*SimulationResult, error) {
+	grades := make([]Grade, 0, len(s.Report.Grades)+len(hypothetical))
+	seen := make(map[string]bool)
+
+	for _, grade := range s.Report.Grades {
+		if value, ok := hypothetical[grade.Code]; ok {
+			grade.Grade = value
+			grade.Absence = false
+			seen[grade.Code] = true
+		}
+		grades = append(grades, grade)
+	}
+
+	// keep a stable order for the evaluations that are not in the report yet
+	codes := make([]string, 0, len(hypothetical))
+	for code := range hypothetical {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		value := hypothetical[code]
+		if value < 0 || value > MaxGrade {
+			return nil, fmt.Errorf("invalid grade for %s: %.2f (must be between 0 and %.0f)", code, value, MaxGrade)
+		}
+		if !seen[code] {
+			grades = append(grades, Grade{Code: code, Name: code, Grade: value})
+		}
+	}
+
+	modules := make(map[string]float64)
+	for module, moduleGrades := range groupByModule(grades) {
+		modules[module], _ = s.weightedAverage(moduleGrades)
+	}
+
+	average, _ := s.weightedAverage(grades)
+	return &SimulationResult{
+		Average: average,
+		Modules: modules,
+		Grades:  grades,
+	}, nil
+}
+
+// RequiredGrade returns the minimum grade to get on every remaining evaluation
+// (by code) for the overall average to reach target.
+func (s *GradeSimulator) RequiredGrade(target float64, remaining ...string) (float64, error) {
+	return s.requiredGrade(s.Report.Grades, target, remaining)
+}
+
+// RequiredModuleGrade is like RequiredGrade but only considers the grades of one module.
+// The remaining evaluations must belong to the module.
+func (s *GradeSimulator) RequiredModuleGrade(module string, target float64, remaining ...string) (float64, error) {
+	for _, code := range remaining {
+		if ModuleCode(code) != module {
+			return 0, fmt.Errorf("evaluation %s is not in module %s", code, module)
+		}
+	}
+
+	var grades []Grade
+	for _, grade := range s.Report.Grades {
+		if ModuleCode(grade.Code) == module {
+			grades = append(grades, grade)
+		}
+	}
+	return s.requiredGrade(grades, target, remaining)
+}
+
+func (s *GradeSimulator) requiredGrade(grades []Grade, target float64, remaining []string) (float64, error) {
+	if len(remaining) == 0 {
+		return 0, fmt.Errorf("no remaining evaluation to solve for")
+	}
+	if target < 0 || target > MaxGrade {
+		return 0, fmt.Errorf("invalid target average: %.2f (must be between 0 and %.0f)", target, MaxGrade)
+	}
+
+	// ignore the remaining evaluations that already have a (placeholder) grade
+	pending := make(map[string]bool)
+	for _, code := range remaining {
+		pending[code] = true
+	}
+
+	var sum, weight float64
+	for _, grade := range grades {
+		if grade.Absence || pending[grade.Code] {
+			continue
+		}
+		coef := s.Coefficients.Coefficient(grade.Code)
+		sum += grade.Grade * coef
+		weight += coef
+	}
+
+	var remainingWeight float64
+	for code := range pending {
+		remainingWeight += s.Coefficients.Coefficient(code)
+	}
+	if remainingWeight <= 0 {
+		return 0, fmt.Errorf("remaining evaluations have no weight")
+	}
+
+	// (sum + x*remainingWeight) / (weight + remainingWeight) >= target
+	required := (target*(weight+remainingWeight) - sum) / remainingWeight
+	if required > MaxGrade {
+		return required, fmt.Errorf("target %.2f is unreachable: %.2f required on the remaining evaluations", target, required)
+	}
+	if required < 0 {
+		required = 0
+	}
+	return required, nil
+}
+
+// weightedAverage returns the weighted average of the grades (absences excluded)
+// and whether at least one grade was counted.
+func (s *GradeSimulator) weightedAverage(grades []Grade) (float64, bool) {
+	var sum, weight float64
+	for _, grade := range grades {
+		if grade.Absence {
+			continue
+		}
+		coef := s.Coefficients.Coefficient(grade.Code)
+		sum += grade.Grade * coef
+		weight += coef
+	}
+	if weight == 0 {
+		return 0, false
+	}
+	return sum / weight, true
+}
+
+func groupByModule(grades []Grade) map[string][]Grade {
+	modules := make(map[string][]Grade)
+	for _, grade := range grades {
+		module := ModuleCode(grade.Code)
+		modules[module] = append(modules[module], grade)
+	}
+	return modules
+}
@@ -0,0 +1,130 @@
+package webaurion
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCoefficient(t *testing.T) {
+	table := CoefficientTable{
+		"CIR2_MATH":        2,
+		"CIR2_MATH_ALG":    3,
+		"CIR2_MATH_ALG_DS": 4,
+		"CIR2_INFO_JAVA":   0.5,
+	}
+	tests := []struct {
+		code string
+		want float64
+	}{
+		{"CIR2_MATH_ALG_DS", 4},  // exact
+		{"CIR2_MATH_ALG_TP1", 3}, // longest prefix
+		{"CIR2_MATH_ANA_DS1", 2},
+		{"CIR2_INFO_JAVA", 0.5},
+		{"CIR2_INFO_C_DS1", 1}, // not in the table
+	}
+	for _, tt := range tests {
+		if got := table.Coefficient(tt.code); got != tt.want {
+			t.Errorf("Coefficient(%q) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
+
+// simulatorReport is a report with two modules, MATH (coefficients 1 and 2) and INFO.
+func simulatorReport() *GradeSimulator {
+	report := NewGradeReport(0, []Grade{
+		{Code: "CIR2_MATH_DS1", Grade: 8},
+		{Code: "CIR2_MATH_DS2", Grade: 14},
+		{Code: "CIR2_INFO_TP1", Grade: 18},
+		{Code: "CIR2_INFO_TP2", Absence: true},
+	})
+	return NewGradeSimulator(report, CoefficientTable{"CIR2_MATH_DS2": 2})
+}
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestSimulate(t *testing.T) {
+	s := simulatorReport()
+	result, err := s.Simulate(map[string]float64{"CIR2_MATH_DS1": 11, "CIR2_INFO_TP3": 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// MATH: (11 + 14*2) / 3, INFO: (18 + 12) / 2, the absence doesn't count
+	if !approx(result.Modules["CIR2_MATH"], 13) || !approx(result.Modules["CIR2_INFO"], 15) {
+		t.Errorf("got modules %v", result.Modules)
+	}
+	if !approx(result.Average, (11+28+18+12)/5.0) {
+		t.Errorf("got average %v", result.Average)
+	}
+	if len(result.Grades) != 5 || result.Grades[4].Code != "CIR2_INFO_TP3" {
+		t.Errorf("got grades %+v", result.Grades)
+	}
+	if s.Report.Grades[0].Grade != 8 {
+		t.Error("Simulate shouldn't change the report")
+	}
+
+	if _, err := s.Simulate(map[string]float64{"CIR2_MATH_DS3": 21}); err == nil {
+		t.Error("Simulate should refuse a grade above 20")
+	}
+}
+
+func TestRequiredGrade(t *testing.T) {
+	tests := []struct {
+		name      string
+		module    string
+		target    float64
+		remaining []string
+		want      float64
+		err       string
+	}{
+		// (8 + 28 + 18 + x) / 5 = 12
+		{"overall", "", 12, []string{"CIR2_INFO_TP3"}, 6, ""},
+		// (8 + 28 + x) / 4 = 10
+		{"module", "CIR2_MATH", 10, []string{"CIR2_MATH_DS3"}, 4, ""},
+		// DS2 already has a grade but is solved for: (8 + x + x*2) / 4 = 10
+		{"placeholder grade", "CIR2_MATH", 10, []string{"CIR2_MATH_DS3", "CIR2_MATH_DS2"}, 32.0 / 3, ""},
+		{"already reached", "", 5, []string{"CIR2_INFO_TP3"}, 0, ""},
+		{"impossible", "CIR2_MATH", 19, []string{"CIR2_MATH_DS3"}, 40, "unreachable"},
+		{"other module", "CIR2_MATH", 12, []string{"CIR2_INFO_TP3"}, 0, "not in module"},
+		{"nothing remaining", "", 12, nil, 0, "no remaining"},
+		{"invalid target", "", 21, []string{"CIR2_INFO_TP3"}, 0, "invalid target"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := simulatorReport()
+			var got float64
+			var err error
+			if tt.module == "" {
+				got, err = s.RequiredGrade(tt.target, tt.remaining...)
+			} else {
+				got, err = s.RequiredModuleGrade(tt.module, tt.target, tt.remaining...)
+			}
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("got %v, want an error with %q", err, tt.err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if !approx(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequiredModuleGradeIgnoresOtherModules(t *testing.T) {
+	s := simulatorReport()
+	// a very low grade in another module doesn't change the grade required in MATH
+	s.Report.Grades = append(s.Report.Grades, Grade{Code: "CIR2_INFO_DS1", Grade: 0})
+	got, err := s.RequiredModuleGrade("CIR2_MATH", 12, "CIR2_MATH_DS3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// (8 + 28 + x) / 4 = 12
+	if !approx(got, 12) {
+		t.Errorf("got %v, want 12", got)
+	}
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// migrations upgrade the database from one schema version to the next:
+// migrations[i] moves a database from version i to version i+1.
+var migrations = []func(tx *bolt.Tx) error{
+	// 0 -> 1: initial schema
+	func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketGrades, bucketAbsences, bucketEvents, bucketCatalogEntries, bucketSnapshots, bucketChanges} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// SchemaVersion is the schema version of the stores written by this package.
+var SchemaVersion = len(migrations)
+
+// schemaVersion returns the version stored in the meta bucket, 0 for a new database.
+func schemaVersion(tx *bolt.Tx) int {
+	meta := tx.Bucket(bucketMeta)
+	if meta == nil {
+		return 0
+	}
+	version, _ := strconv.Atoi(string(meta.Get(keyVersion)))
+	return version
+}
+
+// migrate applies the missing migrations, in the transaction of Open: a failed migration
+// leaves the database as it was.
+func migrate(tx *bolt.Tx) error {
+	version := schemaVersion(tx)
+	if version > SchemaVersion {
+		return fmt.Errorf("store schema version %d is newer than supported version %d", version, SchemaVersion)
+	}
+
+	meta, err := tx.CreateBucketIfNotExists(bucketMeta)
+	if err != nil {
+		return fmt.Errorf("error creating store: %v", err)
+	}
+	for ; version < SchemaVersion; version++ {
+		if err := migrations[version](tx); err != nil {
+			return fmt.Errorf("error migrating store to version %d: %v", version+1, err)
+		}
+		if err := meta.Put(keyVersion, []byte(strconv.Itoa(version+1))); err != nil {
+			return fmt.Errorf("error migrating store to version %d: %v", version+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/CorentinMre/isengo/webaurion"
+	cat "github.com/CorentinMre/isengo/webaurion/catalog"
+)
+
+// gradeReport returns the active grades as a report.
+func gradeReport(records []GradeRecord) *webaurion.GradeReport {
+	grades := []webaurion.Grade{}
+	for _, record := range records {
+		if record.Active() {
+			grades = append(grades, record.Grade)
+		}
+	}
+	return webaurion.NewGradeReport(0, grades)
+}
+
+// absenceReport returns the active absences as a report.
+func absenceReport(records []AbsenceRecord) *webaurion.AbsenceReport {
+	absences := []webaurion.Absence{}
+	for _, record := range records {
+		if record.Active() {
+			absences = append(absences, record.Absence)
+		}
+	}
+	return webaurion.NewAbsenceReport(len(absences), 0, absences)
+}
+
+// planningReport returns the active events as a report.
+func planningReport(records []EventRecord) *webaurion.PlanningReport {
+	events := []webaurion.Event{}
+	for _, record := range records {
+		if record.Active() {
+			events = append(events, record.Event)
+		}
+	}
+	return webaurion.NewPlanningReport(events)
+}
+
+// catalogReport returns the active entries of a catalog as a report.
+func catalogReport(records []CatalogEntryRecord) *cat.CatalogReport {
+	entries := []cat.CatalogEntry{}
+	for _, record := range records {
+		if record.Active() {
+			entries = append(entries, record.Entry)
+		}
+	}
+	return cat.NewCatalogReport(entries)
+}
+
+// readRecords returns the records of a bucket first seen at or after since (zero for all),
+// in the order they were first seen. A missing bucket has no records.
+func readRecords[R any](bucket *bolt.Bucket, since time.Time, seen func(*R) *Seen) ([]R, error) {
+	records := []R{}
+	if bucket == nil {
+		return records, nil
+	}
+	err := bucket.ForEach(func(k, v []byte) error {
+		var record R
+		if err := json.Unmarshal(v, &record); err != nil {
+			return fmt.Errorf("error decoding record %x: %v", k, err)
+		}
+		if !seen(&record).FirstSeen.Before(since) {
+			records = append(records, record)
+		}
+		return nil
+	})
+	return records, err
+}
+
+// listRecords reads the records of a top-level bucket, see readRecords.
+func listRecords[R any](s *Store, name []byte, since time.Time, seen func(*R) *Seen) ([]R, error) {
+	var records []R
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		records, err = readRecords(tx.Bucket(name), since, seen)
+		return err
+	})
+	return records, err
+}
+
+// Grades returns every grade record, including removed ones.
+func (s *Store) Grades() ([]GradeRecord, error) {
+	return listRecords(s, bucketGrades, time.Time{}, gradeSeen)
+}
+
+// GradesAddedSince returns the grades first seen at or after t.
+func (s *Store) GradesAddedSince(t time.Time) ([]GradeRecord, error) {
+	return listRecords(s, bucketGrades, t, gradeSeen)
+}
+
+// Absences returns every absence record, including removed ones.
+func (s *Store) Absences() ([]AbsenceRecord, error) {
+	return listRecords(s, bucketAbsences, time.Time{}, absenceSeen)
+}
+
+// AbsencesAddedSince returns the absences first seen at or after t.
+func (s *Store) AbsencesAddedSince(t time.Time) ([]AbsenceRecord, error) {
+	return listRecords(s, bucketAbsences, t, absenceSeen)
+}
+
+// Events returns every event record, including removed ones.
+func (s *Store) Events() ([]EventRecord, error) {
+	return listRecords(s, bucketEvents, time.Time{}, eventSeen)
+}
+
+// EventsAddedSince returns the events first seen at or after t.
+func (s *Store) EventsAddedSince(t time.Time) ([]EventRecord, error) {
+	return listRecords(s, bucketEvents, t, eventSeen)
+}
+
+// CatalogEntries returns every entry record of a catalog, including removed ones.
+func (s *Store) CatalogEntries(name string) ([]CatalogEntryRecord, error) {
+	return s.CatalogEntriesAddedSince(name, time.Time{})
+}
+
+// CatalogEntriesAddedSince returns the entries of a catalog first seen at or after t.
+func (s *Store) CatalogEntriesAddedSince(name string, t time.Time) ([]CatalogEntryRecord, error) {
+	var records []CatalogEntryRecord
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		records, err = readRecords(tx.Bucket(bucketCatalogEntries).Bucket([]byte(name)), t, catalogEntrySeen)
+		return err
+	})
+	return records, err
+}
+
+// readTimed returns the values of the streams of kind in bucket (snapshots or changes)
+// taken between from and to (a zero bound is open), oldest first.
+func readTimed[T any](s *Store, bucket []byte, kind Kind, from, to time.Time, at func(T) time.Time) ([]T, error) {
+	values := []T{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).ForEachBucket(func(name []byte) error {
+			if !streamOf(name, kind) {
+				return nil
+			}
+			c := tx.Bucket(bucket).Bucket(name).Cursor()
+			k, v := c.First()
+			if !from.IsZero() {
+				k, v = c.Seek(timeKey(from, 0))
+			}
+			for ; k != nil; k, v = c.Next() {
+				var value T
+				if err := json.Unmarshal(v, &value); err != nil {
+					return fmt.Errorf("error decoding %s %x: %v", bucket, k, err)
+				}
+				if !to.IsZero() && at(value).After(to) {
+					break
+				}
+				values = append(values, value)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the catalogs have a stream each
+	sort.SliceStable(values, func(i, j int) bool { return at(values[i]).Before(at(values[j])) })
+	return values, nil
+}
+
+// Snapshots returns the raw snapshots of a resource taken between from and to (a zero bound is open).
+func (s *Store) Snapshots(kind Kind, from, to time.Time) ([]Snapshot, error) {
+	return readTimed(s, bucketSnapshots, kind, from, to, func(snapshot Snapshot) time.Time { return snapshot.At })
+}
+
+// Changes returns the changes of a resource recorded between from and to (a zero bound is open).
+func (s *Store) Changes(kind Kind, from, to time.Time) ([]Change, error) {
+	return readTimed(s, bucketChanges, kind, from, to, func(change Change) time.Time { return change.At })
+}
+
+// PlanningChange is a decoded planning Change.
+type PlanningChange struct {
+	At      time.Time                  `json:"at"`
+	Changes *webaurion.PlanningChanges `json:"changes"`
+}
+
+// PlanningChanges returns the planning changes recorded between from and to (a zero bound is open).
+func (s *Store) PlanningChanges(from, to time.Time) ([]PlanningChange, error) {
+	recorded, err := s.Changes(KindPlanning, from, to)
+	if err != nil {
+		return nil, err
+	}
+	changes := []PlanningChange{}
+	for _, change := range recorded {
+		decoded := &webaurion.PlanningChanges{}
+		if err := json.Unmarshal(change.Changes, decoded); err != nil {
+			return nil, fmt.Errorf("error decoding planning changes of %s: %v", change.At, err)
+		}
+		changes = append(changes, PlanningChange{At: change.At, Changes: decoded})
+	}
+	return changes, nil
+}
+
+// PlanningChangesThisWeek returns the planning changes recorded since the Monday of the week of now.
+func (s *Store) PlanningChangesThisWeek(now time.Time) ([]PlanningChange, error) {
+	return s.PlanningChanges(StartOfWeek(now), time.Time{})
+}
+
+// StartOfWeek returns the Monday 00:00 of the week of t, in the location of t.
+func StartOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7 // days since Monday
+	year, month, day := t.Date()
+	return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,243 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/CorentinMre/isengo/webaurion"
+	cat "github.com/CorentinMre/isengo/webaurion/catalog"
+)
+
+// merge updates the records of a resource with a new snapshot taken at t:
+// records still present are touched (and updated), missing ones are marked removed,
+// and items that were not known yet are appended as new records.
+func merge[R any, T any](records []R, items []T, t time.Time,
+	seen func(*R) *Seen, recordKey func(R) string, itemKey func(T) string,
+	update func(*R, T), create func(T) R) []R {
+
+	pending := make(map[string][]T)
+	for _, item := range items {
+		key := itemKey(item)
+		pending[key] = append(pending[key], item)
+	}
+
+	for i := range records {
+		record := &records[i]
+		if !seen(record).Active() {
+			continue
+		}
+
+		key := recordKey(*record)
+		if matches := pending[key]; len(matches) > 0 {
+			update(record, matches[0])
+			seen(record).LastSeen = t
+			pending[key] = matches[1:]
+			continue
+		}
+
+		removed := t
+		seen(record).RemovedAt = &removed
+	}
+
+	for _, item := range items {
+		key := itemKey(item)
+		if matches := pending[key]; len(matches) > 0 {
+			records = append(records, create(matches[0]))
+			pending[key] = matches[1:]
+		}
+	}
+	return records
+}
+
+func newSeen(t time.Time) Seen {
+	return Seen{FirstSeen: t, LastSeen: t}
+}
+
+// activeRecords reads the records of bucket still present in the last snapshot, with their keys.
+func activeRecords[R any](bucket *bolt.Bucket, seen func(*R) *Seen) ([]R, [][]byte, error) {
+	var records []R
+	var keys [][]byte
+	err := bucket.ForEach(func(k, v []byte) error {
+		var record R
+		if err := json.Unmarshal(v, &record); err != nil {
+			return fmt.Errorf("error decoding record %x: %v", k, err)
+		}
+		if seen(&record).Active() {
+			records = append(records, record)
+			keys = append(keys, bytes.Clone(k))
+		}
+		return nil
+	})
+	return records, keys, err
+}
+
+// putRecords writes the records merged from activeRecords: the known ones under their keys,
+// the new ones under the next sequence number.
+func putRecords[R any](bucket *bolt.Bucket, records []R, keys [][]byte) error {
+	for i, record := range records {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("error encoding record: %v", err)
+		}
+		var key []byte
+		if i < len(keys) {
+			key = keys[i]
+		} else {
+			seq, err := bucket.NextSequence()
+			if err != nil {
+				return fmt.Errorf("error writing record: %v", err)
+			}
+			key = itob(seq)
+		}
+		if err := bucket.Put(key, data); err != nil {
+			return fmt.Errorf("error writing record: %v", err)
+		}
+	}
+	return nil
+}
+
+func gradeSeen(r *GradeRecord) *Seen               { return &r.Seen }
+func absenceSeen(r *AbsenceRecord) *Seen           { return &r.Seen }
+func eventSeen(r *EventRecord) *Seen               { return &r.Seen }
+func catalogEntrySeen(r *CatalogEntryRecord) *Seen { return &r.Seen }
+
+// SaveGrades records a grade snapshot taken at t and returns what changed since the previous one.
+func (s *Store) SaveGrades(report *webaurion.GradeReport, t time.Time) (*webaurion.GradeChanges, error) {
+	if report == nil {
+		return nil, fmt.Errorf("nil grade report")
+	}
+
+	var changes *webaurion.GradeChanges
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketGrades)
+		records, keys, err := activeRecords(bucket, gradeSeen)
+		if err != nil {
+			return err
+		}
+
+		changes = webaurion.DiffGrades(gradeReport(records), report)
+		records = merge(records, report.Grades, t, gradeSeen,
+			func(r GradeRecord) string { return webaurion.GradeKey(r.Grade) },
+			webaurion.GradeKey,
+			func(r *GradeRecord, g webaurion.Grade) { r.Grade = g },
+			func(g webaurion.Grade) GradeRecord { return GradeRecord{Grade: g, Seen: newSeen(t)} },
+		)
+		if err := putRecords(bucket, records, keys); err != nil {
+			return err
+		}
+		return s.record(tx, KindGrades, "", t, report, changes, changes.IsEmpty())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// SaveAbsences records an absence snapshot taken at t and returns what changed since the previous one.
+func (s *Store) SaveAbsences(report *webaurion.AbsenceReport, t time.Time) (*webaurion.AbsenceChanges, error) {
+	if report == nil {
+		return nil, fmt.Errorf("nil absence report")
+	}
+
+	var changes *webaurion.AbsenceChanges
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketAbsences)
+		records, keys, err := activeRecords(bucket, absenceSeen)
+		if err != nil {
+			return err
+		}
+
+		changes = webaurion.DiffAbsences(absenceReport(records), report)
+		records = merge(records, report.Data, t, absenceSeen,
+			func(r AbsenceRecord) string { return webaurion.AbsenceKey(r.Absence) },
+			webaurion.AbsenceKey,
+			func(r *AbsenceRecord, a webaurion.Absence) { r.Absence = a },
+			func(a webaurion.Absence) AbsenceRecord { return AbsenceRecord{Absence: a, Seen: newSeen(t)} },
+		)
+		if err := putRecords(bucket, records, keys); err != nil {
+			return err
+		}
+		return s.record(tx, KindAbsences, "", t, report, changes, changes.IsEmpty())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// SavePlanning records a planning snapshot taken at t and returns what changed since the previous one.
+func (s *Store) SavePlanning(report *webaurion.PlanningReport, t time.Time) (*webaurion.PlanningChanges, error) {
+	if report == nil {
+		return nil, fmt.Errorf("nil planning report")
+	}
+
+	var changes *webaurion.PlanningChanges
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketEvents)
+		records, keys, err := activeRecords(bucket, eventSeen)
+		if err != nil {
+			return err
+		}
+
+		changes = webaurion.DiffPlanning(planningReport(records), report)
+		records = merge(records, report.Events, t, eventSeen,
+			func(r EventRecord) string { return r.Event.ID },
+			func(e webaurion.Event) string { return e.ID },
+			func(r *EventRecord, e webaurion.Event) { r.Event = e },
+			func(e webaurion.Event) EventRecord { return EventRecord{Event: e, Seen: newSeen(t)} },
+		)
+		if err := putRecords(bucket, records, keys); err != nil {
+			return err
+		}
+		return s.record(tx, KindPlanning, "", t, report, changes, changes.IsEmpty())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// SaveCatalog records a snapshot of the named catalog taken at t and returns what changed since the previous one.
+func (s *Store) SaveCatalog(name string, report *cat.CatalogReport, t time.Time) (*webaurion.CatalogChanges, error) {
+	if report == nil {
+		return nil, fmt.Errorf("nil catalog report")
+	}
+	if name == "" {
+		return nil, fmt.Errorf("missing catalog name")
+	}
+
+	var changes *webaurion.CatalogChanges
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		// the entries of each catalog have their own bucket
+		bucket, err := tx.Bucket(bucketCatalogEntries).CreateBucketIfNotExists([]byte(name))
+		if err != nil {
+			return fmt.Errorf("error creating catalog %s: %v", name, err)
+		}
+		records, keys, err := activeRecords(bucket, catalogEntrySeen)
+		if err != nil {
+			return err
+		}
+
+		changes = webaurion.DiffCatalog(catalogReport(records), report)
+		records = merge(records, report.Entries, t, catalogEntrySeen,
+			func(r CatalogEntryRecord) string { return webaurion.CatalogEntryKey(r.Entry) },
+			webaurion.CatalogEntryKey,
+			func(r *CatalogEntryRecord, e cat.CatalogEntry) { r.Entry = e },
+			func(e cat.CatalogEntry) CatalogEntryRecord {
+				return CatalogEntryRecord{Catalog: name, Entry: e, Seen: newSeen(t)}
+			},
+		)
+		if err := putRecords(bucket, records, keys); err != nil {
+			return err
+		}
+		return s.record(tx, KindCatalog, name, t, report, changes, changes.IsEmpty())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
@@ -0,0 +1,240 @@
+// Package store keeps a local history of WebAurion snapshots (grades, absences,
+// planning and catalogs) in an embedded bbolt database, so changes can be queried over time.
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/CorentinMre/isengo/webaurion"
+	cat "github.com/CorentinMre/isengo/webaurion/catalog"
+)
+
+// Kind identifies the resource a snapshot or a change was taken from.
+type Kind string
+
+const (
+	KindGrades   Kind = "grades"
+	KindAbsences Kind = "absences"
+	KindPlanning Kind = "planning"
+	KindCatalog  Kind = "catalog"
+)
+
+// DefaultSnapshotLimit is the number of raw snapshots kept per resource.
+const DefaultSnapshotLimit = 50
+
+// Seen holds the first/last time a record was present in a snapshot.
+// RemovedAt is set once the record disappears from the snapshots.
+type Seen struct {
+	FirstSeen time.Time  `json:"firstSeen"`
+	LastSeen  time.Time  `json:"lastSeen"`
+	RemovedAt *time.Time `json:"removedAt,omitempty"`
+}
+
+// Active reports whether the record is still present in the latest snapshot.
+func (s *Seen) Active() bool {
+	return s.RemovedAt == nil
+}
+
+// GradeRecord is a grade with its history timestamps.
+type GradeRecord struct {
+	Grade webaurion.Grade `json:"grade"`
+	Seen
+}
+
+// AbsenceRecord is an absence with its history timestamps.
+type AbsenceRecord struct {
+	Absence webaurion.Absence `json:"absence"`
+	Seen
+}
+
+// EventRecord is a planning event with its history timestamps.
+type EventRecord struct {
+	Event webaurion.Event `json:"event"`
+	Seen
+}
+
+// CatalogEntryRecord is a catalog entry with its history timestamps.
+type CatalogEntryRecord struct {
+	Catalog string           `json:"catalog"`
+	Entry   cat.CatalogEntry `json:"entry"`
+	Seen
+}
+
+// Snapshot is a raw report as it was saved.
+type Snapshot struct {
+	Kind    Kind            `json:"kind"`
+	Catalog string          `json:"catalog,omitempty"`
+	At      time.Time       `json:"at"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Change is the diff computed when a snapshot was saved.
+type Change struct {
+	Kind    Kind            `json:"kind"`
+	Catalog string          `json:"catalog,omitempty"`
+	At      time.Time       `json:"at"`
+	Changes json.RawMessage `json:"changes"`
+}
+
+// Buckets (the tables) of the database. The records are keyed by a sequence number, in
+// the order they were first seen. The snapshots and the changes have a nested bucket per
+// stream ("grades", "catalog/<name>"...) keyed by time, see timeKey.
+var (
+	bucketMeta           = []byte("meta")
+	bucketGrades         = []byte("grades")
+	bucketAbsences       = []byte("absences")
+	bucketEvents         = []byte("events")
+	bucketCatalogEntries = []byte("catalogEntries") // a nested bucket per catalog
+	bucketSnapshots      = []byte("snapshots")
+	bucketChanges        = []byte("changes")
+
+	keyVersion = []byte("version")
+)
+
+// Store persists WebAurion snapshots in a bbolt database. It is safe for concurrent use,
+// and the database can only be opened by one process at a time.
+type Store struct {
+	db            *bolt.DB
+	temp          string // file of an in-memory store, removed by Close
+	SnapshotLimit int
+}
+
+// Open opens (or creates) the store at path and migrates it to the current schema.
+// An empty path gives a temporary store, deleted by Close.
+func Open(path string) (*Store, error) {
+	s := &Store{SnapshotLimit: DefaultSnapshotLimit}
+	if path == "" {
+		tmp, err := os.CreateTemp("", "isengo-store-*.db")
+		if err != nil {
+			return nil, fmt.Errorf("error creating store: %v", err)
+		}
+		tmp.Close()
+		path, s.temp = tmp.Name(), tmp.Name()
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		s.Close()
+		return nil, fmt.Errorf("error opening store: %v", err)
+	}
+	s.db = db
+
+	if err := db.Update(migrate); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	var err error
+	if s.db != nil {
+		err = s.db.Close()
+	}
+	if s.temp != "" {
+		os.Remove(s.temp)
+	}
+	return err
+}
+
+// Version returns the schema version of the store.
+func (s *Store) Version() int {
+	version := 0
+	s.db.View(func(tx *bolt.Tx) error {
+		version = schemaVersion(tx)
+		return nil
+	})
+	return version
+}
+
+// itob encodes a sequence number as a key, in order.
+func itob(n uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, n)
+	return key
+}
+
+// timeKey is the key of a snapshot or change taken at t, ordered by time. The sequence
+// number keeps apart the entries taken at the same time.
+func timeKey(t time.Time, seq uint64) []byte {
+	return append(itob(uint64(t.UnixNano())), itob(seq)...)
+}
+
+// stream returns the name of the nested bucket of the snapshots and changes of a resource.
+func stream(kind Kind, catalog string) []byte {
+	if kind == KindCatalog {
+		return []byte(string(kind) + "/" + catalog)
+	}
+	return []byte(kind)
+}
+
+// streamOf reports whether the stream name is one of kind.
+func streamOf(name []byte, kind Kind) bool {
+	return bytes.Equal(name, []byte(kind)) || bytes.HasPrefix(name, []byte(string(kind)+"/"))
+}
+
+// record appends a raw snapshot and its diff (unless empty), dropping the oldest snapshots
+// of the resource past the limit.
+func (s *Store) record(tx *bolt.Tx, kind Kind, catalog string, at time.Time, report, changes interface{}, empty bool) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("error encoding snapshot: %v", err)
+	}
+	snapshots, err := tx.Bucket(bucketSnapshots).CreateBucketIfNotExists(stream(kind, catalog))
+	if err != nil {
+		return fmt.Errorf("error writing snapshot: %v", err)
+	}
+	if err := putTimed(snapshots, at, Snapshot{Kind: kind, Catalog: catalog, At: at, Data: data}); err != nil {
+		return fmt.Errorf("error writing snapshot: %v", err)
+	}
+
+	if !empty {
+		diff, err := json.Marshal(changes)
+		if err != nil {
+			return fmt.Errorf("error encoding changes: %v", err)
+		}
+		bucket, err := tx.Bucket(bucketChanges).CreateBucketIfNotExists(stream(kind, catalog))
+		if err != nil {
+			return fmt.Errorf("error writing changes: %v", err)
+		}
+		if err := putTimed(bucket, at, Change{Kind: kind, Catalog: catalog, At: at, Changes: diff}); err != nil {
+			return fmt.Errorf("error writing changes: %v", err)
+		}
+	}
+
+	if s.SnapshotLimit > 0 {
+		// the keys are collected first, a cursor skips entries after a delete
+		var keys [][]byte
+		c := snapshots.Cursor()
+		for k, _ := c.First(); k != nil; k, _ = c.Next() {
+			keys = append(keys, bytes.Clone(k))
+		}
+		for _, k := range keys[:max(len(keys)-s.SnapshotLimit, 0)] {
+			if err := snapshots.Delete(k); err != nil {
+				return fmt.Errorf("error dropping snapshot: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// putTimed stores value in bucket under the time key of at.
+func putTimed(bucket *bolt.Bucket, at time.Time, value interface{}) error {
+	seq, err := bucket.NextSequence()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(timeKey(at, seq), data)
+}
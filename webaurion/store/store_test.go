@@ -0,0 +1,214 @@
+package store
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/CorentinMre/isengo/webaurion"
+	cat "github.com/CorentinMre/isengo/webaurion/catalog"
+)
+
+func grades(codes ...string) *webaurion.GradeReport {
+	var list []webaurion.Grade
+	for _, code := range codes {
+		list = append(list, webaurion.Grade{Code: code, Name: code, Grade: 12})
+	}
+	return webaurion.NewGradeReport(12, list)
+}
+
+func openTemp(t *testing.T) (*Store, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "isengo.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, path
+}
+
+func TestSaveGradesHistory(t *testing.T) {
+	s, path := openTemp(t)
+	t1 := time.Date(2025, 1, 6, 8, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+
+	if _, err := s.SaveGrades(grades("A", "B"), t1); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := s.SaveGrades(grades("A", "C"), t2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes.Added) != 1 || changes.Added[0].Code != "C" || len(changes.Removed) != 1 || changes.Removed[0].Code != "B" {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+
+	// the records survive a reopen
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	records, err := s.Grades()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3", len(records))
+	}
+	for _, record := range records {
+		switch record.Grade.Code {
+		case "A":
+			if !record.Active() || !record.FirstSeen.Equal(t1) || !record.LastSeen.Equal(t2) {
+				t.Errorf("A: unexpected history %+v", record.Seen)
+			}
+		case "B":
+			if record.Active() || !record.RemovedAt.Equal(t2) {
+				t.Errorf("B: should be removed at %s, got %+v", t2, record.Seen)
+			}
+		}
+	}
+
+	added, err := s.GradesAddedSince(t2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 1 || added[0].Grade.Code != "C" {
+		t.Errorf("GradesAddedSince: got %+v", added)
+	}
+
+	// a snapshot without change records no Change
+	if _, err := s.SaveGrades(grades("A", "C"), t2.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := s.Changes(KindGrades, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded) != 2 {
+		t.Errorf("got %d changes, want 2", len(recorded))
+	}
+}
+
+func TestSnapshotLimitAndRange(t *testing.T) {
+	s, _ := openTemp(t)
+	defer s.Close()
+	s.SnapshotLimit = 3
+
+	start := time.Date(2025, 1, 6, 8, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		if _, err := s.SaveGrades(grades("A", strconv.Itoa(i)), start.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshots, err := s.Snapshots(KindGrades, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 3 || !snapshots[0].At.Equal(start.Add(2*time.Hour)) {
+		t.Fatalf("want the 3 latest snapshots, got %d from %v", len(snapshots), snapshots[0].At)
+	}
+
+	snapshots, err = s.Snapshots(KindGrades, start.Add(3*time.Hour), start.Add(3*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 || !snapshots[0].At.Equal(start.Add(3*time.Hour)) {
+		t.Errorf("range: got %+v", snapshots)
+	}
+}
+
+func TestCatalogStreams(t *testing.T) {
+	s, _ := openTemp(t)
+	defer s.Close()
+
+	t1 := time.Date(2025, 1, 6, 8, 0, 0, 0, time.UTC)
+	first := cat.NewCatalogReport([]cat.CatalogEntry{{Company: "Acme", City: "Brest"}})
+	second := cat.NewCatalogReport([]cat.CatalogEntry{{Company: "Globex", City: "Rennes"}})
+	if _, err := s.SaveCatalog("M1", first, t1.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SaveCatalog("M2", second, t1); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := s.CatalogEntries("M1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Entry.Company != "Acme" || entries[0].Catalog != "M1" {
+		t.Errorf("CatalogEntries: got %+v", entries)
+	}
+	if entries, _ := s.CatalogEntries("unknown"); len(entries) != 0 {
+		t.Errorf("unknown catalog: got %+v", entries)
+	}
+
+	// both catalogs, oldest first
+	snapshots, err := s.Snapshots(KindCatalog, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Catalog != "M2" || snapshots[1].Catalog != "M1" {
+		t.Errorf("Snapshots: got %+v", snapshots)
+	}
+}
+
+func TestNilReport(t *testing.T) {
+	s, _ := openTemp(t)
+	defer s.Close()
+
+	if _, err := s.SaveGrades(nil, time.Now()); err == nil {
+		t.Error("SaveGrades(nil) should fail")
+	}
+	if _, err := s.SaveAbsences(nil, time.Now()); err == nil {
+		t.Error("SaveAbsences(nil) should fail")
+	}
+	if _, err := s.SavePlanning(nil, time.Now()); err == nil {
+		t.Error("SavePlanning(nil) should fail")
+	}
+	if _, err := s.SaveCatalog("M1", nil, time.Now()); err == nil {
+		t.Error("SaveCatalog(nil) should fail")
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	s, path := openTemp(t)
+	if s.Version() != SchemaVersion {
+		t.Errorf("got version %d, want %d", s.Version(), SchemaVersion)
+	}
+	s.Close()
+
+	// a store written by a newer version is refused
+	db, err := bolt.Open(path, 0o600, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketMeta).Put(keyVersion, []byte(strconv.Itoa(SchemaVersion+1)))
+	})
+	db.Close()
+	if _, err := Open(path); err == nil {
+		t.Error("Open should refuse a newer schema")
+	}
+}
+
+func TestTemporaryStore(t *testing.T) {
+	s, err := Open("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.SaveGrades(grades("A"), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -11,9 +11,15 @@ import (
 	"net/url"
 	"strings"
 	"time"
+	"unicode"
 	// "os"
 	"github.com/PuerkitoBio/goquery"
+	"github.com/CorentinMre/isengo/webaurion/campus"
 	cat "github.com/CorentinMre/isengo/webaurion/catalog"
+	"github.com/CorentinMre/isengo/webaurion/menu"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
 )
 
 type WebAurion struct {
@@ -28,13 +34,16 @@ type WebAurion struct {
 	GradeLink        string
 	AbsenceLink      string
 	PlanningLink     string
-	// UserInfoLink	 string
+	UserInfoLink     string
+	PhotoURL         string
 	IdInit           string
 	IdBasic          string
 	Payload          string
 	ProxyEndpoints   []string
 	currentProxyIndex int
 	Catalogs         []cat.Catalog
+	Authenticator    Authenticator // how Login opens the session, FormLogin when nil
+	Campus           *campus.Campus // labels and component IDs of the deployment, BaseURL is its host
 }
 
 
@@ -46,8 +55,10 @@ func NewWebAurion() *WebAurion {
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
 		},
 	}
+	profile := campus.ISENOuest()
 	return &WebAurion{
-		BaseURL:           "https://web.isen-ouest.fr",
+		BaseURL:           profile.BaseURL,
+		Campus:            profile,
 		Link:              make(map[string]string),
 		LoggedIn:          false,
 		Client:            client,
@@ -57,6 +68,31 @@ func NewWebAurion() *WebAurion {
 }
 
 
+// NewWebAurionForCampus creates a client for a campus registered in the campus package.
+func NewWebAurionForCampus(key string) (*WebAurion, error) {
+	profile, err := campus.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	w := NewWebAurion()
+	w.Campus = profile
+	w.BaseURL = profile.BaseURL
+	return w, nil
+}
+
+// campus returns the campus of w, ISEN-Ouest when it isn't set.
+func (w *WebAurion) campus() *campus.Campus {
+	if w.Campus == nil {
+		w.Campus = campus.ISENOuest()
+	}
+	return w.Campus
+}
+
+// GetCampus returns the campus of w (implements catalog.WebAurionClient).
+func (w *WebAurion) GetCampus() *campus.Campus {
+	return w.campus()
+}
+
 func NewWebAurionWithProxies(proxyEndpoints []string) *WebAurion {
 	w := NewWebAurion()
 	w.SetProxies(proxyEndpoints)
@@ -179,6 +215,8 @@ func (w *WebAurion) Login(username, password string) (bool, error) {
 }
 
 
+// LoginWithRetry logs in, retrying up to maxRetries times (with the next proxy if any),
+// except with authenticators whose failures don't change, like CookieLogin.
 func (w *WebAurion) LoginWithRetry(username, password string, maxRetries int) (bool, error) {
 	var lastError error
 	
@@ -193,6 +231,9 @@ func (w *WebAurion) LoginWithRetry(username, password string, maxRetries int) (b
 		if success {
 			return true, nil
 		}
+		if !retryable(w.Authenticator) {
+			return false, err
+		}
 		
 		lastError = err
 		
@@ -206,35 +247,22 @@ func (w *WebAurion) LoginWithRetry(username, password string, maxRetries int) (b
 }
 
 func (w *WebAurion) performLogin(username, password string) (bool, error) {
-	payload := url.Values{}
-	payload.Set("username", username)
-	payload.Set("password", password)
-	payload.Set("j_idt27", "")
-
-	req, err := http.NewRequest("POST", w.BaseURL+"/webAurion/login", strings.NewReader(payload.Encode()))
-	if err != nil {
-		return false, errors.New("error creating login request")
+	auth := w.Authenticator
+	if auth == nil {
+		auth = FormLogin{}
 	}
-
-	w.setRequestHeaders(req)
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-
-	resp, err := w.Client.Do(req)
-	if err != nil {
-		return false, fmt.Errorf("error during login request: %v", err)
+	if err := auth.Authenticate(w, username, password); err != nil {
+		return false, err
 	}
-	defer resp.Body.Close()
-
-	w.Cookies = resp.Cookies()
 
 	// get the main page
-	req, err = http.NewRequest("GET", w.BaseURL+"/webAurion/", nil)
+	req, err := http.NewRequest("GET", w.BaseURL+"/webAurion/", nil)
 	if err != nil {
 		return false, errors.New("error creating main page request")
 	}
 	w.setRequestHeaders(req)
 
-	resp, err = w.Client.Do(req)
+	resp, err := w.Client.Do(req)
 	if err != nil {
 		return false, fmt.Errorf("error getting main page: %v", err)
 	}
@@ -242,7 +270,7 @@ func (w *WebAurion) performLogin(username, password string) (bool, error) {
 
 	w.ViewState, err = w.getViewState(resp.Body, true)
 	if err != nil {
-		return false, errors.New("username or password incorrect")
+		return false, loginError(auth)
 	}
 
 	w.LoggedIn = true
@@ -251,14 +279,20 @@ func (w *WebAurion) performLogin(username, password string) (bool, error) {
 }
 
 func (w *WebAurion) RemoveAccents(str string) string {
-	// todo better
-	replacer := strings.NewReplacer(
-		"á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u",
-		"à", "a", "è", "e", "ì", "i", "ò", "o", "ù", "u",
-		"â", "a", "ê", "e", "î", "i", "ô", "o", "û", "u",
-		"ä", "a", "ë", "e", "ï", "i", "ö", "o", "ü", "u",
-	)
-	return replacer.Replace(str)
+	return removeAccents(str)
+}
+
+// ligatures aren't decomposed by NFD
+var ligatureReplacer = strings.NewReplacer("œ", "oe", "Œ", "OE", "æ", "ae", "Æ", "AE", "ß", "ss")
+
+// removeAccents decomposes str (NFD) and drops the combining marks, "Élodie" becomes "Elodie".
+func removeAccents(str string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	result, _, err := transform.String(t, ligatureReplacer.Replace(str))
+	if err != nil {
+		return str
+	}
+	return result
 }
 
 func (w *WebAurion) DoRequest(payload string, referer ...string) ([]byte, error) {
@@ -331,28 +365,32 @@ func (w *WebAurion) getViewState(body io.Reader, first bool) (string, error) {
 	}
 
 	if first {
+		profile := w.campus()
 		w.Name = doc.Find("div.menuMonCompte h3").Text()
-		doc.Find("a.lien-cliquable").Each(func(i int, s *goquery.Selection) {
-			id, _ := s.Attr("id")
-			if strings.Contains(s.Text(), "note") {
-				w.GradeLink = id
-			} else if strings.Contains(s.Text(), "Absences") {
-				w.AbsenceLink = id
-			} else if strings.Contains(s.Text(), "Planning") {
-				w.PlanningLink = id
+		links := menu.ParseLinks(doc)
+		for link, label := range map[*string]string{
+			&w.UserInfoLink: profile.Menu.Profile,
+			&w.GradeLink:    profile.Menu.Grades,
+			&w.AbsenceLink:  profile.Menu.Absences,
+			&w.PlanningLink: profile.Menu.Planning,
+		} {
+			if item, err := links.Find(label); err == nil {
+				*link = item.Params["id"]
 			}
-		})
+		}
 
-		doc.Find("a.ui-menuitem-link")
+		if src, ok := doc.Find("div.menuMonCompte img").First().Attr("src"); ok {
+			w.PhotoURL = w.absoluteURL(src)
+		}
 
 		doc.Find("input").Each(func(i int, s *goquery.Selection) {
-			name, _ := s.Attr("name")
-			value, _ := s.Attr("value")
-			w.Payload += fmt.Sprintf("%s=%s&", name, value)
+			if name := s.AttrOr("name", ""); name != "" {
+				w.Payload += url.QueryEscape(name) + "=" + url.QueryEscape(s.AttrOr("value", "")) + "&"
+			}
 		})
 
 		w.IdBasic, _ = doc.Find("input[value='basicDay']").Attr("id")
-		w.Payload += "form:j_idt820_input=275805"
+		w.Payload += url.QueryEscape(profile.Components.RoleSelect+"_input") + "=" + url.QueryEscape(profile.Components.RoleValue)
 	}
 
 	viewState, exists := doc.Find("input[name='javax.faces.ViewState']").Attr("value")
@@ -375,19 +413,19 @@ func (w *WebAurion) setRequestHeaders(req *http.Request) {
 
 
 	if req.Method == "POST" && req.URL.Path == "/webAurion/login" {
-		req.Header.Set("Origin", "https://web.isen-ouest.fr")
-		req.Header.Set("Referer", "https://web.isen-ouest.fr/webAurion/faces/Login.xhtml")
+		req.Header.Set("Origin", w.BaseURL)
+		req.Header.Set("Referer", w.BaseURL+"/webAurion/faces/Login.xhtml")
 	} else if req.Method == "GET" {
 		// Pour les requêtes GET après login
 		req.Header.Set("Priority", "u=0, i")
 		if req.URL.Path == "/webAurion/" {
-			req.Header.Set("Referer", "https://web.isen-ouest.fr/webAurion/faces/Login.xhtml")
+			req.Header.Set("Referer", w.BaseURL+"/webAurion/faces/Login.xhtml")
 		} else {
-			req.Header.Set("Referer", "https://web.isen-ouest.fr/webAurion/")
+			req.Header.Set("Referer", w.BaseURL+"/webAurion/")
 		}
 	} else {
 		// Autres requêtes POST
-		req.Header.Set("Referer", "https://web.isen-ouest.fr/webAurion/")
+		req.Header.Set("Referer", w.BaseURL+"/webAurion/")
 	}
 }
 
@@ -408,6 +446,20 @@ func (w *WebAurion) GetViewState(reader io.Reader, isInitial bool) (string, erro
 	return w.getViewState(reader, isInitial)
 }
 
+// PayloadValue returns the value of a field of the payload of the main page (implements
+// catalog.WebAurionClient).
+func (w *WebAurion) PayloadValue(name string) string {
+	return w.pageForm().Get(name)
+}
+
+// pageForm returns the fields of the payload of the main page.
+func (w *WebAurion) pageForm() url.Values {
+	// the fields appended to the payload by the requests may repeat or be invalid, the
+	// first value of a field is the one of the page
+	form, _ := url.ParseQuery(w.Payload)
+	return form
+}
+
 func (w *WebAurion) GetPayload() string {
 	return w.Payload
 }
@@ -416,6 +468,10 @@ func (w *WebAurion) GetGradesPayload() string {
 	return fmt.Sprintf("%s&%s=%s", w.Payload, w.GradeLink, w.GradeLink)
 }
 
+func (w *WebAurion) GetUserInfoPayload() string {
+	return fmt.Sprintf("%s&%s=%s", w.Payload, w.UserInfoLink, w.UserInfoLink)
+}
+
 func (w *WebAurion) GetAbsencesPayload() string {
 	return fmt.Sprintf("%s&%s=%s", w.Payload, w.AbsenceLink, w.AbsenceLink)
 }
@@ -451,17 +507,50 @@ func (w *WebAurion) GetCatalogEntries(catalogIndex int) (*cat.CatalogReport, err
 	return cat.GetCatalogEntries(w, catalogIndex, w.Catalogs, w.DoRequest)
 }
 
+// GetCatalogEntriesWithQuery returns the entries of a catalog matching query, searched by WebAurion.
+func (w *WebAurion) GetCatalogEntriesWithQuery(catalogIndex int, query cat.CatalogQuery) (*cat.CatalogReport, error) {
+	return cat.GetCatalogEntriesWithQuery(w, catalogIndex, w.Catalogs, w.DoRequest, query)
+}
+
 func (w *WebAurion) GetCatalogEntryDetails(entry cat.CatalogEntry) (*cat.CatalogDetails, error) {
 	return cat.GetCatalogEntryDetails(w, entry)
 }
 
 func (w *WebAurion) GetPlanningPayload2(viewState string) string {
+	return w.planningForm(viewState).Encode()
+}
+
+// planningForm returns the fields of the AJAX request of the events of the schedule.
+func (w *WebAurion) planningForm(viewState string) url.Values {
 	startDate := time.Now().AddDate(0, -3, 0)
 	endDate := time.Now().AddDate(0, 10, 0)
 	startTimestamp := startDate.UnixNano() / int64(time.Millisecond)
 	endTimestamp := endDate.UnixNano() / int64(time.Millisecond)
 
-	return fmt.Sprintf("javax.faces.partial.ajax=true&javax.faces.source=form%%3Aj_idt118&javax.faces.partial.execute=form%%3Aj_idt118&javax.faces.partial.render=form%%3Aj_idt118&form%%3Aj_idt118=form%%3Aj_idt118&form%%3Aj_idt118_start=%d&form%%3Aj_idt118_end=%d&form=form&form%%3AlargeurDivCenter=&form%%3AidInit=%s&form%%3Adate_input=27%%2F05%%2F2024&form%%3Aweek=22-2024&form%%3Aj_idt118_view=agendaWeek&form%%3AoffsetFuseauNavigateur=-7200000&form%%3Aonglets_activeIndex=0&form%%3Aonglets_scrollState=0&form%%3Aj_idt244_focus=&form%%3Aj_idt244_input=275805&javax.faces.ViewState=%s", startTimestamp, endTimestamp, w.IdInit, viewState)
+	profile := w.campus()
+	_, offset := time.Now().In(profile.Location()).Zone()
+
+	payload := url.Values{}
+	payload.Set("javax.faces.partial.ajax", "true")
+	payload.Set("javax.faces.source", profile.Components.Schedule)
+	payload.Set("javax.faces.partial.execute", profile.Components.Schedule)
+	payload.Set("javax.faces.partial.render", profile.Components.Schedule)
+	payload.Set(profile.Components.Schedule, profile.Components.Schedule)
+	payload.Set(profile.Components.Schedule+"_start", fmt.Sprint(startTimestamp))
+	payload.Set(profile.Components.Schedule+"_end", fmt.Sprint(endTimestamp))
+	payload.Set("form", "form")
+	payload.Set("form:largeurDivCenter", "")
+	payload.Set("form:idInit", w.IdInit)
+	payload.Set("form:date_input", "27/05/2024")
+	payload.Set("form:week", "22-2024")
+	payload.Set(profile.Components.Schedule+"_view", "agendaWeek")
+	payload.Set("form:offsetFuseauNavigateur", fmt.Sprint(-offset*1000))
+	payload.Set("form:onglets_activeIndex", "0")
+	payload.Set("form:onglets_scrollState", "0")
+	payload.Set(profile.Components.PlanningForm+"_focus", "")
+	payload.Set(profile.Components.PlanningForm+"_input", profile.Components.RoleValue)
+	payload.Set("javax.faces.ViewState", viewState)
+	return payload
 }
 
 func (w *WebAurion) GetGrades() (*GradeReport, error) {
@@ -475,6 +564,7 @@ func (w *WebAurion) GetGrades() (*GradeReport, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error parsing grades: %v", err)
 	}
+	gradeReport.Location = w.campus().Location()
 
 	w.LastRequetTime = time.Now()
 	return gradeReport, nil
@@ -491,6 +581,7 @@ func (w *WebAurion) GetAbsences() (*AbsenceReport, error) {
 	if err != nil {
 		return nil, fmt.Errorf("error parsing absences: %v", err)
 	}
+	absenceReport.Location = w.campus().Location()
 
 	w.LastRequetTime = time.Now()
 	return absenceReport, nil
@@ -507,13 +598,13 @@ func (w *WebAurion) GetPlanning() (*PlanningReport, error) {
 		return nil, fmt.Errorf("error getting new view state: %v", err)
 	}
 
-	planningData, err := w.DoRequest(w.GetPlanningPayload2(newViewState), "/webAurion/faces/Planning.xhtml")
+	response, err := w.PostPartial("/webAurion/faces/Planning.xhtml", w.planningForm(newViewState))
 	if err != nil {
 		return nil, fmt.Errorf("error getting planning data: %v", err)
 	}
 
-	beautifulPlanning := &BeautifulPlanning{}
-	planningReport, err := beautifulPlanning.ParsePlanning(planningData)
+	beautifulPlanning := &BeautifulPlanning{ScheduleID: w.campus().Components.Schedule, Location: w.campus().Location()}
+	planningReport, err := beautifulPlanning.parseResponse(response)
 	if err != nil {
 		return nil, fmt.Errorf("error parsing planning data: %v", err)
 	}
@@ -522,26 +613,6 @@ func (w *WebAurion) GetPlanning() (*PlanningReport, error) {
 	return planningReport, nil
 }
 
-func (w *WebAurion) UserInfo() (*UserInfo, error) {
-	nameParts := strings.Fields(w.Name)
-	var firstName, lastName []string
-
-	for _, word := range nameParts {
-		if word == strings.ToUpper(word) {
-			lastName = append(lastName, word)
-		} else if string(word[0]) == strings.ToUpper(string(word[0])) {
-			firstName = append(firstName, word)
-		}
-	}
-
-	firstNameStr := strings.Join(firstName, " ")
-	lastNameStr := strings.Join(lastName, " ")
-	email := fmt.Sprintf("%s.%s@isen-ouest.yncrea.fr", strings.ToLower(strings.Join(firstName, "-")), strings.ToLower(strings.Join(lastName, "-")))
-
-	userInfo := NewUserInfo(firstNameStr, lastNameStr, w.Name, w.RemoveAccents(email))
-	return userInfo, nil
-}
-
 func (w *WebAurion) IsSessionValid() bool {
     if !w.LoggedIn {
         return false
@@ -561,7 +632,8 @@ func (w *WebAurion) IsSessionValid() bool {
     defer resp.Body.Close()
     
     
-    return resp.Request.URL.Path != "auth3.isen-ouest.fr" // if we go back to the login page, we are disconnected
+    // if we go back to the login page (or to the CAS), we are disconnected
+    return !sameHost(resp.Request.URL, w.campus().CASURL) && !strings.Contains(resp.Request.URL.Path, "Login")
 }
 
 func (w *WebAurion) Refresh() error {